@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"jam-tracker/internal/config"
+	"jam-tracker/internal/database"
+	"jam-tracker/internal/geo"
+	"jam-tracker/internal/models"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+const geocodeUsage = "usage: jam-tracker geocode"
+
+// runGeocode handles the geocode subcommand, which fills in the coordinates of
+// venues and user homes saved before they were geocoded
+func runGeocode(args []string) {
+	if len(args) != 0 {
+		log.Fatal(geocodeUsage)
+	}
+
+	config.LoadEnv()
+	db := database.Connect()
+	geocoder := geo.Connect()
+	ctx := context.Background()
+
+	var venues []models.Venue
+	if err := db.Where("latitude IS NULL OR longitude IS NULL").Order("name").Find(&venues).Error; err != nil {
+		log.Fatal("Failed to load venues:", err)
+	}
+
+	located := 0
+	for _, venue := range venues {
+		if geocodeRecord(ctx, db, geocoder, &venue, venue.Name, venue.Location, venue.Address, "latitude", "longitude") {
+			located++
+		}
+	}
+	fmt.Printf("%d of %d venue(s) geocoded\n", located, len(venues))
+
+	var users []models.User
+	if err := db.Where("home_city <> '' AND (home_latitude IS NULL OR home_longitude IS NULL)").
+		Order("username").Find(&users).Error; err != nil {
+		log.Fatal("Failed to load users:", err)
+	}
+
+	located = 0
+	for _, user := range users {
+		if geocodeRecord(ctx, db, geocoder, &user, user.Username, user.Location, "", "home_latitude", "home_longitude") {
+			located++
+		}
+	}
+	fmt.Printf("%d of %d user home(s) geocoded\n", located, len(users))
+}
+
+// geocodeRecord looks up a location and saves its coordinates into the given
+// columns of record. It returns false when the geocoder did not know the place.
+func geocodeRecord(ctx context.Context, db *gorm.DB, geocoder geo.Geocoder, record any, name string, location models.Location, street, latColumn, lngColumn string) bool {
+	point, err := geocoder.Geocode(ctx, geo.Address{
+		Street:  street,
+		City:    location.City,
+		State:   location.State,
+		Country: location.Country,
+	})
+	if errors.Is(err, geo.ErrNotFound) {
+		fmt.Printf("not found %s (%s, %s)\n", name, location.City, location.State)
+		return false
+	}
+	if err != nil {
+		log.Fatalf("Failed to geocode %s: %v", name, err)
+	}
+
+	if err := db.Model(record).Updates(map[string]any{latColumn: point.Lat, lngColumn: point.Lng}).Error; err != nil {
+		log.Fatalf("Failed to save %s: %v", name, err)
+	}
+	return true
+}
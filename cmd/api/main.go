@@ -3,19 +3,52 @@ package main
 import (
 	"jam-tracker/internal/config"
 	"jam-tracker/internal/database"
+	"jam-tracker/internal/geo"
 	"jam-tracker/internal/handlers"
+	"jam-tracker/internal/mailer"
+	"jam-tracker/internal/ratelimit"
+	"jam-tracker/internal/storage"
 	"log"
+	"os"
+	_ "time/tzdata" // Venue timezones work without zoneinfo files on the host
 
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
+	// Run a subcommand instead of the server
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			runMigrate(os.Args[2:])
+			return
+		case "role":
+			runRole(os.Args[2:])
+			return
+		case "geocode":
+			runGeocode(os.Args[2:])
+			return
+		}
+	}
+
 	// Load configuration
 	cfg := config.Load()
 
-	// Connect to database
-	database.Connect()
-	database.Migrate()
+	// Connect to database and apply pending migrations
+	db := database.Connect()
+	database.Migrate(db)
+
+	// Initialize media storage
+	storage.Connect()
+
+	// Initialize the mailer for password resets and email verification
+	mail := mailer.Connect()
+
+	// Initialize the rate limit store
+	rateLimits := ratelimit.Connect()
+
+	// Initialize the geocoder for venue coordinates
+	geocoder := geo.Connect()
 
 	// Set gin mode based on environment
 	if cfg.Environment == "production" {
@@ -25,12 +58,18 @@ func main() {
 	// Initialize router
 	router := gin.Default()
 
+	// Only believe X-Forwarded-For from our own proxies, or clients could pick
+	// the IP address rate limits and sessions see
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
+
 	// Add middleware
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
 
 	// Setup routes
-	handlers.SetupRoutes(router)
+	handlers.SetupRoutes(router, handlers.NewHandler(db, mail, geocoder, rateLimits))
 
 	// Start server
 	log.Printf("Starting server on port %s", cfg.Port)
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"jam-tracker/internal/config"
+	"jam-tracker/internal/database"
+	"jam-tracker/internal/models"
+	"jam-tracker/internal/repository"
+	"log"
+)
+
+const roleUsage = "usage: jam-tracker role <email> user|editor|admin"
+
+// runRole handles the role subcommand, which sets up the first admin
+func runRole(args []string) {
+	if len(args) != 2 {
+		log.Fatal(roleUsage)
+	}
+
+	role := models.Role(args[1])
+	if !role.Valid() {
+		log.Fatal(roleUsage)
+	}
+
+	config.LoadEnv()
+	users := repository.NewGormRepositories(database.Connect()).Users
+	ctx := context.Background()
+
+	user, err := users.FindByEmail(ctx, args[0])
+	if err != nil {
+		log.Fatalf("Failed to find user %s: %v", args[0], err)
+	}
+
+	user.Role = role
+	if err := users.Update(ctx, &user); err != nil {
+		log.Fatal("Failed to update role:", err)
+	}
+
+	fmt.Printf("%s is now %s\n", user.Email, user.Role)
+}
@@ -3,28 +3,35 @@ package config
 import (
 	"log"
 	"os"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
 
 // Config holds all configuration for our application
 type Config struct {
-	Port        string
-	JWTSecret   string
-	Environment string
+	Port           string
+	JWTSecret      string
+	Environment    string
+	TrustedProxies []string // Addresses or CIDRs of the proxies in front of the API, none by default
 }
 
-// Load loads configuratio from environment variables
-func Load() *Config {
-	// Load .env file if it exists (for local development)
+// LoadEnv loads the .env file if it exists (for local development)
+func LoadEnv() {
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found, relying on environment variables")
 	}
+}
+
+// Load loads configuratio from environment variables
+func Load() *Config {
+	LoadEnv()
 
 	config := &Config{
-		Port:        getEnv("PORT", "8080"),
-		JWTSecret:   getEnv("JWT_SECRET", ""),
-		Environment: getEnv("ENVIRONMENT", "development"),
+		Port:           getEnv("PORT", "8080"),
+		JWTSecret:      getEnv("JWT_SECRET", ""),
+		Environment:    getEnv("ENVIRONMENT", "development"),
+		TrustedProxies: splitList(getEnv("TRUSTED_PROXIES", "")),
 	}
 
 	if config.JWTSecret == "" {
@@ -34,6 +41,17 @@ func Load() *Config {
 	return config
 }
 
+// splitList reads a comma-separated list, leaving out empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
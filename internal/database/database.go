@@ -5,31 +5,26 @@ import (
 	"log"
 	"os"
 
-	"jam-tracker/internal/models"
-
 	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
-var DB *gorm.DB
-
-// Connect initializes the database connection
-func Connect() {
-	var err error
+// Connect opens the database connection selected by DB_DRIVER
+func Connect() *gorm.DB {
+	var dialector gorm.Dialector
 
-	// Database connection parameters
-	host := getEnv("DB_HOST", "localhost")
-	port := getEnv("DB_PORT", "5432")
-	user := getEnv("DB_USER", "jamtracker")
-	password := getEnv("DB_PASSWORD", "jamtracker123")
-	dbname := getEnv("DB_NAME", "jamtracker")
-	sslmode := getEnv("DB_SSLMODE", "disable")
-
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		host, port, user, password, dbname, sslmode)
+	switch driver := getEnv("DB_DRIVER", DriverPostgres); driver {
+	case DriverPostgres:
+		dialector = postgres.Open(postgresDSN())
+	case DriverSQLite:
+		dialector = sqlite.Open(sqliteDSN())
+	default:
+		log.Fatalf("Unsupported DB_DRIVER %q, must be %s or %s", driver, DriverPostgres, DriverSQLite)
+	}
 
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
+	db, err := gorm.Open(dialector, &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info), // Shows SQL queries in development
 	})
 
@@ -38,23 +33,36 @@ func Connect() {
 	}
 
 	log.Println("Database connected successfully!")
+	return db
 }
 
-// Migrate runs the auto migration for our models
-func Migrate() {
-	err := DB.AutoMigrate(
-		&models.User{},
-		&models.Band{},
-		&models.Venue{},
-		&models.Show{},
-		&models.ShowAttendance{},
-	)
+// postgresDSN builds the Postgres connection string from the DB_* variables
+func postgresDSN() string {
+	host := getEnv("DB_HOST", "localhost")
+	port := getEnv("DB_PORT", "5432")
+	user := getEnv("DB_USER", "jamtracker")
+	password := getEnv("DB_PASSWORD", "jamtracker123")
+	dbname := getEnv("DB_NAME", "jamtracker")
+	sslmode := getEnv("DB_SSLMODE", "disable")
+
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		host, port, user, password, dbname, sslmode)
+}
+
+// sqliteDSN opens the DB_PATH file with foreign keys enforced like in Postgres
+func sqliteDSN() string {
+	path := getEnv("DB_PATH", "jamtracker.db")
+	return path + "?_foreign_keys=on&_busy_timeout=5000"
+}
 
+// Migrate applies pending migrations at startup
+func Migrate(db *gorm.DB) {
+	applied, err := MigrateUp(db)
 	if err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
 
-	log.Println("Database migration completed!")
+	log.Printf("Database migration completed! Applied %d migration(s)", len(applied))
 }
 
 // Helper functions
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// Supported values of DB_DRIVER
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// Dialect writes the SQL fragments that differ between the supported databases.
+// Queries that compare dates, times or text should go through it so every
+// endpoint behaves the same on Postgres and SQLite.
+type Dialect string
+
+// DialectOf returns the dialect of a connection
+func DialectOf(db *gorm.DB) Dialect {
+	return Dialect(db.Dialector.Name())
+}
+
+// Date returns the UTC calendar day of a timestamp column, comparable to a YYYY-MM-DD string
+func (d Dialect) Date(column string) string {
+	if d == DriverSQLite {
+		// SQLite converts the stored offset to UTC
+		return fmt.Sprintf("DATE(%s)", column)
+	}
+	return fmt.Sprintf("DATE(%s AT TIME ZONE 'UTC')", column)
+}
+
+// Time wraps a timestamp column or placeholder so it compares chronologically.
+// SQLite stores times as text, which only sorts correctly within a single UTC offset.
+func (d Dialect) Time(expr string) string {
+	if d == DriverSQLite {
+		return fmt.Sprintf("julianday(%s)", expr)
+	}
+	return expr
+}
+
+// Contains returns a condition matching a column containing the placeholder argument, ignoring case.
+// Wrap the argument with ContainsPattern.
+func (d Dialect) Contains(column string) string {
+	if d == DriverSQLite {
+		// LIKE ignores case in SQLite
+		return column + " LIKE ?"
+	}
+	return column + " ILIKE ?"
+}
+
+// ContainsPattern returns the LIKE pattern for a Contains condition
+func ContainsPattern(value string) string {
+	return "%" + value + "%"
+}
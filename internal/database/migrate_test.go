@@ -0,0 +1,228 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"jam-tracker/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// schemaModels are the models the migrations must create tables for
+var schemaModels = []any{
+	&models.User{}, &models.Band{}, &models.Venue{}, &models.Show{}, &models.ShowPerformance{},
+	&models.Festival{}, &models.Tour{}, &models.TourShow{}, &models.Song{}, &models.Set{},
+	&models.SetlistEntry{}, &models.ShowAttendance{}, &models.CaughtPerformance{}, &models.Media{},
+	&models.Follow{}, &models.Session{}, &models.UserToken{}, &models.RecoveryCode{}, &models.ChangeRequest{},
+}
+
+func TestMigrationsPairUp(t *testing.T) {
+	postgresMigrations, err := Migrations(DriverPostgres)
+	if err != nil {
+		t.Fatalf("postgres migrations: %v", err)
+	}
+	sqliteMigrations, err := Migrations(DriverSQLite)
+	if err != nil {
+		t.Fatalf("sqlite migrations: %v", err)
+	}
+
+	// Both databases go through the same numbered steps
+	if len(postgresMigrations) != len(sqliteMigrations) {
+		t.Fatalf("%d postgres migrations, %d sqlite migrations", len(postgresMigrations), len(sqliteMigrations))
+	}
+	for i, migration := range postgresMigrations {
+		if migration.Version != i+1 {
+			t.Errorf("migration %04d_%s is number %d", migration.Version, migration.Name, i+1)
+		}
+		if other := sqliteMigrations[i]; other.Version != migration.Version || other.Name != migration.Name {
+			t.Errorf("postgres has %04d_%s where sqlite has %04d_%s", migration.Version, migration.Name, other.Version, other.Name)
+		}
+	}
+}
+
+func TestMigrateSQLite(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")+"?_foreign_keys=on"),
+		&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	testMigrateUpAndDown(t, db)
+}
+
+// TestMigratePostgres runs against the empty database in TEST_POSTGRES_DSN, e.g.
+// "host=localhost user=jamtracker password=jamtracker123 dbname=jamtracker_test"
+func TestMigratePostgres(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	testMigrateUpAndDown(t, db)
+}
+
+func testMigrateUpAndDown(t *testing.T, db *gorm.DB) {
+	t.Helper()
+
+	migrations, err := Migrations(DialectOf(db))
+	if err != nil {
+		t.Fatalf("migrations: %v", err)
+	}
+
+	applied, err := MigrateUp(db)
+	if err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	if len(applied) != len(migrations) {
+		t.Fatalf("applied %d migrations, want %d", len(applied), len(migrations))
+	}
+	checkSchema(t, db)
+
+	if applied, err := MigrateUp(db); err != nil || len(applied) != 0 {
+		t.Fatalf("migrating up again applied %d migrations, error %v, want none", len(applied), err)
+	}
+
+	// Every migration rolls back from the schema the newer ones left behind
+	for i := len(migrations) - 1; i >= 0; i-- {
+		rolledBack, err := MigrateDown(db, 1)
+		if err != nil {
+			t.Fatalf("migrate down: %v", err)
+		}
+		if len(rolledBack) != 1 || rolledBack[0].Version != migrations[i].Version {
+			t.Fatalf("rolled back %+v, want %04d_%s", rolledBack, migrations[i].Version, migrations[i].Name)
+		}
+	}
+
+	for _, model := range schemaModels {
+		if db.Migrator().HasTable(model) {
+			t.Errorf("table of %T is left after migrating all the way down", model)
+		}
+	}
+	statuses, err := MigrationStatuses(db)
+	if err != nil {
+		t.Fatalf("migration statuses: %v", err)
+	}
+	for _, status := range statuses {
+		if status.AppliedAt != nil {
+			t.Errorf("%04d_%s is still applied", status.Version, status.Name)
+		}
+	}
+
+	// And the way back up works again
+	if applied, err := MigrateUp(db); err != nil || len(applied) != len(migrations) {
+		t.Fatalf("migrating up again applied %d migrations, error %v, want %d", len(applied), err, len(migrations))
+	}
+	checkSchema(t, db)
+
+	if _, err := MigrateDown(db, len(migrations)); err != nil {
+		t.Fatalf("migrate down: %v", err)
+	}
+}
+
+// checkSchema fails the test for every model column the migrations did not create
+func checkSchema(t *testing.T, db *gorm.DB) {
+	t.Helper()
+
+	for _, model := range schemaModels {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			t.Fatalf("parse %T: %v", model, err)
+		}
+		if !db.Migrator().HasTable(stmt.Schema.Table) {
+			t.Errorf("no table %s for %T", stmt.Schema.Table, model)
+			continue
+		}
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName != "" && !db.Migrator().HasColumn(stmt.Schema.Table, field.DBName) {
+				t.Errorf("no column %s.%s for %T.%s", stmt.Schema.Table, field.DBName, model, field.Name)
+			}
+		}
+	}
+}
+
+func TestSetBandsMigrationKeepsSetlists(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")+"?_foreign_keys=on"),
+		&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	applied, err := MigrateUp(db)
+	if err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	since := len(applied)
+	for i, migration := range applied {
+		if migration.Version == 14 {
+			since = len(applied) - i
+		}
+	}
+	if _, err := MigrateDown(db, since); err != nil {
+		t.Fatalf("migrate down to before 0014_set_bands: %v", err)
+	}
+
+	band := models.Band{Name: "goose"}
+	venue := models.Venue{Name: "red rocks", Location: models.Location{City: "morrison", State: "co", Country: "usa"}, Timezone: "America/Denver"}
+	song := models.Song{Name: "arcadia", DisplayName: "Arcadia"}
+	for _, row := range []any{&band, &venue} {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatalf("create %T: %v", row, err)
+		}
+	}
+	show := models.Show{BandID: band.ID, VenueID: venue.ID, Date: time.Now(), LocalDate: "2025-09-10"}
+	song.BandID = band.ID
+	for _, row := range []any{&show, &song} {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatalf("create %T: %v", row, err)
+		}
+	}
+
+	// Sets have no band yet, so they are written by hand
+	setID, entryID := uuid.New(), uuid.New()
+	if err := db.Exec("INSERT INTO sets (id, show_id, name, position) VALUES (?, ?, 'Set 1', 1)", setID, show.ID).Error; err != nil {
+		t.Fatalf("create set: %v", err)
+	}
+	if err := db.Exec("INSERT INTO setlist_entries (id, show_id, set_id, song_id, position) VALUES (?, ?, ?, ?, 1)",
+		entryID, show.ID, setID, song.ID).Error; err != nil {
+		t.Fatalf("create setlist entry: %v", err)
+	}
+
+	if _, err := MigrateUp(db); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+
+	var set models.Set
+	if err := db.Preload("Entries").First(&set, "id = ?", setID).Error; err != nil {
+		t.Fatalf("load set: %v", err)
+	}
+	if set.BandID != band.ID || len(set.Entries) != 1 {
+		t.Errorf("set = %+v, want it played by the headliner with its entry kept", set)
+	}
+
+	// Like in Postgres, every set needs a band
+	err = db.Exec("INSERT INTO sets (id, show_id, name, position) VALUES (?, ?, 'Set 2', 2)", uuid.New(), show.ID).Error
+	if err == nil {
+		t.Error("created a set without a band")
+	}
+	err = db.Exec("INSERT INTO sets (id, show_id, band_id, name, position) VALUES (?, ?, ?, 'Set 2', 2)", uuid.New(), show.ID, uuid.New()).Error
+	if err == nil {
+		t.Error("created a set played by a band that does not exist")
+	}
+
+	if _, err := MigrateDown(db, since); err != nil {
+		t.Fatalf("migrate down: %v", err)
+	}
+	var entries int64
+	if err := db.Table("setlist_entries").Where("set_id = ?", setID).Count(&entries).Error; err != nil || entries != 1 {
+		t.Errorf("%d entries of the set after migrating down, error %v, want 1", entries, err)
+	}
+}
@@ -0,0 +1,157 @@
+CREATE TABLE IF NOT EXISTS users (
+    id uuid PRIMARY KEY,
+    email text NOT NULL,
+    username text NOT NULL,
+    password text NOT NULL,
+    first_name text,
+    last_name text,
+    location text,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username ON users (username);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+
+CREATE TABLE IF NOT EXISTS bands (
+    id uuid PRIMARY KEY,
+    name text NOT NULL,
+    genre text,
+    description text,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_bands_name ON bands (name);
+
+CREATE TABLE IF NOT EXISTS venues (
+    id uuid PRIMARY KEY,
+    name text NOT NULL,
+    city text NOT NULL,
+    state text NOT NULL,
+    country text DEFAULT 'USA',
+    address text,
+    capacity text,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_venues_name ON venues (name);
+
+CREATE TABLE IF NOT EXISTS shows (
+    id uuid PRIMARY KEY,
+    band_id uuid NOT NULL,
+    venue_id uuid NOT NULL,
+    date timestamptz NOT NULL,
+    setlist_id text,
+    notes text,
+    created_at timestamptz,
+    updated_at timestamptz,
+    CONSTRAINT fk_venues_shows FOREIGN KEY (venue_id) REFERENCES venues (id),
+    CONSTRAINT fk_bands_shows FOREIGN KEY (band_id) REFERENCES bands (id)
+);
+CREATE INDEX IF NOT EXISTS idx_shows_date ON shows (date);
+CREATE INDEX IF NOT EXISTS idx_shows_venue_id ON shows (venue_id);
+CREATE INDEX IF NOT EXISTS idx_shows_band_id ON shows (band_id);
+
+CREATE TABLE IF NOT EXISTS show_attendances (
+    id uuid PRIMARY KEY,
+    user_id uuid NOT NULL,
+    show_id uuid NOT NULL,
+    rating decimal,
+    favorite_song text,
+    notes text,
+    status text NOT NULL DEFAULT 'attended',
+    created_at timestamptz,
+    updated_at timestamptz,
+    CONSTRAINT fk_users_show_attendances FOREIGN KEY (user_id) REFERENCES users (id),
+    CONSTRAINT fk_shows_show_attendances FOREIGN KEY (show_id) REFERENCES shows (id),
+    CONSTRAINT chk_show_attendances_rating CHECK (rating >= 1 AND rating <= 5)
+);
+CREATE INDEX IF NOT EXISTS idx_show_attendances_show_id ON show_attendances (show_id);
+CREATE INDEX IF NOT EXISTS idx_show_attendances_user_id ON show_attendances (user_id);
+
+-- Databases created by AutoMigrate before attendance statuses still have the attended flag
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_name = 'show_attendances' AND column_name = 'attended') THEN
+        ALTER TABLE show_attendances ADD COLUMN IF NOT EXISTS status text NOT NULL DEFAULT 'attended';
+        UPDATE show_attendances SET status = 'interested' WHERE attended = false;
+        ALTER TABLE show_attendances DROP COLUMN attended;
+    END IF;
+END
+$$;
+CREATE INDEX IF NOT EXISTS idx_show_attendances_status ON show_attendances (status);
+
+CREATE TABLE IF NOT EXISTS songs (
+    id uuid PRIMARY KEY,
+    band_id uuid NOT NULL,
+    name text NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz,
+    CONSTRAINT fk_bands_songs FOREIGN KEY (band_id) REFERENCES bands (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_songs_band_name ON songs (band_id, name);
+
+CREATE TABLE IF NOT EXISTS sets (
+    id uuid PRIMARY KEY,
+    show_id uuid NOT NULL,
+    name text NOT NULL,
+    position bigint NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz,
+    CONSTRAINT fk_shows_sets FOREIGN KEY (show_id) REFERENCES shows (id)
+);
+CREATE INDEX IF NOT EXISTS idx_sets_show_id ON sets (show_id);
+
+CREATE TABLE IF NOT EXISTS setlist_entries (
+    id uuid PRIMARY KEY,
+    show_id uuid NOT NULL,
+    set_id uuid NOT NULL,
+    song_id uuid NOT NULL,
+    position bigint NOT NULL,
+    transition text NOT NULL DEFAULT 'stop',
+    duration_seconds bigint,
+    teases text,
+    jam_notes text,
+    created_at timestamptz,
+    updated_at timestamptz,
+    CONSTRAINT fk_sets_entries FOREIGN KEY (set_id) REFERENCES sets (id),
+    CONSTRAINT fk_setlist_entries_song FOREIGN KEY (song_id) REFERENCES songs (id)
+);
+CREATE INDEX IF NOT EXISTS idx_setlist_entries_song_id ON setlist_entries (song_id);
+CREATE INDEX IF NOT EXISTS idx_setlist_entries_set_id ON setlist_entries (set_id);
+CREATE INDEX IF NOT EXISTS idx_setlist_entries_show_id ON setlist_entries (show_id);
+
+CREATE TABLE IF NOT EXISTS follows (
+    id uuid PRIMARY KEY,
+    follower_id uuid NOT NULL,
+    followee_id uuid NOT NULL,
+    status text NOT NULL DEFAULT 'pending',
+    accepted_at timestamptz,
+    created_at timestamptz,
+    updated_at timestamptz,
+    CONSTRAINT fk_follows_follower FOREIGN KEY (follower_id) REFERENCES users (id),
+    CONSTRAINT fk_follows_followee FOREIGN KEY (followee_id) REFERENCES users (id)
+);
+CREATE INDEX IF NOT EXISTS idx_follows_status ON follows (status);
+CREATE INDEX IF NOT EXISTS idx_follows_followee_id ON follows (followee_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_follows_pair ON follows (follower_id, followee_id);
+
+CREATE TABLE IF NOT EXISTS media (
+    id uuid PRIMARY KEY,
+    attendance_id uuid NOT NULL,
+    user_id uuid NOT NULL,
+    kind text NOT NULL DEFAULT 'photo',
+    caption text,
+    content_type text NOT NULL,
+    size bigint NOT NULL,
+    width bigint,
+    height bigint,
+    taken_at timestamptz,
+    storage_key text NOT NULL,
+    thumbnail_key text NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz,
+    CONSTRAINT fk_show_attendances_media FOREIGN KEY (attendance_id) REFERENCES show_attendances (id)
+);
+CREATE INDEX IF NOT EXISTS idx_media_user_id ON media (user_id);
+CREATE INDEX IF NOT EXISTS idx_media_attendance_id ON media (attendance_id);
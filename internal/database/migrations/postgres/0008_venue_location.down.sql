@@ -0,0 +1,3 @@
+DROP INDEX idx_venues_location;
+ALTER TABLE venues DROP COLUMN longitude;
+ALTER TABLE venues DROP COLUMN latitude;
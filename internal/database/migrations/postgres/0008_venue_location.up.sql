@@ -0,0 +1,4 @@
+ALTER TABLE venues ADD COLUMN latitude double precision;
+ALTER TABLE venues ADD COLUMN longitude double precision;
+
+CREATE INDEX idx_venues_location ON venues (latitude, longitude);
@@ -0,0 +1,13 @@
+-- Venue locations stay lowercase, only the old default comes back
+ALTER TABLE venues ALTER COLUMN country SET DEFAULT 'USA';
+
+ALTER TABLE users ADD COLUMN location text;
+
+UPDATE users SET location = home_city || CASE WHEN home_state <> '' THEN ', ' || home_state ELSE '' END
+WHERE home_city <> '';
+
+ALTER TABLE users DROP COLUMN home_longitude;
+ALTER TABLE users DROP COLUMN home_latitude;
+ALTER TABLE users DROP COLUMN home_country;
+ALTER TABLE users DROP COLUMN home_state;
+ALTER TABLE users DROP COLUMN home_city;
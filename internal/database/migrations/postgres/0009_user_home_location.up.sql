@@ -0,0 +1,23 @@
+ALTER TABLE users ADD COLUMN home_city text NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN home_state text NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN home_country text NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN home_latitude double precision;
+ALTER TABLE users ADD COLUMN home_longitude double precision;
+
+-- Best effort: the free-text location was meant to be "City, State" or "City, State, Country"
+UPDATE users SET
+    home_city = lower(trim(split_part(location, ',', 1))),
+    home_state = lower(trim(split_part(location, ',', 2))),
+    home_country = lower(trim(split_part(location, ',', 3)))
+WHERE trim(coalesce(location, '')) <> '';
+UPDATE users SET home_country = 'usa' WHERE home_city <> '' AND home_country = '';
+
+ALTER TABLE users DROP COLUMN location;
+
+-- Venues store their location the same way, so they compare equal to home locations
+UPDATE venues SET
+    city = regexp_replace(lower(trim(city)), '\s+', ' ', 'g'),
+    state = regexp_replace(lower(trim(state)), '\s+', ' ', 'g'),
+    country = regexp_replace(lower(trim(coalesce(country, ''))), '\s+', ' ', 'g');
+UPDATE venues SET country = 'usa' WHERE country = '';
+ALTER TABLE venues ALTER COLUMN country SET DEFAULT 'usa';
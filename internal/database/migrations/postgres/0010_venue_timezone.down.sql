@@ -0,0 +1,3 @@
+DROP INDEX idx_shows_local_date;
+ALTER TABLE shows DROP COLUMN local_date;
+ALTER TABLE venues DROP COLUMN timezone;
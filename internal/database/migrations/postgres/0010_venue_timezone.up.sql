@@ -0,0 +1,105 @@
+ALTER TABLE venues ADD COLUMN timezone text NOT NULL DEFAULT 'UTC';
+ALTER TABLE shows ADD COLUMN local_date text NOT NULL DEFAULT '';
+
+-- Guess the timezone of existing venues the way new venues get theirs (geo.Timezone),
+-- from the state in the US and from the country elsewhere. Locations are lowercase by now.
+CREATE TEMP TABLE state_timezones (state text, name text, timezone text);
+INSERT INTO state_timezones VALUES
+    ('al', 'alabama', 'America/Chicago'),
+    ('ak', 'alaska', 'America/Anchorage'),
+    ('az', 'arizona', 'America/Phoenix'),
+    ('ar', 'arkansas', 'America/Chicago'),
+    ('ca', 'california', 'America/Los_Angeles'),
+    ('co', 'colorado', 'America/Denver'),
+    ('ct', 'connecticut', 'America/New_York'),
+    ('de', 'delaware', 'America/New_York'),
+    ('dc', 'district of columbia', 'America/New_York'),
+    ('fl', 'florida', 'America/New_York'),
+    ('ga', 'georgia', 'America/New_York'),
+    ('hi', 'hawaii', 'Pacific/Honolulu'),
+    ('id', 'idaho', 'America/Boise'),
+    ('il', 'illinois', 'America/Chicago'),
+    ('in', 'indiana', 'America/Indiana/Indianapolis'),
+    ('ia', 'iowa', 'America/Chicago'),
+    ('ks', 'kansas', 'America/Chicago'),
+    ('ky', 'kentucky', 'America/New_York'),
+    ('la', 'louisiana', 'America/Chicago'),
+    ('me', 'maine', 'America/New_York'),
+    ('md', 'maryland', 'America/New_York'),
+    ('ma', 'massachusetts', 'America/New_York'),
+    ('mi', 'michigan', 'America/Detroit'),
+    ('mn', 'minnesota', 'America/Chicago'),
+    ('ms', 'mississippi', 'America/Chicago'),
+    ('mo', 'missouri', 'America/Chicago'),
+    ('mt', 'montana', 'America/Denver'),
+    ('ne', 'nebraska', 'America/Chicago'),
+    ('nv', 'nevada', 'America/Los_Angeles'),
+    ('nh', 'new hampshire', 'America/New_York'),
+    ('nj', 'new jersey', 'America/New_York'),
+    ('nm', 'new mexico', 'America/Denver'),
+    ('ny', 'new york', 'America/New_York'),
+    ('nc', 'north carolina', 'America/New_York'),
+    ('nd', 'north dakota', 'America/Chicago'),
+    ('oh', 'ohio', 'America/New_York'),
+    ('ok', 'oklahoma', 'America/Chicago'),
+    ('or', 'oregon', 'America/Los_Angeles'),
+    ('pa', 'pennsylvania', 'America/New_York'),
+    ('ri', 'rhode island', 'America/New_York'),
+    ('sc', 'south carolina', 'America/New_York'),
+    ('sd', 'south dakota', 'America/Chicago'),
+    ('tn', 'tennessee', 'America/Chicago'),
+    ('tx', 'texas', 'America/Chicago'),
+    ('ut', 'utah', 'America/Denver'),
+    ('vt', 'vermont', 'America/New_York'),
+    ('va', 'virginia', 'America/New_York'),
+    ('wa', 'washington', 'America/Los_Angeles'),
+    ('wv', 'west virginia', 'America/New_York'),
+    ('wi', 'wisconsin', 'America/Chicago'),
+    ('wy', 'wyoming', 'America/Denver');
+
+CREATE TEMP TABLE country_timezones (country text, timezone text);
+INSERT INTO country_timezones VALUES
+    ('austria', 'Europe/Vienna'),
+    ('belgium', 'Europe/Brussels'),
+    ('costa rica', 'America/Costa_Rica'),
+    ('czech republic', 'Europe/Prague'),
+    ('denmark', 'Europe/Copenhagen'),
+    ('finland', 'Europe/Helsinki'),
+    ('france', 'Europe/Paris'),
+    ('germany', 'Europe/Berlin'),
+    ('iceland', 'Atlantic/Reykjavik'),
+    ('ireland', 'Europe/Dublin'),
+    ('italy', 'Europe/Rome'),
+    ('jamaica', 'America/Jamaica'),
+    ('japan', 'Asia/Tokyo'),
+    ('korea, south', 'Asia/Seoul'),
+    ('south korea', 'Asia/Seoul'),
+    ('netherlands', 'Europe/Amsterdam'),
+    ('norway', 'Europe/Oslo'),
+    ('poland', 'Europe/Warsaw'),
+    ('puerto rico', 'America/Puerto_Rico'),
+    ('sweden', 'Europe/Stockholm'),
+    ('switzerland', 'Europe/Zurich'),
+    ('united kingdom', 'Europe/London'),
+    ('uk', 'Europe/London'),
+    ('gb', 'Europe/London'),
+    ('great britain', 'Europe/London'),
+    ('england', 'Europe/London'),
+    ('scotland', 'Europe/London'),
+    ('wales', 'Europe/London'),
+    ('northern ireland', 'Europe/London');
+
+
+UPDATE venues SET timezone = t.timezone FROM state_timezones t
+WHERE venues.country IN ('', 'usa', 'us', 'u.s.', 'u.s.a.', 'united states', 'united states of america', 'america') AND venues.state IN (t.state, t.name);
+UPDATE venues SET timezone = t.timezone FROM country_timezones t
+WHERE venues.country = t.country;
+
+DROP TABLE state_timezones;
+DROP TABLE country_timezones;
+
+-- Venues that could not be placed stay in UTC
+UPDATE shows SET local_date = to_char(shows.date AT TIME ZONE venues.timezone, 'YYYY-MM-DD')
+FROM venues WHERE venues.id = shows.venue_id;
+
+CREATE INDEX idx_shows_local_date ON shows (local_date);
@@ -0,0 +1,9 @@
+DROP TABLE caught_performances;
+DROP TABLE show_performances;
+
+DROP INDEX idx_shows_festival_id;
+ALTER TABLE shows DROP CONSTRAINT fk_festivals_shows;
+ALTER TABLE shows DROP COLUMN stage;
+ALTER TABLE shows DROP COLUMN festival_id;
+
+DROP TABLE festivals;
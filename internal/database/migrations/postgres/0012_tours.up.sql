@@ -0,0 +1,19 @@
+CREATE TABLE tours (
+    id uuid PRIMARY KEY,
+    band_id uuid NOT NULL,
+    name text NOT NULL,
+    description text,
+    created_at timestamptz,
+    updated_at timestamptz,
+    CONSTRAINT fk_bands_tours FOREIGN KEY (band_id) REFERENCES bands (id)
+);
+CREATE INDEX idx_tours_band_id ON tours (band_id);
+
+CREATE TABLE tour_shows (
+    tour_id uuid NOT NULL,
+    show_id uuid NOT NULL,
+    PRIMARY KEY (tour_id, show_id),
+    CONSTRAINT fk_tour_shows_tour FOREIGN KEY (tour_id) REFERENCES tours (id),
+    CONSTRAINT fk_tour_shows_show FOREIGN KEY (show_id) REFERENCES shows (id)
+);
+CREATE INDEX idx_tour_shows_show_id ON tour_shows (show_id);
@@ -0,0 +1,2 @@
+DROP INDEX idx_sets_band_id;
+ALTER TABLE sets DROP COLUMN band_id;
@@ -0,0 +1,8 @@
+ALTER TABLE sets ADD COLUMN band_id uuid;
+
+-- Only the headliner's setlist was recorded so far
+UPDATE sets SET band_id = shows.band_id FROM shows WHERE shows.id = sets.show_id;
+
+ALTER TABLE sets ALTER COLUMN band_id SET NOT NULL;
+ALTER TABLE sets ADD CONSTRAINT fk_bands_sets FOREIGN KEY (band_id) REFERENCES bands (id);
+CREATE INDEX idx_sets_band_id ON sets (band_id);
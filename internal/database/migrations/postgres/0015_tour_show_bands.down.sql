@@ -0,0 +1,3 @@
+DROP INDEX idx_tour_shows_band_show;
+ALTER TABLE tour_shows DROP CONSTRAINT fk_tour_shows_band;
+ALTER TABLE tour_shows DROP COLUMN band_id;
@@ -0,0 +1,12 @@
+ALTER TABLE tour_shows ADD COLUMN band_id uuid;
+
+UPDATE tour_shows SET band_id = tours.band_id FROM tours WHERE tours.id = tour_shows.tour_id;
+
+-- A show that ended up on two tours of a band stays on the one created first
+DELETE FROM tour_shows a USING tour_shows b, tours ta, tours tb
+WHERE a.band_id = b.band_id AND a.show_id = b.show_id AND ta.id = a.tour_id AND tb.id = b.tour_id
+    AND (ta.created_at, ta.id) > (tb.created_at, tb.id);
+
+ALTER TABLE tour_shows ALTER COLUMN band_id SET NOT NULL;
+ALTER TABLE tour_shows ADD CONSTRAINT fk_tour_shows_band FOREIGN KEY (band_id) REFERENCES bands (id);
+CREATE UNIQUE INDEX idx_tour_shows_band_show ON tour_shows (band_id, show_id);
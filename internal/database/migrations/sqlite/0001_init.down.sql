@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS `media`;
+DROP TABLE IF EXISTS `follows`;
+DROP TABLE IF EXISTS `setlist_entries`;
+DROP TABLE IF EXISTS `sets`;
+DROP TABLE IF EXISTS `songs`;
+DROP TABLE IF EXISTS `show_attendances`;
+DROP TABLE IF EXISTS `shows`;
+DROP TABLE IF EXISTS `venues`;
+DROP TABLE IF EXISTS `bands`;
+DROP TABLE IF EXISTS `users`;
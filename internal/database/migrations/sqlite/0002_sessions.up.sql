@@ -0,0 +1,4 @@
+CREATE TABLE `sessions` (`id` uuid,`user_id` uuid NOT NULL,`refresh_token_hash` text NOT NULL,`previous_token_hash` text,`user_agent` text,`ip_address` text,`expires_at` datetime NOT NULL,`last_used_at` datetime NOT NULL,`revoked_at` datetime,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`id`),CONSTRAINT `fk_sessions_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`));
+CREATE INDEX `idx_sessions_user_id` ON `sessions`(`user_id`);
+CREATE UNIQUE INDEX `idx_sessions_refresh_token_hash` ON `sessions`(`refresh_token_hash`);
+CREATE INDEX `idx_sessions_previous_token_hash` ON `sessions`(`previous_token_hash`);
@@ -0,0 +1,4 @@
+CREATE TABLE `change_requests` (`id` uuid,`user_id` uuid NOT NULL,`target_type` text NOT NULL,`target_id` uuid NOT NULL,`changes` text NOT NULL,`comment` text,`status` text NOT NULL DEFAULT 'pending',`reviewer_id` uuid,`review_note` text,`reviewed_at` datetime,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`id`),CONSTRAINT `fk_change_requests_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`),CONSTRAINT `fk_change_requests_reviewer` FOREIGN KEY (`reviewer_id`) REFERENCES `users`(`id`));
+CREATE INDEX `idx_change_requests_user_id` ON `change_requests`(`user_id`);
+CREATE INDEX `idx_change_requests_target` ON `change_requests`(`target_type`,`target_id`);
+CREATE INDEX `idx_change_requests_status` ON `change_requests`(`status`);
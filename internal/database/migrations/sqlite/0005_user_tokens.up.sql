@@ -0,0 +1,5 @@
+ALTER TABLE `users` ADD COLUMN `email_verified_at` datetime;
+
+CREATE TABLE `user_tokens` (`id` uuid,`user_id` uuid NOT NULL,`purpose` text NOT NULL,`token_hash` text NOT NULL,`email` text NOT NULL,`expires_at` datetime NOT NULL,`used_at` datetime,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`id`),CONSTRAINT `fk_user_tokens_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`));
+CREATE INDEX `idx_user_tokens_user_id` ON `user_tokens`(`user_id`);
+CREATE UNIQUE INDEX `idx_user_tokens_token_hash` ON `user_tokens`(`token_hash`);
@@ -0,0 +1,7 @@
+ALTER TABLE `users` ADD COLUMN `totp_secret` text;
+ALTER TABLE `users` ADD COLUMN `totp_enabled_at` datetime;
+ALTER TABLE `users` ADD COLUMN `totp_last_step` integer NOT NULL DEFAULT 0;
+
+CREATE TABLE `recovery_codes` (`id` uuid,`user_id` uuid NOT NULL,`code_hash` text NOT NULL,`used_at` datetime,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`id`),CONSTRAINT `fk_recovery_codes_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`));
+CREATE INDEX `idx_recovery_codes_user_id` ON `recovery_codes`(`user_id`);
+CREATE UNIQUE INDEX `idx_recovery_codes_code_hash` ON `recovery_codes`(`code_hash`);
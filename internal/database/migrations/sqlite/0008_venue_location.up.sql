@@ -0,0 +1,4 @@
+ALTER TABLE `venues` ADD COLUMN `latitude` real;
+ALTER TABLE `venues` ADD COLUMN `longitude` real;
+
+CREATE INDEX `idx_venues_location` ON `venues`(`latitude`,`longitude`);
@@ -0,0 +1,21 @@
+-- Venue locations stay lowercase, only the old default comes back
+PRAGMA defer_foreign_keys = ON;
+CREATE TEMP TABLE `venues_copy` AS SELECT * FROM `venues`;
+DELETE FROM `venues`;
+DROP TABLE `venues`;
+CREATE TABLE `venues` (`id` uuid,`name` text NOT NULL,`city` text NOT NULL,`state` text NOT NULL,`country` text DEFAULT 'USA',`address` text,`capacity` text,`created_at` datetime,`updated_at` datetime,`latitude` real,`longitude` real,PRIMARY KEY (`id`));
+CREATE INDEX `idx_venues_name` ON `venues`(`name`);
+CREATE INDEX `idx_venues_location` ON `venues`(`latitude`,`longitude`);
+INSERT INTO `venues` SELECT * FROM `venues_copy`;
+DROP TABLE `venues_copy`;
+
+ALTER TABLE `users` ADD COLUMN `location` text;
+
+UPDATE `users` SET `location` = `home_city` || CASE WHEN `home_state` <> '' THEN ', ' || `home_state` ELSE '' END
+WHERE `home_city` <> '';
+
+ALTER TABLE `users` DROP COLUMN `home_longitude`;
+ALTER TABLE `users` DROP COLUMN `home_latitude`;
+ALTER TABLE `users` DROP COLUMN `home_country`;
+ALTER TABLE `users` DROP COLUMN `home_state`;
+ALTER TABLE `users` DROP COLUMN `home_city`;
@@ -0,0 +1,43 @@
+ALTER TABLE `users` ADD COLUMN `home_city` text NOT NULL DEFAULT '';
+ALTER TABLE `users` ADD COLUMN `home_state` text NOT NULL DEFAULT '';
+ALTER TABLE `users` ADD COLUMN `home_country` text NOT NULL DEFAULT '';
+ALTER TABLE `users` ADD COLUMN `home_latitude` real;
+ALTER TABLE `users` ADD COLUMN `home_longitude` real;
+
+-- Best effort: the free-text location was meant to be "City, State" or "City, State, Country".
+-- SQLite has no split function, so peel off one part at a time.
+UPDATE `users` SET
+    `home_city` = CASE WHEN instr(`location`, ',') > 0 THEN substr(`location`, 1, instr(`location`, ',') - 1) ELSE `location` END,
+    `home_state` = CASE WHEN instr(`location`, ',') > 0 THEN substr(`location`, instr(`location`, ',') + 1) ELSE '' END
+WHERE trim(coalesce(`location`, '')) <> '';
+UPDATE `users` SET
+    `home_state` = substr(`home_state`, 1, instr(`home_state`, ',') - 1),
+    `home_country` = substr(`home_state`, instr(`home_state`, ',') + 1)
+WHERE instr(`home_state`, ',') > 0;
+UPDATE `users` SET
+    `home_city` = lower(trim(`home_city`)),
+    `home_state` = lower(trim(`home_state`)),
+    `home_country` = lower(trim(CASE WHEN instr(`home_country`, ',') > 0 THEN substr(`home_country`, 1, instr(`home_country`, ',') - 1) ELSE `home_country` END));
+UPDATE `users` SET `home_country` = 'usa' WHERE `home_city` <> '' AND `home_country` = '';
+
+ALTER TABLE `users` DROP COLUMN `location`;
+
+-- Venues store their location the same way, so they compare equal to home locations.
+-- Repeated replaces squeeze runs of spaces into one.
+UPDATE `venues` SET
+    `city` = replace(replace(replace(lower(trim(replace(`city`, char(9), ' '))), '  ', ' '), '  ', ' '), '  ', ' '),
+    `state` = replace(replace(replace(lower(trim(replace(`state`, char(9), ' '))), '  ', ' '), '  ', ' '), '  ', ' '),
+    `country` = replace(replace(replace(lower(trim(replace(coalesce(`country`, ''), char(9), ' '))), '  ', ' '), '  ', ' '), '  ', ' ');
+UPDATE `venues` SET `country` = 'usa' WHERE `country` = '';
+
+-- SQLite cannot change a column default, so rebuild the table. Shows point at
+-- venues, so their foreign keys are only checked once the rows are back.
+PRAGMA defer_foreign_keys = ON;
+CREATE TEMP TABLE `venues_copy` AS SELECT * FROM `venues`;
+DELETE FROM `venues`;
+DROP TABLE `venues`;
+CREATE TABLE `venues` (`id` uuid,`name` text NOT NULL,`city` text NOT NULL,`state` text NOT NULL,`country` text DEFAULT 'usa',`address` text,`capacity` text,`created_at` datetime,`updated_at` datetime,`latitude` real,`longitude` real,PRIMARY KEY (`id`));
+CREATE INDEX `idx_venues_name` ON `venues`(`name`);
+CREATE INDEX `idx_venues_location` ON `venues`(`latitude`,`longitude`);
+INSERT INTO `venues` SELECT * FROM `venues_copy`;
+DROP TABLE `venues_copy`;
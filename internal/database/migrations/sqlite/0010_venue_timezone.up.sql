@@ -0,0 +1,164 @@
+ALTER TABLE `venues` ADD COLUMN `timezone` text NOT NULL DEFAULT 'UTC';
+ALTER TABLE `shows` ADD COLUMN `local_date` text NOT NULL DEFAULT '';
+
+-- Guess the timezone of existing venues the way new venues get theirs (geo.Timezone),
+-- from the state in the US and from the country elsewhere. Locations are lowercase by now.
+CREATE TEMP TABLE `state_timezones` (`state` text, `name` text, `timezone` text);
+INSERT INTO `state_timezones` VALUES
+    ('al', 'alabama', 'America/Chicago'),
+    ('ak', 'alaska', 'America/Anchorage'),
+    ('az', 'arizona', 'America/Phoenix'),
+    ('ar', 'arkansas', 'America/Chicago'),
+    ('ca', 'california', 'America/Los_Angeles'),
+    ('co', 'colorado', 'America/Denver'),
+    ('ct', 'connecticut', 'America/New_York'),
+    ('de', 'delaware', 'America/New_York'),
+    ('dc', 'district of columbia', 'America/New_York'),
+    ('fl', 'florida', 'America/New_York'),
+    ('ga', 'georgia', 'America/New_York'),
+    ('hi', 'hawaii', 'Pacific/Honolulu'),
+    ('id', 'idaho', 'America/Boise'),
+    ('il', 'illinois', 'America/Chicago'),
+    ('in', 'indiana', 'America/Indiana/Indianapolis'),
+    ('ia', 'iowa', 'America/Chicago'),
+    ('ks', 'kansas', 'America/Chicago'),
+    ('ky', 'kentucky', 'America/New_York'),
+    ('la', 'louisiana', 'America/Chicago'),
+    ('me', 'maine', 'America/New_York'),
+    ('md', 'maryland', 'America/New_York'),
+    ('ma', 'massachusetts', 'America/New_York'),
+    ('mi', 'michigan', 'America/Detroit'),
+    ('mn', 'minnesota', 'America/Chicago'),
+    ('ms', 'mississippi', 'America/Chicago'),
+    ('mo', 'missouri', 'America/Chicago'),
+    ('mt', 'montana', 'America/Denver'),
+    ('ne', 'nebraska', 'America/Chicago'),
+    ('nv', 'nevada', 'America/Los_Angeles'),
+    ('nh', 'new hampshire', 'America/New_York'),
+    ('nj', 'new jersey', 'America/New_York'),
+    ('nm', 'new mexico', 'America/Denver'),
+    ('ny', 'new york', 'America/New_York'),
+    ('nc', 'north carolina', 'America/New_York'),
+    ('nd', 'north dakota', 'America/Chicago'),
+    ('oh', 'ohio', 'America/New_York'),
+    ('ok', 'oklahoma', 'America/Chicago'),
+    ('or', 'oregon', 'America/Los_Angeles'),
+    ('pa', 'pennsylvania', 'America/New_York'),
+    ('ri', 'rhode island', 'America/New_York'),
+    ('sc', 'south carolina', 'America/New_York'),
+    ('sd', 'south dakota', 'America/Chicago'),
+    ('tn', 'tennessee', 'America/Chicago'),
+    ('tx', 'texas', 'America/Chicago'),
+    ('ut', 'utah', 'America/Denver'),
+    ('vt', 'vermont', 'America/New_York'),
+    ('va', 'virginia', 'America/New_York'),
+    ('wa', 'washington', 'America/Los_Angeles'),
+    ('wv', 'west virginia', 'America/New_York'),
+    ('wi', 'wisconsin', 'America/Chicago'),
+    ('wy', 'wyoming', 'America/Denver');
+
+CREATE TEMP TABLE `country_timezones` (`country` text, `timezone` text);
+INSERT INTO `country_timezones` VALUES
+    ('austria', 'Europe/Vienna'),
+    ('belgium', 'Europe/Brussels'),
+    ('costa rica', 'America/Costa_Rica'),
+    ('czech republic', 'Europe/Prague'),
+    ('denmark', 'Europe/Copenhagen'),
+    ('finland', 'Europe/Helsinki'),
+    ('france', 'Europe/Paris'),
+    ('germany', 'Europe/Berlin'),
+    ('iceland', 'Atlantic/Reykjavik'),
+    ('ireland', 'Europe/Dublin'),
+    ('italy', 'Europe/Rome'),
+    ('jamaica', 'America/Jamaica'),
+    ('japan', 'Asia/Tokyo'),
+    ('korea, south', 'Asia/Seoul'),
+    ('south korea', 'Asia/Seoul'),
+    ('netherlands', 'Europe/Amsterdam'),
+    ('norway', 'Europe/Oslo'),
+    ('poland', 'Europe/Warsaw'),
+    ('puerto rico', 'America/Puerto_Rico'),
+    ('sweden', 'Europe/Stockholm'),
+    ('switzerland', 'Europe/Zurich'),
+    ('united kingdom', 'Europe/London'),
+    ('uk', 'Europe/London'),
+    ('gb', 'Europe/London'),
+    ('great britain', 'Europe/London'),
+    ('england', 'Europe/London'),
+    ('scotland', 'Europe/London'),
+    ('wales', 'Europe/London'),
+    ('northern ireland', 'Europe/London');
+
+
+UPDATE `venues` SET `timezone` = t.`timezone` FROM `state_timezones` t
+WHERE `venues`.`country` IN ('', 'usa', 'us', 'u.s.', 'u.s.a.', 'united states', 'united states of america', 'america') AND `venues`.`state` IN (t.`state`, t.`name`);
+UPDATE `venues` SET `timezone` = t.`timezone` FROM `country_timezones` t
+WHERE `venues`.`country` = t.`country`;
+
+-- SQLite knows no timezones, so spell out the standard offset of each guessed one
+-- in minutes, and whether it follows US or EU daylight saving time
+CREATE TEMP TABLE `timezone_offsets` (`timezone` text, `offset` integer, `dst` text);
+INSERT INTO `timezone_offsets` VALUES
+    ('America/New_York', -300, 'us'),
+    ('America/Detroit', -300, 'us'),
+    ('America/Indiana/Indianapolis', -300, 'us'),
+    ('America/Chicago', -360, 'us'),
+    ('America/Denver', -420, 'us'),
+    ('America/Boise', -420, 'us'),
+    ('America/Phoenix', -420, ''),
+    ('America/Los_Angeles', -480, 'us'),
+    ('America/Anchorage', -540, 'us'),
+    ('Pacific/Honolulu', -600, ''),
+    ('America/Costa_Rica', -360, ''),
+    ('America/Jamaica', -300, ''),
+    ('America/Puerto_Rico', -240, ''),
+    ('Atlantic/Reykjavik', 0, ''),
+    ('Europe/London', 0, 'eu'),
+    ('Europe/Dublin', 0, 'eu'),
+    ('Europe/Amsterdam', 60, 'eu'),
+    ('Europe/Berlin', 60, 'eu'),
+    ('Europe/Brussels', 60, 'eu'),
+    ('Europe/Copenhagen', 60, 'eu'),
+    ('Europe/Oslo', 60, 'eu'),
+    ('Europe/Paris', 60, 'eu'),
+    ('Europe/Prague', 60, 'eu'),
+    ('Europe/Rome', 60, 'eu'),
+    ('Europe/Stockholm', 60, 'eu'),
+    ('Europe/Vienna', 60, 'eu'),
+    ('Europe/Warsaw', 60, 'eu'),
+    ('Europe/Zurich', 60, 'eu'),
+    ('Europe/Helsinki', 120, 'eu'),
+    ('Asia/Seoul', 540, ''),
+    ('Asia/Tokyo', 540, '');
+
+CREATE TEMP TABLE `show_times` AS
+SELECT s.`id`, datetime(s.`date`) AS `utc`, datetime(s.`date`, o.`offset` || ' minutes') AS `local`, o.`dst`
+FROM `shows` s
+JOIN `venues` v ON v.`id` = s.`venue_id`
+JOIN `timezone_offsets` o ON o.`timezone` = v.`timezone`;
+
+-- US daylight saving time runs from 2:00 local time on the second Sunday of March to
+-- the first Sunday of November, and from the first Sunday of April to the last Sunday
+-- of October before 2007. The EU's runs from 1:00 UTC on the last Sunday of March to
+-- the last Sunday of October.
+UPDATE `show_times` SET `local` = datetime(`local`, '+60 minutes')
+WHERE (`dst` = 'us' AND substr(`local`, 1, 4) >= '2007'
+        AND `local` >= date(substr(`local`, 1, 4) || '-03-01', 'weekday 0', '+7 days') || ' 02:00:00'
+        AND `local` < date(substr(`local`, 1, 4) || '-11-01', 'weekday 0') || ' 01:00:00')
+    OR (`dst` = 'us' AND substr(`local`, 1, 4) < '2007'
+        AND `local` >= date(substr(`local`, 1, 4) || '-04-01', 'weekday 0') || ' 02:00:00'
+        AND `local` < date(substr(`local`, 1, 4) || '-10-25', 'weekday 0') || ' 01:00:00')
+    OR (`dst` = 'eu'
+        AND `utc` >= date(substr(`utc`, 1, 4) || '-03-25', 'weekday 0') || ' 01:00:00'
+        AND `utc` < date(substr(`utc`, 1, 4) || '-10-25', 'weekday 0') || ' 01:00:00');
+
+-- Venues that could not be placed stay in UTC
+UPDATE `shows` SET `local_date` = DATE(`date`);
+UPDATE `shows` SET `local_date` = date(t.`local`) FROM `show_times` t WHERE t.`id` = `shows`.`id`;
+
+DROP TABLE `state_timezones`;
+DROP TABLE `country_timezones`;
+DROP TABLE `timezone_offsets`;
+DROP TABLE `show_times`;
+
+CREATE INDEX `idx_shows_local_date` ON `shows`(`local_date`);
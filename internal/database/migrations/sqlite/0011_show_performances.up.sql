@@ -0,0 +1,18 @@
+CREATE TABLE `festivals` (`id` uuid,`name` text NOT NULL,`description` text,`start_date` text NOT NULL,`end_date` text NOT NULL,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`id`));
+CREATE INDEX `idx_festivals_name` ON `festivals`(`name`);
+
+ALTER TABLE `shows` ADD COLUMN `festival_id` uuid REFERENCES `festivals`(`id`);
+ALTER TABLE `shows` ADD COLUMN `stage` text;
+CREATE INDEX `idx_shows_festival_id` ON `shows`(`festival_id`);
+
+CREATE TABLE `show_performances` (`id` uuid,`show_id` uuid NOT NULL,`band_id` uuid NOT NULL,`position` integer NOT NULL,`set_start` datetime,`set_end` datetime,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`id`),CONSTRAINT `fk_shows_performances` FOREIGN KEY (`show_id`) REFERENCES `shows`(`id`),CONSTRAINT `fk_bands_performances` FOREIGN KEY (`band_id`) REFERENCES `bands`(`id`));
+CREATE UNIQUE INDEX `idx_show_performances_show_band` ON `show_performances`(`show_id`,`band_id`);
+CREATE INDEX `idx_show_performances_band_id` ON `show_performances`(`band_id`);
+
+-- Every existing show has its band as the only one on the bill. The show ID is
+-- unique, so it doubles as the ID of the headliner's performance.
+INSERT INTO `show_performances` (`id`, `show_id`, `band_id`, `position`, `created_at`, `updated_at`)
+SELECT `id`, `id`, `band_id`, 1, `created_at`, `updated_at` FROM `shows`;
+
+CREATE TABLE `caught_performances` (`attendance_id` uuid NOT NULL,`performance_id` uuid NOT NULL,PRIMARY KEY (`attendance_id`,`performance_id`),CONSTRAINT `fk_caught_performances_attendance` FOREIGN KEY (`attendance_id`) REFERENCES `show_attendances`(`id`),CONSTRAINT `fk_caught_performances_performance` FOREIGN KEY (`performance_id`) REFERENCES `show_performances`(`id`));
+CREATE INDEX `idx_caught_performances_performance_id` ON `caught_performances`(`performance_id`);
@@ -0,0 +1,2 @@
+DROP TABLE `tour_shows`;
+DROP TABLE `tours`;
@@ -0,0 +1,4 @@
+ALTER TABLE `songs` ADD COLUMN `display_name` text NOT NULL DEFAULT '';
+
+-- Names were stored lowercase, the only spelling known for existing songs
+UPDATE `songs` SET `display_name` = `name`;
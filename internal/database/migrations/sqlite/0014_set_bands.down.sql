@@ -0,0 +1,9 @@
+PRAGMA defer_foreign_keys = ON;
+CREATE TEMP TABLE `sets_copy` AS SELECT * FROM `sets`;
+DELETE FROM `sets`;
+DROP TABLE `sets`;
+CREATE TABLE `sets` (`id` uuid,`show_id` uuid NOT NULL,`name` text NOT NULL,`position` integer NOT NULL,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`id`),CONSTRAINT `fk_shows_sets` FOREIGN KEY (`show_id`) REFERENCES `shows`(`id`));
+CREATE INDEX `idx_sets_show_id` ON `sets`(`show_id`);
+INSERT INTO `sets` (`id`,`show_id`,`name`,`position`,`created_at`,`updated_at`)
+SELECT `id`, `show_id`, `name`, `position`, `created_at`, `updated_at` FROM `sets_copy`;
+DROP TABLE `sets_copy`;
@@ -0,0 +1,16 @@
+-- SQLite cannot add a NOT NULL column without a default, so rebuild the table.
+-- Setlist entries point at sets, so their foreign keys are only checked once the
+-- rows are back.
+PRAGMA defer_foreign_keys = ON;
+CREATE TEMP TABLE `sets_copy` AS SELECT * FROM `sets`;
+DELETE FROM `sets`;
+DROP TABLE `sets`;
+CREATE TABLE `sets` (`id` uuid,`show_id` uuid NOT NULL,`band_id` uuid NOT NULL,`name` text NOT NULL,`position` integer NOT NULL,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`id`),CONSTRAINT `fk_shows_sets` FOREIGN KEY (`show_id`) REFERENCES `shows`(`id`),CONSTRAINT `fk_bands_sets` FOREIGN KEY (`band_id`) REFERENCES `bands`(`id`));
+CREATE INDEX `idx_sets_show_id` ON `sets`(`show_id`);
+CREATE INDEX `idx_sets_band_id` ON `sets`(`band_id`);
+
+-- Only the headliner's setlist was recorded so far
+INSERT INTO `sets` (`id`,`show_id`,`band_id`,`name`,`position`,`created_at`,`updated_at`)
+SELECT c.`id`, c.`show_id`, s.`band_id`, c.`name`, c.`position`, c.`created_at`, c.`updated_at`
+FROM `sets_copy` c JOIN `shows` s ON s.`id` = c.`show_id`;
+DROP TABLE `sets_copy`;
@@ -0,0 +1,6 @@
+CREATE TEMP TABLE `tour_shows_copy` AS SELECT * FROM `tour_shows`;
+DROP TABLE `tour_shows`;
+CREATE TABLE `tour_shows` (`tour_id` uuid NOT NULL,`show_id` uuid NOT NULL,PRIMARY KEY (`tour_id`,`show_id`),CONSTRAINT `fk_tour_shows_tour` FOREIGN KEY (`tour_id`) REFERENCES `tours`(`id`),CONSTRAINT `fk_tour_shows_show` FOREIGN KEY (`show_id`) REFERENCES `shows`(`id`));
+CREATE INDEX `idx_tour_shows_show_id` ON `tour_shows`(`show_id`);
+INSERT INTO `tour_shows` (`tour_id`,`show_id`) SELECT `tour_id`, `show_id` FROM `tour_shows_copy`;
+DROP TABLE `tour_shows_copy`;
@@ -0,0 +1,13 @@
+-- SQLite cannot add a NOT NULL column without a default, so rebuild the table
+CREATE TEMP TABLE `tour_shows_copy` AS SELECT * FROM `tour_shows`;
+DROP TABLE `tour_shows`;
+CREATE TABLE `tour_shows` (`tour_id` uuid NOT NULL,`show_id` uuid NOT NULL,`band_id` uuid NOT NULL,PRIMARY KEY (`tour_id`,`show_id`),CONSTRAINT `fk_tour_shows_tour` FOREIGN KEY (`tour_id`) REFERENCES `tours`(`id`),CONSTRAINT `fk_tour_shows_show` FOREIGN KEY (`show_id`) REFERENCES `shows`(`id`),CONSTRAINT `fk_tour_shows_band` FOREIGN KEY (`band_id`) REFERENCES `bands`(`id`));
+CREATE INDEX `idx_tour_shows_show_id` ON `tour_shows`(`show_id`);
+CREATE UNIQUE INDEX `idx_tour_shows_band_show` ON `tour_shows`(`band_id`,`show_id`);
+
+-- A show that ended up on two tours of a band stays on the one created first
+INSERT OR IGNORE INTO `tour_shows` (`tour_id`,`show_id`,`band_id`)
+SELECT c.`tour_id`, c.`show_id`, t.`band_id`
+FROM `tour_shows_copy` c JOIN `tours` t ON t.`id` = c.`tour_id`
+ORDER BY t.`created_at`, t.`id`;
+DROP TABLE `tour_shows_copy`;
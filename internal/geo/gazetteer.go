@@ -0,0 +1,146 @@
+package geo
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/csv"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// cities.csv lists about 10,000 of the largest cities worldwide, the biggest of
+// each country first. It was generated from the public domain
+// github.com/tidwall/cities data set, with states added for US cities.
+//
+//go:embed cities.csv
+var citiesCSV []byte
+
+// countryAliases maps common spellings to the country names in cities.csv
+var countryAliases = map[string]string{
+	"usa":                      "united states",
+	"us":                       "united states",
+	"u.s.":                     "united states",
+	"u.s.a.":                   "united states",
+	"united states of america": "united states",
+	"america":                  "united states",
+	"uk":                       "united kingdom",
+	"gb":                       "united kingdom",
+	"great britain":            "united kingdom",
+	"england":                  "united kingdom",
+	"scotland":                 "united kingdom",
+	"wales":                    "united kingdom",
+	"northern ireland":         "united kingdom",
+	"south korea":              "korea, south",
+	"north korea":              "korea, north",
+}
+
+// usStates maps the postal codes of US states to their names
+var usStates = map[string]string{
+	"al": "alabama", "ak": "alaska", "az": "arizona", "ar": "arkansas", "ca": "california",
+	"co": "colorado", "ct": "connecticut", "de": "delaware", "dc": "district of columbia",
+	"fl": "florida", "ga": "georgia", "hi": "hawaii", "id": "idaho", "il": "illinois",
+	"in": "indiana", "ia": "iowa", "ks": "kansas", "ky": "kentucky", "la": "louisiana",
+	"me": "maine", "md": "maryland", "ma": "massachusetts", "mi": "michigan", "mn": "minnesota",
+	"ms": "mississippi", "mo": "missouri", "mt": "montana", "ne": "nebraska", "nv": "nevada",
+	"nh": "new hampshire", "nj": "new jersey", "nm": "new mexico", "ny": "new york",
+	"nc": "north carolina", "nd": "north dakota", "oh": "ohio", "ok": "oklahoma", "or": "oregon",
+	"pa": "pennsylvania", "ri": "rhode island", "sc": "south carolina", "sd": "south dakota",
+	"tn": "tennessee", "tx": "texas", "ut": "utah", "vt": "vermont", "va": "virginia",
+	"wa": "washington", "wv": "west virginia", "wi": "wisconsin", "wy": "wyoming",
+}
+
+type gazetteerEntry struct {
+	state string // Postal code, only set for US cities
+	point Point
+}
+
+// Gazetteer geocodes cities from the bundled city list without network access.
+// It ignores the street of an address.
+type Gazetteer struct {
+	cities map[string][]gazetteerEntry // By normalized city and country
+}
+
+// NewGazetteer loads the bundled city list
+func NewGazetteer() *Gazetteer {
+	records, err := csv.NewReader(bytes.NewReader(citiesCSV)).ReadAll()
+	if err != nil {
+		log.Fatal("Invalid bundled city list:", err)
+	}
+
+	g := &Gazetteer{cities: make(map[string][]gazetteerEntry, len(records))}
+	for _, record := range records[1:] { // Skip the header
+		lat, latErr := strconv.ParseFloat(record[3], 64)
+		lng, lngErr := strconv.ParseFloat(record[4], 64)
+		if latErr != nil || lngErr != nil {
+			log.Fatalf("Invalid coordinates for %s in bundled city list", record[0])
+		}
+
+		key := gazetteerKey(record[0], record[2])
+		g.cities[key] = append(g.cities[key], gazetteerEntry{
+			state: strings.ToLower(record[1]),
+			point: Point{Lat: lat, Lng: lng},
+		})
+	}
+	return g
+}
+
+// Geocode returns the position of the city of addr. The country defaults to
+// the United States, like venues do. A state narrows the match when given.
+func (g *Gazetteer) Geocode(ctx context.Context, addr Address) (Point, error) {
+	country := addr.Country
+	if strings.TrimSpace(country) == "" {
+		country = "usa"
+	}
+
+	entries := g.cities[gazetteerKey(addr.City, country)]
+	state := normalizeState(addr.State)
+	for _, entry := range entries {
+		if state == "" || entry.state == "" || entry.state == state {
+			return entry.point, nil
+		}
+	}
+	return Point{}, ErrNotFound
+}
+
+func gazetteerKey(city, country string) string {
+	return normalizeName(city) + "|" + normalizeCountry(country)
+}
+
+// normalizeCountry lowercases a country and resolves common aliases to the
+// names in cities.csv
+func normalizeCountry(country string) string {
+	country = strings.Join(strings.Fields(strings.ToLower(country)), " ")
+	if alias, ok := countryAliases[country]; ok {
+		return alias
+	}
+	return country
+}
+
+// normalizeName lowercases a city name and spells out the abbreviations
+// cities.csv does not use, so "St. Louis" finds "Saint Louis"
+func normalizeName(name string) string {
+	name = strings.Join(strings.Fields(strings.ToLower(name)), " ")
+	for _, prefix := range []string{"st. ", "st ", "ste. ", "ste "} {
+		if rest, ok := strings.CutPrefix(name, prefix); ok {
+			if strings.HasPrefix(prefix, "ste") {
+				return "sainte " + rest
+			}
+			return "saint " + rest
+		}
+	}
+	return name
+}
+
+// normalizeState turns a US state name into its postal code. Other states are
+// only lowercased.
+func normalizeState(state string) string {
+	state = strings.ToLower(strings.TrimSpace(state))
+	for code, name := range usStates {
+		if state == name {
+			return code
+		}
+	}
+	return state
+}
@@ -0,0 +1,61 @@
+package geo
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestGazetteerGeocode(t *testing.T) {
+	g := NewGazetteer()
+	ctx := context.Background()
+
+	denver, err := g.Geocode(ctx, Address{City: "Denver", State: "Colorado"})
+	if err != nil {
+		t.Fatalf("Geocode(Denver, Colorado) error = %v", err)
+	}
+	if d := Distance(denver, Point{39.74, -104.99}); d > 25 {
+		t.Errorf("Denver is %.0f km off", d)
+	}
+
+	// The country tells apart cities of the same name
+	birminghamAL, err := g.Geocode(ctx, Address{City: "birmingham", State: "al", Country: "usa"})
+	if err != nil {
+		t.Fatalf("Geocode(birmingham, al) error = %v", err)
+	}
+	birminghamUK, err := g.Geocode(ctx, Address{City: "birmingham", Country: "england"})
+	if err != nil {
+		t.Fatalf("Geocode(birmingham, england) error = %v", err)
+	}
+	if Distance(birminghamAL, birminghamUK) < 1000 {
+		t.Errorf("Birmingham, AL %+v and Birmingham, England %+v are the same place", birminghamAL, birminghamUK)
+	}
+
+	if _, err := g.Geocode(ctx, Address{City: "St. Louis", State: "MO"}); err != nil {
+		t.Errorf("Geocode(St. Louis) error = %v", err)
+	}
+
+	if _, err := g.Geocode(ctx, Address{City: "nowhere at all"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Geocode(nowhere at all) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestTimezone(t *testing.T) {
+	tests := []struct {
+		addr Address
+		want string
+	}{
+		{Address{State: "CO"}, "America/Denver"},
+		{Address{State: "Colorado", Country: "USA"}, "America/Denver"},
+		{Address{State: "ny", Country: "united states of america"}, "America/New_York"},
+		{Address{State: "england", Country: "uk"}, "Europe/London"},
+		{Address{Country: "South Korea"}, "Asia/Seoul"},
+		{Address{State: "nsw", Country: "australia"}, ""}, // Several zones
+		{Address{State: "zz"}, ""},
+	}
+	for _, tt := range tests {
+		if got := Timezone(tt.addr); got != tt.want {
+			t.Errorf("Timezone(%+v) = %q, want %q", tt.addr, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package geo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const earthRadiusKm = 6371.0088 // Mean radius
+
+// ErrNotFound is returned when a geocoder does not know a place
+var ErrNotFound = errors.New("location not found")
+
+// Point is a position in degrees
+type Point struct {
+	Lat float64 `json:"latitude"`
+	Lng float64 `json:"longitude"`
+}
+
+// ParsePoint reads a point written as "lat,lng", e.g. "39.6654,-105.2057"
+func ParsePoint(value string) (Point, error) {
+	lat, lng, ok := strings.Cut(value, ",")
+	if !ok {
+		return Point{}, fmt.Errorf("invalid point %q, expected lat,lng", value)
+	}
+
+	var p Point
+	var err error
+	if p.Lat, err = strconv.ParseFloat(strings.TrimSpace(lat), 64); err != nil {
+		return Point{}, fmt.Errorf("invalid latitude %q", lat)
+	}
+	if p.Lng, err = strconv.ParseFloat(strings.TrimSpace(lng), 64); err != nil {
+		return Point{}, fmt.Errorf("invalid longitude %q", lng)
+	}
+	if !p.Valid() {
+		return Point{}, fmt.Errorf("point %q is out of range", value)
+	}
+	return p, nil
+}
+
+// Valid reports whether the latitude is within ±90 and the longitude within ±180
+func (p Point) Valid() bool {
+	return p.Lat >= -90 && p.Lat <= 90 && p.Lng >= -180 && p.Lng <= 180
+}
+
+// Distance returns the great-circle distance between two points in kilometers
+func Distance(a, b Point) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLat := lat2 - lat1
+	dLng := radians(b.Lng - a.Lng)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Circle is the area within RadiusKm of Center
+type Circle struct {
+	Center   Point
+	RadiusKm float64
+}
+
+// Contains reports whether p lies within the circle
+func (c Circle) Contains(p Point) bool {
+	return Distance(c.Center, p) <= c.RadiusKm
+}
+
+// Box is a latitude/longitude rectangle. AnyLng is set when the box spans every
+// longitude, which happens near the poles and across the antimeridian.
+type Box struct {
+	MinLat, MaxLat float64
+	MinLng, MaxLng float64
+	AnyLng         bool
+}
+
+// Bounds returns a box around the circle, cheap to check in SQL before
+// measuring the exact distance
+func (c Circle) Bounds() Box {
+	dLat := degrees(c.RadiusKm / earthRadiusKm)
+	box := Box{
+		MinLat: math.Max(-90, c.Center.Lat-dLat),
+		MaxLat: math.Min(90, c.Center.Lat+dLat),
+	}
+
+	// A degree of longitude shrinks with the cosine of the latitude
+	cosLat := math.Cos(radians(math.Max(math.Abs(box.MinLat), math.Abs(box.MaxLat))))
+	if cosLat <= 0 || dLat/cosLat >= 180 {
+		box.AnyLng = true
+		return box
+	}
+	dLng := dLat / cosLat
+	box.MinLng, box.MaxLng = c.Center.Lng-dLng, c.Center.Lng+dLng
+	if box.MinLng < -180 || box.MaxLng > 180 {
+		box.AnyLng = true
+	}
+	return box
+}
+
+// Address is the place to geocode. Empty fields are left out of the search.
+type Address struct {
+	Street  string
+	City    string
+	State   string
+	Country string
+}
+
+// Geocoder finds the position of an address
+type Geocoder interface {
+	Geocode(ctx context.Context, addr Address) (Point, error)
+}
+
+// Connect returns the geocoder selected by GEOCODER. The offline gazetteer only
+// knows cities, so venues get the position of their city.
+func Connect() Geocoder {
+	var geocoder Geocoder
+	switch backend := getEnv("GEOCODER", "offline"); backend {
+	case "offline":
+		geocoder = NewGazetteer()
+	case "http":
+		geocoder = &HTTPGeocoder{
+			BaseURL:    getEnv("GEOCODER_BASE_URL", DefaultBaseURL),
+			UserAgent:  getEnv("GEOCODER_USER_AGENT", "JamTracker/1.0"),
+			HTTPClient: &http.Client{Timeout: 10 * time.Second},
+		}
+	default:
+		log.Fatalf("Unknown GEOCODER %q, expected offline or http", backend)
+	}
+
+	log.Println("Geocoder initialized!")
+	return geocoder
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func degrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
+
+// Helper functions
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,103 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParsePoint(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Point
+		wantErr bool
+	}{
+		{value: "39.6654,-105.2057", want: Point{Lat: 39.6654, Lng: -105.2057}},
+		{value: " 39.6654 , -105.2057 ", want: Point{Lat: 39.6654, Lng: -105.2057}},
+		{value: "-90,180", want: Point{Lat: -90, Lng: 180}},
+		{value: "39.6654", wantErr: true},
+		{value: "north,west", wantErr: true},
+		{value: "39.6654,west", wantErr: true},
+		{value: "90.5,0", wantErr: true},
+		{value: "0,-180.5", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePoint(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParsePoint(%q) error = %v, want error %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParsePoint(%q) = %+v, want %+v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Point
+		want float64 // Kilometers
+	}{
+		{"same point", Point{39.74, -104.99}, Point{39.74, -104.99}, 0},
+		{"london to paris", Point{51.5074, -0.1278}, Point{48.8566, 2.3522}, 343.6},
+		{"across the antimeridian", Point{0, 179.5}, Point{0, -179.5}, 111.2},
+		{"around the pole", Point{90, 0}, Point{90, 120}, 0},
+		{"pole to pole", Point{90, 0}, Point{-90, 0}, math.Pi * earthRadiusKm},
+	}
+	for _, tt := range tests {
+		if got := Distance(tt.a, tt.b); math.Abs(got-tt.want) > 0.5 {
+			t.Errorf("%s: Distance = %.1f km, want %.1f", tt.name, got, tt.want)
+		}
+		if got, back := Distance(tt.a, tt.b), Distance(tt.b, tt.a); got != back {
+			t.Errorf("%s: Distance is %.3f one way and %.3f back", tt.name, got, back)
+		}
+	}
+}
+
+func TestCircleBounds(t *testing.T) {
+	denver := Circle{Center: Point{39.74, -104.99}, RadiusKm: 50}
+	box := denver.Bounds()
+	if box.AnyLng {
+		t.Fatalf("box around Denver spans every longitude: %+v", box)
+	}
+
+	// Points just inside the circle in every direction are inside the box
+	for _, bearing := range []float64{0, 90, 180, 270} {
+		p := destination(denver.Center, bearing, 49.9)
+		if !denver.Contains(p) {
+			t.Errorf("circle does not contain %+v at bearing %g", p, bearing)
+		}
+		if p.Lat < box.MinLat || p.Lat > box.MaxLat || p.Lng < box.MinLng || p.Lng > box.MaxLng {
+			t.Errorf("box %+v does not contain %+v at bearing %g", box, p, bearing)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		circle Circle
+	}{
+		{"across the antimeridian", Circle{Center: Point{-17.7, 179.9}, RadiusKm: 50}},
+		{"at the north pole", Circle{Center: Point{89.9, 0}, RadiusKm: 50}},
+		{"at the south pole", Circle{Center: Point{-90, 45}, RadiusKm: 10}},
+		{"wider than the world", Circle{Center: Point{0, 0}, RadiusKm: 30000}},
+	}
+	for _, tt := range tests {
+		box := tt.circle.Bounds()
+		if !box.AnyLng {
+			t.Errorf("%s: box %+v should span every longitude", tt.name, box)
+		}
+		if box.MinLat < -90 || box.MaxLat > 90 {
+			t.Errorf("%s: latitudes %g to %g are out of range", tt.name, box.MinLat, box.MaxLat)
+		}
+	}
+}
+
+// destination returns the point distanceKm away from p heading bearing degrees clockwise from north
+func destination(p Point, bearing, distanceKm float64) Point {
+	lat1, lng1, theta := radians(p.Lat), radians(p.Lng), radians(bearing)
+	delta := distanceKm / earthRadiusKm
+
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(delta) + math.Cos(lat1)*math.Sin(delta)*math.Cos(theta))
+	lng2 := lng1 + math.Atan2(math.Sin(theta)*math.Sin(delta)*math.Cos(lat1), math.Cos(delta)-math.Sin(lat1)*math.Sin(lat2))
+	return Point{Lat: degrees(lat2), Lng: degrees(lng2)}
+}
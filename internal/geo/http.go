@@ -0,0 +1,87 @@
+package geo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DefaultBaseURL is the public OpenStreetMap Nominatim server
+const DefaultBaseURL = "https://nominatim.openstreetmap.org"
+
+// HTTPGeocoder geocodes addresses with the Nominatim search API or any server
+// that mimics it. Point BaseURL at a local mock to avoid calling the real API.
+type HTTPGeocoder struct {
+	BaseURL    string
+	UserAgent  string // Nominatim refuses requests without one
+	HTTPClient *http.Client
+}
+
+type nominatimPlace struct {
+	Lat string `json:"lat"`
+	Lon string `json:"lon"`
+}
+
+// Geocode returns the position of the best match for addr. When the street
+// cannot be found it settles for the city.
+func (g *HTTPGeocoder) Geocode(ctx context.Context, addr Address) (Point, error) {
+	point, err := g.search(ctx, addr)
+	if errors.Is(err, ErrNotFound) && addr.Street != "" {
+		addr.Street = ""
+		return g.search(ctx, addr)
+	}
+	return point, err
+}
+
+func (g *HTTPGeocoder) search(ctx context.Context, addr Address) (Point, error) {
+	query := url.Values{"format": {"jsonv2"}, "limit": {"1"}}
+	for key, value := range map[string]string{
+		"street":  addr.Street,
+		"city":    addr.City,
+		"state":   addr.State,
+		"country": addr.Country,
+	} {
+		if value = strings.TrimSpace(value); value != "" {
+			query.Set(key, value)
+		}
+	}
+
+	endpoint := strings.TrimSuffix(g.BaseURL, "/") + "/search?" + query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return Point{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", g.UserAgent)
+
+	resp, err := g.HTTPClient.Do(req)
+	if err != nil {
+		return Point{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Point{}, fmt.Errorf("geocoder returned status %d", resp.StatusCode)
+	}
+
+	var places []nominatimPlace
+	if err := json.NewDecoder(resp.Body).Decode(&places); err != nil {
+		return Point{}, fmt.Errorf("invalid geocoder response: %w", err)
+	}
+	if len(places) == 0 {
+		return Point{}, ErrNotFound
+	}
+
+	lat, latErr := strconv.ParseFloat(places[0].Lat, 64)
+	lng, lngErr := strconv.ParseFloat(places[0].Lon, 64)
+	point := Point{Lat: lat, Lng: lng}
+	if latErr != nil || lngErr != nil || !point.Valid() {
+		return Point{}, fmt.Errorf("invalid geocoder coordinates %q,%q", places[0].Lat, places[0].Lon)
+	}
+	return point, nil
+}
@@ -0,0 +1,58 @@
+package geo
+
+// stateTimezones maps US states to the IANA timezone most of their people live in
+var stateTimezones = map[string]string{
+	"al": "America/Chicago", "ak": "America/Anchorage", "az": "America/Phoenix", "ar": "America/Chicago",
+	"ca": "America/Los_Angeles", "co": "America/Denver", "ct": "America/New_York", "de": "America/New_York",
+	"dc": "America/New_York", "fl": "America/New_York", "ga": "America/New_York", "hi": "Pacific/Honolulu",
+	"id": "America/Boise", "il": "America/Chicago", "in": "America/Indiana/Indianapolis", "ia": "America/Chicago",
+	"ks": "America/Chicago", "ky": "America/New_York", "la": "America/Chicago", "me": "America/New_York",
+	"md": "America/New_York", "ma": "America/New_York", "mi": "America/Detroit", "mn": "America/Chicago",
+	"ms": "America/Chicago", "mo": "America/Chicago", "mt": "America/Denver", "ne": "America/Chicago",
+	"nv": "America/Los_Angeles", "nh": "America/New_York", "nj": "America/New_York", "nm": "America/Denver",
+	"ny": "America/New_York", "nc": "America/New_York", "nd": "America/Chicago", "oh": "America/New_York",
+	"ok": "America/Chicago", "or": "America/Los_Angeles", "pa": "America/New_York", "ri": "America/New_York",
+	"sc": "America/New_York", "sd": "America/Chicago", "tn": "America/Chicago", "tx": "America/Chicago",
+	"ut": "America/Denver", "vt": "America/New_York", "va": "America/New_York", "wa": "America/Los_Angeles",
+	"wv": "America/New_York", "wi": "America/Chicago", "wy": "America/Denver",
+}
+
+// countryTimezones maps countries with a single timezone to it
+var countryTimezones = map[string]string{
+	"austria":        "Europe/Vienna",
+	"belgium":        "Europe/Brussels",
+	"costa rica":     "America/Costa_Rica",
+	"czech republic": "Europe/Prague",
+	"denmark":        "Europe/Copenhagen",
+	"finland":        "Europe/Helsinki",
+	"france":         "Europe/Paris",
+	"germany":        "Europe/Berlin",
+	"iceland":        "Atlantic/Reykjavik",
+	"ireland":        "Europe/Dublin",
+	"italy":          "Europe/Rome",
+	"jamaica":        "America/Jamaica",
+	"japan":          "Asia/Tokyo",
+	"korea, south":   "Asia/Seoul",
+	"netherlands":    "Europe/Amsterdam",
+	"norway":         "Europe/Oslo",
+	"poland":         "Europe/Warsaw",
+	"puerto rico":    "America/Puerto_Rico",
+	"sweden":         "Europe/Stockholm",
+	"switzerland":    "Europe/Zurich",
+	"united kingdom": "Europe/London",
+}
+
+// Timezone guesses the IANA timezone of an address from its state or country.
+// It returns an empty string for places it cannot tell, such as countries
+// spanning several timezones.
+func Timezone(addr Address) string {
+	country := normalizeCountry(addr.Country)
+	if country == "" {
+		country = "united states" // Like the gazetteer
+	}
+
+	if country == "united states" {
+		return stateTimezones[normalizeState(addr.State)]
+	}
+	return countryTimezones[country]
+}
@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"jam-tracker/internal/database"
+	"jam-tracker/internal/mailer"
+	"jam-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	passwordResetTTL     = time.Hour
+	emailVerificationTTL = 48 * time.Hour
+)
+
+var errInvalidUserToken = errors.New("invalid or expired token")
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ForgotPassword mails a password reset link. It answers the same whether or not
+// the email belongs to an account, so it cannot be used to look up users.
+func (h *Handler) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := gin.H{"message": "If an account exists for this email, a password reset link has been sent"}
+
+	user, err := h.Users.FindByEmail(c.Request.Context(), req.Email)
+	if err != nil {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	token, err := issueUserToken(h.DB, user, models.TokenPurposePasswordReset, passwordResetTTL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create reset token"})
+		return
+	}
+
+	link := appLink("/reset-password", token)
+	if err := h.Mailer.Send(c.Request.Context(), mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your JamTracker password",
+		Body: fmt.Sprintf("Hi %s,\n\nFollow this link to choose a new password:\n\n%s\n\n"+
+			"Or post this token to /api/v1/auth/reset-password: %s\n\n"+
+			"The link expires in 1 hour. If you did not ask for a reset, you can ignore this email.\n",
+			user.Username, link, token),
+	}); err != nil {
+		// Failing here would tell the caller the account exists
+		log.Printf("Failed to send password reset email to user %s: %v", user.ID, err)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ResetPassword sets a new password with a token from ForgotPassword and signs the
+// user out everywhere
+func (h *Handler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		token, err := consumeUserToken(tx, models.TokenPurposePasswordReset, req.Token)
+		if err != nil {
+			return err
+		}
+
+		var user models.User
+		if err := tx.Where("id = ?", token.UserID).First(&user).Error; err != nil {
+			return err
+		}
+
+		// Receiving the email also proves the user owns the address, so lift any lockout
+		updates := map[string]any{"password": string(hashedPassword), "failed_logins": 0, "locked_until": nil}
+		if user.EmailVerifiedAt == nil && user.Email == token.Email {
+			updates["email_verified_at"] = time.Now()
+		}
+		if err := tx.Model(&models.User{}).Where("id = ?", token.UserID).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		// Other reset links must not work after the password changed
+		if err := expireUserTokens(tx, token.UserID, models.TokenPurposePasswordReset); err != nil {
+			return err
+		}
+		return revokeUserSessions(tx, token.UserID)
+	})
+	if errors.Is(err, errInvalidUserToken) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset token"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset successfully. Please log in again."})
+}
+
+// VerifyEmail marks the email of a user as verified with a token from the verification email
+func (h *Handler) VerifyEmail(c *gin.Context) {
+	var req VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		token, err := consumeUserToken(tx, models.TokenPurposeEmailVerification, req.Token)
+		if err != nil {
+			return err
+		}
+
+		// The token only counts for the address it was sent to
+		result := tx.Model(&models.User{}).Where("id = ? AND email = ?", token.UserID, token.Email).
+			Update("email_verified_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errInvalidUserToken
+		}
+		return nil
+	})
+	if errors.Is(err, errInvalidUserToken) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification token"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified successfully"})
+}
+
+// ResendVerificationEmail mails the current user a new verification link
+func (h *Handler) ResendVerificationEmail(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	user, err := h.Users.FindByID(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if user.EmailVerifiedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Email is already verified"})
+		return
+	}
+
+	if err := h.sendVerificationEmail(c.Request.Context(), user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Verification email sent"})
+}
+
+// sendVerificationEmail mails a user a link to verify their email address
+func (h *Handler) sendVerificationEmail(ctx context.Context, user models.User) error {
+	token, err := issueUserToken(h.DB, user, models.TokenPurposeEmailVerification, emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	link := appLink("/verify-email", token)
+	return h.Mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your JamTracker email",
+		Body: fmt.Sprintf("Hi %s,\n\nFollow this link to verify your email address:\n\n%s\n\n"+
+			"Or post this token to /api/v1/auth/verify-email: %s\n\nThe link expires in 48 hours.\n",
+			user.Username, link, token),
+	})
+}
+
+// issueUserToken stores a new single-use token for a user and returns it
+func issueUserToken(db *gorm.DB, user models.User, purpose models.TokenPurpose, ttl time.Duration) (string, error) {
+	token, err := generateToken()
+	if err != nil {
+		return "", err
+	}
+
+	userToken := models.UserToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		TokenHash: hashToken(token),
+		Email:     user.Email,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := db.Create(&userToken).Error; err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// consumeUserToken marks a token as used and returns it. Of two concurrent uses
+// of the same token only one succeeds.
+func consumeUserToken(db *gorm.DB, purpose models.TokenPurpose, token string) (models.UserToken, error) {
+	hash := hashToken(token)
+	dialect := database.DialectOf(db)
+	now := time.Now()
+
+	result := db.Model(&models.UserToken{}).
+		Where("token_hash = ? AND purpose = ? AND used_at IS NULL", hash, purpose).
+		Where(dialect.Time("expires_at")+" > "+dialect.Time("?"), now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return models.UserToken{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return models.UserToken{}, errInvalidUserToken
+	}
+
+	var userToken models.UserToken
+	if err := db.Where("token_hash = ?", hash).First(&userToken).Error; err != nil {
+		return models.UserToken{}, err
+	}
+	return userToken, nil
+}
+
+// expireUserTokens uses up every outstanding token of a user for a purpose
+func expireUserTokens(db *gorm.DB, userID uuid.UUID, purpose models.TokenPurpose) error {
+	return db.Model(&models.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", time.Now()).Error
+}
+
+// deleteUserTokens removes every token of a user
+func deleteUserTokens(db *gorm.DB, userID uuid.UUID) error {
+	return db.Where("user_id = ?", userID).Delete(&models.UserToken{}).Error
+}
+
+// appLink builds a link to a page of the app carrying a token. APP_URL is where
+// the app is served, e.g. https://jamtracker.example.com
+func appLink(path, token string) string {
+	base := os.Getenv("APP_URL")
+	if base == "" {
+		base = "http://localhost:8080"
+	}
+	return strings.TrimSuffix(base, "/") + path + "?token=" + url.QueryEscape(token)
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+
+	"jam-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+var resetTokenPattern = regexp.MustCompile(`/api/v1/auth/reset-password: (\S+)`)
+
+func TestResetPasswordLiftsLockout(t *testing.T) {
+	s := newAPIServer(t)
+	user := s.register("fan@example.com", "fan").User
+	s.token = ""
+
+	wrong := gin.H{"email": "fan@example.com", "password": "wrong password"}
+	for i := 0; i < maxFailedLogins; i++ {
+		s.do(http.MethodPost, "/api/v1/auth/login", wrong, http.StatusUnauthorized)
+	}
+	s.do(http.MethodPost, "/api/v1/auth/login", gin.H{"email": "fan@example.com", "password": testPassword}, http.StatusTooManyRequests)
+
+	s.do(http.MethodPost, "/api/v1/auth/forgot-password", gin.H{"email": "fan@example.com"}, http.StatusOK)
+	match := resetTokenPattern.FindStringSubmatch(s.mail.last(t, "fan@example.com").Body)
+	if match == nil {
+		t.Fatal("reset email has no token")
+	}
+	s.do(http.MethodPost, "/api/v1/auth/reset-password", gin.H{"token": match[1], "password": "new secret"}, http.StatusOK)
+
+	var got models.User
+	if err := s.db.First(&got, "id = ?", user.ID).Error; err != nil {
+		t.Fatalf("load user: %v", err)
+	}
+	if got.FailedLogins != 0 || got.LockedUntil != nil {
+		t.Errorf("failed_logins = %d, locked_until = %v after the reset, want both cleared", got.FailedLogins, got.LockedUntil)
+	}
+
+	s.do(http.MethodPost, "/api/v1/auth/login", gin.H{"email": "fan@example.com", "password": "new secret"}, http.StatusOK)
+
+	// The token only works once
+	s.do(http.MethodPost, "/api/v1/auth/reset-password", gin.H{"token": match[1], "password": "another secret"}, http.StatusBadRequest)
+}
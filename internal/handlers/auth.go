@@ -1,12 +1,12 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
 	"os"
 	"strings"
 	"time"
 
-	"jam-tracker/internal/database"
 	"jam-tracker/internal/models"
 
 	"github.com/gin-gonic/gin"
@@ -16,12 +16,12 @@ import (
 )
 
 type RegisterRequest struct {
-	Email     string `json:"email" binding:"required,email"`
-	Username  string `json:"username" binding:"required,min=3"`
-	Password  string `json:"password" binding:"required,min=6"`
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name"`
-	Location  string `json:"location"`
+	Email     string           `json:"email" binding:"required,email"`
+	Username  string           `json:"username" binding:"required,min=3"`
+	Password  string           `json:"password" binding:"required,min=6"`
+	FirstName string           `json:"first_name"`
+	LastName  string           `json:"last_name"`
+	Location  *LocationRequest `json:"location"` // Home location
 }
 
 type LoginRequest struct {
@@ -30,9 +30,9 @@ type LoginRequest struct {
 }
 
 type UpdateProfileRequest struct {
-	FirstName string `json:"first_name" binding:"required,min=1,max=50"`
-	LastName  string `json:"last_name" binding:"required,min=1,max=50"`
-	Location  string `json:"location" binding:"max=100"`
+	FirstName string           `json:"first_name" binding:"required,min=1,max=50"`
+	LastName  string           `json:"last_name" binding:"required,min=1,max=50"`
+	Location  *LocationRequest `json:"location"` // Kept when left out, cleared by {}
 }
 
 type DeleteRequest struct {
@@ -40,12 +40,14 @@ type DeleteRequest struct {
 }
 
 type AuthResponse struct {
-	Token string      `json:"token"`
-	User  models.User `json:"user"`
+	Token          string      `json:"token"` // Short-lived access token
+	TokenExpiresAt time.Time   `json:"token_expires_at"`
+	RefreshToken   string      `json:"refresh_token"` // Trade for a new token at /auth/refresh
+	User           models.User `json:"user"`
 }
 
 // RegisterUser creates a new user account
-func RegisterUser(c *gin.Context) {
+func (h *Handler) RegisterUser(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -53,12 +55,21 @@ func RegisterUser(c *gin.Context) {
 	}
 
 	// Check if user already exists
-	var existingUser models.User
-	if err := database.DB.Where("email = ? OR username = ?", req.Email, req.Username).First(&existingUser).Error; err == nil {
+	if _, err := h.Users.FindByEmailOrUsername(c.Request.Context(), req.Email, req.Username); err == nil {
 		c.JSON(http.StatusConflict, gin.H{"error": "User with this email or username already exists"})
 		return
 	}
 
+	var location models.Location
+	if req.Location != nil {
+		var err error
+		if location, err = normalizeLocation(*req.Location); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		h.locate(c.Request.Context(), &location, "")
+	}
+
 	// Hash password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -73,32 +84,38 @@ func RegisterUser(c *gin.Context) {
 		Password:  string(hashedPassword),
 		FirstName: req.FirstName,
 		LastName:  req.LastName,
-		Location:  req.Location,
+		Location:  location,
+		Role:      models.RoleUser,
 	}
 
-	if err := database.DB.Create(&user).Error; err != nil {
+	if err := h.Users.Create(c.Request.Context(), &user); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 		return
 	}
 
-	// Generate JWT token
-	token, err := generateJWT(user.ID.String())
+	// The account works right away; the user can ask for another email if this one fails
+	if err := h.sendVerificationEmail(c.Request.Context(), user); err != nil {
+		log.Printf("Failed to send verification email to user %s: %v", user.ID, err)
+	}
+
+	// Start a session and generate its tokens
+	session, refreshToken, err := h.startSession(c, user.ID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start session"})
 		return
 	}
 
-	// Remove password from response
-	user.Password = ""
+	response, err := newAuthResponse(user, session, refreshToken)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
 
-	c.JSON(http.StatusCreated, AuthResponse{
-		Token: token,
-		User:  user,
-	})
+	c.JSON(http.StatusCreated, response)
 }
 
-// LoginUser authenticates a user and returns a JWT token
-func LoginUser(c *gin.Context) {
+// LoginUser authenticates a user and starts a session
+func (h *Handler) LoginUser(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -106,41 +123,65 @@ func LoginUser(c *gin.Context) {
 	}
 
 	// Find user by email
-	var user models.User
-	if err := database.DB.Where("email = ?", req.Email).First(&user).Error; err != nil {
+	user, err := h.Users.FindByEmail(c.Request.Context(), req.Email)
+	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
 		return
 	}
 
+	// Refuse to check passwords while the account is locked
+	if !checkLockout(c, user) {
+		return
+	}
+
 	// Check password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		if err := recordFailedLogin(h.DB, user.ID); err != nil {
+			log.Printf("Failed to record failed login for user %s: %v", user.ID, err)
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
 		return
 	}
 
-	// Generate JWT token
-	token, err := generateJWT(user.ID.String())
+	// With two-factor login on, the password only earns a token for /auth/login/mfa
+	if user.TOTPEnabledAt != nil {
+		challenge, err := newMFAChallenge(user.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+			return
+		}
+		c.JSON(http.StatusOK, challenge)
+		return
+	}
+
+	if err := resetFailedLogins(h.DB, user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+		return
+	}
+
+	// Start a session and generate its tokens
+	session, refreshToken, err := h.startSession(c, user.ID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start session"})
 		return
 	}
 
-	// Remove password from response
-	user.Password = ""
+	response, err := newAuthResponse(user, session, refreshToken)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
 
-	c.JSON(http.StatusOK, AuthResponse{
-		Token: token,
-		User:  user,
-	})
+	c.JSON(http.StatusOK, response)
 }
 
 // GetUserProfile returns the current user's profile
-func GetUserProfile(c *gin.Context) {
+func (h *Handler) GetUserProfile(c *gin.Context) {
 	userID := c.MustGet("user_id").(uuid.UUID)
 
 	// Find user by ID
-	var user models.User
-	if err := database.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+	user, err := h.Users.FindByID(c.Request.Context(), userID)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
@@ -151,7 +192,7 @@ func GetUserProfile(c *gin.Context) {
 }
 
 // UpdateUserProfile updates the current user's profile
-func UpdateUserProfile(c *gin.Context) {
+func (h *Handler) UpdateUserProfile(c *gin.Context) {
 	userID := c.MustGet("user_id").(uuid.UUID)
 
 	var req UpdateProfileRequest
@@ -161,8 +202,8 @@ func UpdateUserProfile(c *gin.Context) {
 	}
 
 	// Find user by ID
-	var user models.User
-	if err := database.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+	user, err := h.Users.FindByID(c.Request.Context(), userID)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "User not found"})
 		return
 	}
@@ -170,9 +211,20 @@ func UpdateUserProfile(c *gin.Context) {
 	// Update user fields
 	user.FirstName = req.FirstName
 	user.LastName = req.LastName
-	user.Location = req.Location
+	if req.Location != nil {
+		location, err := normalizeLocation(*req.Location)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 
-	if err := database.DB.Save(&user).Error; err != nil {
+		// Geocode again only when the user moved, unless coordinates were given
+		keepCoordinates(&location, user.Location)
+		h.locate(c.Request.Context(), &location, "")
+		user.Location = location
+	}
+
+	if err := h.Users.Update(c.Request.Context(), &user); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user profile"})
 		return
 	}
@@ -184,7 +236,7 @@ func UpdateUserProfile(c *gin.Context) {
 }
 
 // DeleteUserAccount deletes the current user's account
-func DeleteUserAccount(c *gin.Context) {
+func (h *Handler) DeleteUserAccount(c *gin.Context) {
 	userID := c.MustGet("user_id").(uuid.UUID)
 
 	var req DeleteRequest
@@ -194,8 +246,8 @@ func DeleteUserAccount(c *gin.Context) {
 	}
 
 	// Find user by ID
-	var user models.User
-	if err := database.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+	user, err := h.Users.FindByID(c.Request.Context(), userID)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found."})
 		return
 	}
@@ -206,14 +258,54 @@ func DeleteUserAccount(c *gin.Context) {
 		return
 	}
 
+	// Delete user's uploaded media
+	if err := deleteMedia(c.Request.Context(), h.DB, "user_id = ?", userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user data"})
+		return
+	}
+
 	// Delete user's show attendance
-	if err := database.DB.Where("user_id = ?", userID).Delete(&models.ShowAttendance{}).Error; err != nil {
+	if err := h.Attendances.DeleteByUser(c.Request.Context(), userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user data"})
+		return
+	}
+
+	// Delete user's follow relationships
+	if err := deleteFollows(h.DB, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user data"})
+		return
+	}
+
+	// Delete user's change requests and unlink the ones they reviewed
+	if err := deleteChangeRequests(h.DB, "user_id = ?", userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user data"})
+		return
+	}
+	if err := h.DB.Model(&models.ChangeRequest{}).Where("reviewer_id = ?", userID).Update("reviewer_id", nil).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user data"})
+		return
+	}
+
+	// Delete user's sessions, which also invalidates their tokens
+	if err := deleteSessions(h.DB, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user data"})
+		return
+	}
+
+	// Delete user's reset and verification tokens
+	if err := deleteUserTokens(h.DB, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user data"})
+		return
+	}
+
+	// Delete user's recovery codes
+	if err := deleteRecoveryCodes(h.DB, userID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user data"})
 		return
 	}
 
 	// Hard delete user
-	if err := database.DB.Unscoped().Delete(&user).Error; err != nil {
+	if err := h.Users.Delete(c.Request.Context(), user.ID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user account"})
 		return
 	}
@@ -222,16 +314,38 @@ func DeleteUserAccount(c *gin.Context) {
 
 }
 
-// generateJWT creates a JWT token for the given user ID
-func generateJWT(userID string) (string, error) {
+// newAuthResponse issues an access token for a session and bundles it with its refresh token
+func newAuthResponse(user models.User, session models.Session, refreshToken string) (AuthResponse, error) {
+	token, expiresAt, err := generateJWT(user.ID, session.ID)
+	if err != nil {
+		return AuthResponse{}, err
+	}
+
+	// Remove password from response
+	user.Password = ""
+
+	return AuthResponse{
+		Token:          token,
+		TokenExpiresAt: expiresAt,
+		RefreshToken:   refreshToken,
+		User:           user,
+	}, nil
+}
+
+// generateJWT creates a short-lived access token for the given user and session
+func generateJWT(userID, sessionID uuid.UUID) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(accessTokenTTL)
 	claims := jwt.MapClaims{
-		"user_id": userID,
-		"exp":     time.Now().Add(time.Hour * 24 * 7).Unix(), // 7 days
-		"iat":     time.Now().Unix(),
+		"user_id":    userID.String(),
+		"session_id": sessionID.String(),
+		"exp":        expiresAt.Unix(),
+		"iat":        now.Unix(),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(getJWTSecret())
+	signed, err := token.SignedString(getJWTSecret())
+	return signed, expiresAt, err
 }
 
 // Get the JWT secret from environment variable
@@ -243,7 +357,8 @@ func getJWTSecret() []byte {
 	return []byte(secret)
 }
 
-func AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware accepts access tokens whose session is still active
+func (h *Handler) AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get the Authorization header
 		authHeader := c.GetHeader("Authorization")
@@ -296,8 +411,23 @@ func AuthMiddleware() gin.HandlerFunc {
 				return
 			}
 
-			// Store user ID in context for handlers to use
+			// Reject tokens whose session was revoked
+			sessionIDStr, _ := claims["session_id"].(string)
+			sessionID, err := uuid.Parse(sessionIDStr)
+			if err != nil {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid session in token"})
+				c.Abort()
+				return
+			}
+			if _, err := activeSession(h.DB, sessionID, userID); err != nil {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Session expired or revoked"})
+				c.Abort()
+				return
+			}
+
+			// Store user and session IDs in context for handlers to use
 			c.Set("user_id", userID)
+			c.Set("session_id", sessionID)
 
 			// Continue to the next handler
 			c.Next()
@@ -4,8 +4,8 @@ import (
 	"net/http"
 	"strings"
 
-	"jam-tracker/internal/database"
 	"jam-tracker/internal/models"
+	"jam-tracker/internal/repository"
 
 	"github.com/gin-gonic/gin"
 )
@@ -22,7 +22,7 @@ type UpdateBandRequest struct {
 	Description string `json:"description" binding:"required"`
 }
 
-func CreateBand(c *gin.Context) {
+func (h *Handler) CreateBand(c *gin.Context) {
 	var req CreateBandRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -30,8 +30,7 @@ func CreateBand(c *gin.Context) {
 	}
 
 	// Check if band already exists
-	var existingBand models.Band
-	if err := database.DB.Where("LOWER(name) = LOWER(?)", req.Name).First(&existingBand).Error; err == nil {
+	if _, err := h.Bands.FindByName(c.Request.Context(), strings.ToLower(req.Name)); err == nil {
 		c.JSON(http.StatusConflict, gin.H{"error": "Band with this name already exists"})
 		return
 	}
@@ -43,7 +42,7 @@ func CreateBand(c *gin.Context) {
 		Description: req.Description,
 	}
 
-	if err := database.DB.Create(&band).Error; err != nil {
+	if err := h.Bands.Create(c.Request.Context(), &band); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create band"})
 		return
 	}
@@ -51,23 +50,31 @@ func CreateBand(c *gin.Context) {
 	c.JSON(http.StatusCreated, band)
 }
 
-func GetBands(c *gin.Context) {
-	var bands []models.Band
-	if err := database.DB.Find(&bands).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve bands"})
+// bandListOptions are the sorts GetBands accepts
+var bandListOptions = ListOptions[models.Band]{
+	Spec:         repository.BandSorts,
+	DefaultSort:  "name",
+	DefaultOrder: "asc",
+}
+
+func (h *Handler) GetBands(c *gin.Context) {
+	bands, ok := paginate(c, bandListOptions, func(page repository.PageRequest) (repository.Page[models.Band], error) {
+		return h.Bands.List(c.Request.Context(), page)
+	})
+	if !ok {
 		return
 	}
 
 	c.JSON(http.StatusOK, bands)
 }
 
-func GetBand(c *gin.Context) {
+func (h *Handler) GetBand(c *gin.Context) {
 	bandName := c.Param("id")
 	normalizedBandName := strings.ToLower(strings.TrimSpace(strings.ReplaceAll(bandName, "-", " ")))
 
 	// Find band by name
-	var band models.Band
-	if err := database.DB.Where("name = ?", normalizedBandName).First(&band).Error; err != nil {
+	band, err := h.Bands.FindByName(c.Request.Context(), normalizedBandName)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Band not found"})
 		return
 	}
@@ -75,7 +82,7 @@ func GetBand(c *gin.Context) {
 	c.JSON(http.StatusOK, band)
 }
 
-func UpdateBand(c *gin.Context) {
+func (h *Handler) UpdateBand(c *gin.Context) {
 	// Get band name from URL
 	bandName := c.Param("id")
 	normalizedBandName := strings.ToLower(strings.TrimSpace(strings.ReplaceAll(bandName, "-", " ")))
@@ -88,19 +95,13 @@ func UpdateBand(c *gin.Context) {
 	}
 
 	// Find existing band
-	var band models.Band
-	if err := database.DB.Where("name = ?", normalizedBandName).First(&band).Error; err != nil {
+	band, err := h.Bands.FindByName(c.Request.Context(), normalizedBandName)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Band not found"})
 		return
 	}
 
-	// Update the band
-	if err := database.DB.Model(&band).Updates(models.Band{
-		Name:        strings.ToLower(req.Name),
-		Genre:       req.Genre,
-		Description: req.Description,
-	}).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update band"})
+	if !h.applyBandUpdate(c, &band, req) {
 		return
 	}
 
@@ -108,19 +109,77 @@ func UpdateBand(c *gin.Context) {
 	c.JSON(http.StatusOK, band)
 }
 
-func DeleteBand(c *gin.Context) {
+// applyBandUpdate saves req onto band. It writes the error response itself and
+// returns false when the update failed.
+func (h *Handler) applyBandUpdate(c *gin.Context, band *models.Band, req UpdateBandRequest) bool {
+	band.Name = strings.ToLower(req.Name)
+	band.Genre = req.Genre
+	band.Description = req.Description
+
+	if err := h.Bands.Update(c.Request.Context(), band); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update band"})
+		return false
+	}
+	return true
+}
+
+func (h *Handler) DeleteBand(c *gin.Context) {
 	bandName := c.Param("id")
 	normalizedBandName := strings.ToLower(strings.TrimSpace(strings.ReplaceAll(bandName, "-", " ")))
 
 	// Find existing band
-	var band models.Band
-	if err := database.DB.Where("name = ?", normalizedBandName).First(&band).Error; err != nil {
+	band, err := h.Bands.FindByName(c.Request.Context(), normalizedBandName)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Band not found"})
 		return
 	}
 
+	// Check if the band plays any shows, headlining or not
+	var showCount int64
+	if err := h.DB.Model(&models.ShowPerformance{}).Where("band_id = ?", band.ID).Count(&showCount).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check band usage"})
+		return
+	}
+
+	if showCount > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":       "Cannot delete band that has shows associated with it",
+			"shows_count": showCount,
+		})
+		return
+	}
+
+	// Songs still in setlists, such as of shows the band no longer headlines, keep the band
+	var songCount int64
+	if err := h.DB.Model(&models.Song{}).
+		Where("band_id = ? AND id IN (?)", band.ID, h.DB.Model(&models.SetlistEntry{}).Select("song_id")).
+		Count(&songCount).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check band usage"})
+		return
+	}
+
+	if songCount > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":       "Cannot delete band that has songs in setlists",
+			"songs_count": songCount,
+		})
+		return
+	}
+
+	// Delete suggested changes to the band
+	if err := deleteChangeRequests(h.DB, "target_type = ? AND target_id = ?", models.ChangeTargetBand, band.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete change requests"})
+		return
+	}
+
+	// Delete the tours of the band
+	if err := deleteTours(h.DB, "band_id = ?", band.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete tours"})
+		return
+	}
+
 	// Delete the band
-	if err := database.DB.Delete(&band).Error; err != nil {
+	if err := h.Bands.Delete(c.Request.Context(), band.ID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete band"})
 		return
 	}
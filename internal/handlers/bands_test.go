@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"jam-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCreateBandStoresNameLowercase(t *testing.T) {
+	s := newTestServer(t)
+
+	band := decode[models.Band](t, s.do(http.MethodPost, "/api/v1/bands", gin.H{"band_name": "King Gizzard", "genre": "psych"}, http.StatusCreated))
+	if band.Name != "king gizzard" {
+		t.Errorf("name = %q, want %q", band.Name, "king gizzard")
+	}
+
+	s.do(http.MethodPost, "/api/v1/bands", gin.H{"band_name": "KING GIZZARD"}, http.StatusConflict)
+}
+
+func TestGetBandBySlug(t *testing.T) {
+	s := newTestServer(t)
+	s.do(http.MethodPost, "/api/v1/bands", gin.H{"band_name": "king gizzard"}, http.StatusCreated)
+
+	band := decode[models.Band](t, s.do(http.MethodGet, "/api/v1/bands/King-Gizzard", nil, http.StatusOK))
+	if band.Name != "king gizzard" {
+		t.Errorf("name = %q, want %q", band.Name, "king gizzard")
+	}
+
+	s.do(http.MethodGet, "/api/v1/bands/goose", nil, http.StatusNotFound)
+}
+
+func TestUpdateBand(t *testing.T) {
+	s := newTestServer(t)
+	s.do(http.MethodPost, "/api/v1/bands", gin.H{"band_name": "goose"}, http.StatusCreated)
+
+	s.do(http.MethodPut, "/api/v1/bands/goose", gin.H{"band_name": "Goose", "genre": "jam", "description": "From Connecticut"}, http.StatusOK)
+
+	band := decode[models.Band](t, s.do(http.MethodGet, "/api/v1/bands/goose", nil, http.StatusOK))
+	if band.Genre != "jam" || band.Description != "From Connecticut" {
+		t.Errorf("band = %+v, want the updated genre and description", band)
+	}
+
+	s.do(http.MethodPut, "/api/v1/bands/phish", gin.H{"band_name": "phish", "genre": "jam", "description": "Vermont"}, http.StatusNotFound)
+}
+
+func TestGetBandsPaginates(t *testing.T) {
+	s := newTestServer(t)
+	for _, name := range []string{"phish", "goose", "dead"} {
+		s.do(http.MethodPost, "/api/v1/bands", gin.H{"band_name": name}, http.StatusCreated)
+	}
+
+	rec := s.do(http.MethodGet, "/api/v1/bands?limit=2", nil, http.StatusOK)
+	first := decode[[]models.Band](t, rec)
+	if len(first) != 2 || first[0].Name != "dead" || first[1].Name != "goose" {
+		t.Fatalf("first page = %v, want dead and goose", bandNames(first))
+	}
+	if total := rec.Header().Get(HeaderTotalCount); total != "3" {
+		t.Errorf("%s = %q, want 3", HeaderTotalCount, total)
+	}
+
+	cursor := rec.Header().Get(HeaderNextCursor)
+	if cursor == "" {
+		t.Fatalf("no %s on the first page", HeaderNextCursor)
+	}
+
+	rec = s.do(http.MethodGet, "/api/v1/bands?limit=2&cursor="+cursor, nil, http.StatusOK)
+	second := decode[[]models.Band](t, rec)
+	if len(second) != 1 || second[0].Name != "phish" {
+		t.Errorf("second page = %v, want phish", bandNames(second))
+	}
+	if next := rec.Header().Get(HeaderNextCursor); next != "" {
+		t.Errorf("%s = %q on the last page, want none", HeaderNextCursor, next)
+	}
+
+	s.do(http.MethodGet, "/api/v1/bands?sort=rating", nil, http.StatusBadRequest)
+}
+
+func bandNames(bands []models.Band) []string {
+	names := make([]string, len(bands))
+	for i, band := range bands {
+		names[i] = band.Name
+	}
+	return names
+}
@@ -0,0 +1,441 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"jam-tracker/internal/models"
+	"jam-tracker/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	errChangeRequestReviewed = errors.New("change request was already reviewed")
+	errResponseWritten       = errors.New("error response written") // Rolls back a transaction whose handler already answered
+)
+
+type SuggestChangeRequest struct {
+	Changes map[string]any `json:"changes" binding:"required"`
+	Comment string         `json:"comment" binding:"omitempty,max=500"`
+}
+
+type ReviewChangeRequest struct {
+	Note string `json:"note" binding:"omitempty,max=500"`
+}
+
+// changeFields are the fields a change request may set on each target, keyed like
+// the target's update request and mapped to whether the value may be empty
+var changeFields = map[models.ChangeTarget]map[string]bool{
+	models.ChangeTargetBand: {
+		"band_name":   false,
+		"genre":       false,
+		"description": false,
+	},
+	models.ChangeTargetVenue: {
+		"city":     false,
+		"state":    false,
+		"country":  false,
+		"timezone": false,
+		"address":  false,
+		"capacity": false,
+	},
+	models.ChangeTargetShow: {
+		"band_name":   false,
+		"venue_name":  false,
+		"date":        false,
+		"festival_id": true,
+		"stage":       true,
+		"notes":       true,
+	},
+}
+
+// changeRequestListOptions order change requests by when they were suggested
+var changeRequestListOptions = ListOptions[models.ChangeRequest]{
+	Spec: repository.SortSpec[models.ChangeRequest]{
+		Fields: map[string]repository.SortField[models.ChangeRequest]{
+			"created_at": {Column: "created_at", Type: repository.SortTime, Value: func(cr models.ChangeRequest) any { return cr.CreatedAt }},
+		},
+		IDColumn: "id",
+		ID:       func(cr models.ChangeRequest) uuid.UUID { return cr.ID },
+	},
+	DefaultSort:  "created_at",
+	DefaultOrder: "asc", // Oldest first, so the queue is worked in order
+}
+
+// SuggestBandChange proposes a change to a band for an editor to review
+func (h *Handler) SuggestBandChange(c *gin.Context) {
+	bandName := c.Param("id")
+	normalizedBandName := strings.ToLower(strings.TrimSpace(strings.ReplaceAll(bandName, "-", " ")))
+
+	band, err := h.Bands.FindByName(c.Request.Context(), normalizedBandName)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Band not found"})
+		return
+	}
+
+	h.suggestChange(c, models.ChangeTargetBand, band.ID)
+}
+
+// SuggestVenueChange proposes a change to a venue for an editor to review
+func (h *Handler) SuggestVenueChange(c *gin.Context) {
+	venueName := c.Param("id")
+	normalizedVenueName := strings.ToLower(strings.TrimSpace(strings.ReplaceAll(venueName, "-", " ")))
+
+	venue, err := h.Venues.FindByName(c.Request.Context(), normalizedVenueName)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Venue not found"})
+		return
+	}
+
+	h.suggestChange(c, models.ChangeTargetVenue, venue.ID)
+}
+
+// SuggestShowChange proposes a change to a show for an editor to review
+func (h *Handler) SuggestShowChange(c *gin.Context) {
+	showID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid show ID"})
+		return
+	}
+
+	show, err := h.Shows.FindByID(c.Request.Context(), showID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Show not found"})
+		return
+	}
+
+	h.suggestChange(c, models.ChangeTargetShow, show.ID)
+}
+
+// GetChangeRequests returns the moderation queue. Pending requests are listed
+// unless ?status= asks for another one.
+func (h *Handler) GetChangeRequests(c *gin.Context) {
+	status := models.ChangeRequestStatus(c.DefaultQuery("status", string(models.ChangeRequestStatusPending)))
+	if !isChangeRequestStatus(status) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be one of pending, approved or rejected"})
+		return
+	}
+
+	query := h.DB.Where("status = ?", status)
+
+	if targetType := c.Query("target_type"); targetType != "" {
+		if _, ok := changeFields[models.ChangeTarget(targetType)]; !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "target_type must be one of band, venue or show"})
+			return
+		}
+		query = query.Where("target_type = ?", targetType)
+	}
+
+	changeRequests, ok := paginateQuery(c, query, changeRequestListOptions)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, changeRequests)
+}
+
+// GetMyChangeRequests returns the change requests of the current user
+func (h *Handler) GetMyChangeRequests(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	changeRequests, ok := paginateQuery(c, h.DB.Where("user_id = ?", userID), changeRequestListOptions)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, changeRequests)
+}
+
+// ApproveChangeRequest applies a pending change request to its target
+func (h *Handler) ApproveChangeRequest(c *gin.Context) {
+	var req ReviewChangeRequest
+	if !bindReview(c, &req) {
+		return
+	}
+
+	changeRequest, ok := h.pendingChangeRequest(c)
+	if !ok {
+		return
+	}
+
+	// Claim the request before applying it, so of two concurrent approvals only one
+	// applies the changes, and undo the claim when they cannot be applied
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := claimChangeRequest(c, tx, &changeRequest, models.ChangeRequestStatusApproved, req.Note); err != nil {
+			return err
+		}
+
+		// Apply through the same logic as a direct update
+		if !h.withDB(tx).applyChangeRequest(c, changeRequest) {
+			return errResponseWritten
+		}
+		return nil
+	})
+	if errors.Is(err, errResponseWritten) {
+		return
+	}
+	if errors.Is(err, errChangeRequestReviewed) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Change request was already reviewed"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update change request"})
+		return
+	}
+
+	c.JSON(http.StatusOK, changeRequest)
+}
+
+// RejectChangeRequest closes a pending change request without applying it
+func (h *Handler) RejectChangeRequest(c *gin.Context) {
+	var req ReviewChangeRequest
+	if !bindReview(c, &req) {
+		return
+	}
+
+	changeRequest, ok := h.pendingChangeRequest(c)
+	if !ok {
+		return
+	}
+
+	err := claimChangeRequest(c, h.DB, &changeRequest, models.ChangeRequestStatusRejected, req.Note)
+	if errors.Is(err, errChangeRequestReviewed) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Change request was already reviewed"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update change request"})
+		return
+	}
+
+	c.JSON(http.StatusOK, changeRequest)
+}
+
+func (h *Handler) suggestChange(c *gin.Context, target models.ChangeTarget, targetID uuid.UUID) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	var req SuggestChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := validateChanges(target, req.Changes); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	changeRequest := models.ChangeRequest{
+		UserID:     userID,
+		TargetType: target,
+		TargetID:   targetID,
+		Changes:    req.Changes,
+		Comment:    strings.TrimSpace(req.Comment),
+		Status:     models.ChangeRequestStatusPending,
+	}
+
+	if err := h.DB.Create(&changeRequest).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create change request"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, changeRequest)
+}
+
+// pendingChangeRequest loads the change request in the URL. It writes the error
+// response itself and returns false when it is missing or already reviewed.
+func (h *Handler) pendingChangeRequest(c *gin.Context) (models.ChangeRequest, bool) {
+	var changeRequest models.ChangeRequest
+
+	changeRequestID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid change request ID"})
+		return changeRequest, false
+	}
+
+	err = h.DB.Where("id = ?", changeRequestID).First(&changeRequest).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Change request not found"})
+		return changeRequest, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load change request"})
+		return changeRequest, false
+	}
+
+	if changeRequest.Status != models.ChangeRequestStatusPending {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Change request was already %s", changeRequest.Status)})
+		return changeRequest, false
+	}
+
+	return changeRequest, true
+}
+
+// applyChangeRequest lays the changes over the current state of the target and
+// saves it. It writes the error response itself and returns false when it failed.
+func (h *Handler) applyChangeRequest(c *gin.Context, changeRequest models.ChangeRequest) bool {
+	ctx := c.Request.Context()
+
+	switch changeRequest.TargetType {
+	case models.ChangeTargetBand:
+		band, err := h.Bands.FindByID(ctx, changeRequest.TargetID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Band not found"})
+			return false
+		}
+
+		req := UpdateBandRequest{Name: band.Name, Genre: band.Genre, Description: band.Description}
+		if !mergeChanges(c, changeRequest.Changes, &req) {
+			return false
+		}
+		return h.applyBandUpdate(c, &band, req)
+
+	case models.ChangeTargetVenue:
+		venue, err := h.Venues.FindByID(ctx, changeRequest.TargetID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Venue not found"})
+			return false
+		}
+
+		req := UpdateVenueRequest{
+			Name:            venue.Name,
+			LocationRequest: LocationRequest{City: venue.City, State: venue.State, Country: venue.Country},
+			Address:         venue.Address,
+			Capacity:        venue.Capacity,
+		}
+		if !mergeChanges(c, changeRequest.Changes, &req) {
+			return false
+		}
+		return h.applyVenueUpdate(c, &venue, req)
+
+	case models.ChangeTargetShow:
+		show, err := h.Shows.FindByID(ctx, changeRequest.TargetID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Show not found"})
+			return false
+		}
+
+		// The bill is kept, with the headliner replaced when band_name changes
+		req := UpdateShowRequest{
+			BandName:  show.Band.Name,
+			VenueName: show.Venue.Name,
+			Date:      show.Date.Format(time.RFC3339),
+			Stage:     show.Stage,
+			Notes:     show.Notes,
+		}
+		if show.FestivalID != nil {
+			req.FestivalID = show.FestivalID.String()
+		}
+		if !mergeChanges(c, changeRequest.Changes, &req) {
+			return false
+		}
+		return h.applyShowUpdate(c, &show, req)
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Unknown change request target"})
+	return false
+}
+
+// claimChangeRequest records the decision of the current editor. Only the first
+// of two concurrent reviews succeeds, the other gets errChangeRequestReviewed.
+func claimChangeRequest(c *gin.Context, db *gorm.DB, changeRequest *models.ChangeRequest, status models.ChangeRequestStatus, note string) error {
+	reviewerID := c.MustGet("user_id").(uuid.UUID)
+	note = strings.TrimSpace(note)
+	now := time.Now()
+
+	result := db.Model(&models.ChangeRequest{}).
+		Where("id = ? AND status = ?", changeRequest.ID, models.ChangeRequestStatusPending).
+		Updates(map[string]any{
+			"status":      status,
+			"reviewer_id": reviewerID,
+			"review_note": note,
+			"reviewed_at": now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return errChangeRequestReviewed
+	}
+
+	changeRequest.Status = status
+	changeRequest.ReviewerID = &reviewerID
+	changeRequest.ReviewNote = note
+	changeRequest.ReviewedAt = &now
+	changeRequest.UpdatedAt = now
+	return nil
+}
+
+// bindReview reads the optional review note
+func bindReview(c *gin.Context, req *ReviewChangeRequest) bool {
+	if err := c.ShouldBindJSON(req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// validateChanges checks that changes only sets known fields of the target to strings
+func validateChanges(target models.ChangeTarget, changes map[string]any) error {
+	if len(changes) == 0 {
+		return errors.New("changes must set at least one field")
+	}
+
+	fields := changeFields[target]
+	for field, value := range changes {
+		allowEmpty, ok := fields[field]
+		if !ok {
+			return fmt.Errorf("%s is not a field of a %s that can be changed", field, target)
+		}
+
+		text, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s must be a string", field)
+		}
+		if !allowEmpty && strings.TrimSpace(text) == "" {
+			return fmt.Errorf("%s cannot be empty", field)
+		}
+	}
+	return nil
+}
+
+// mergeChanges overwrites the fields of req named in changes and checks the result
+// the way a direct update is checked
+func mergeChanges(c *gin.Context, changes map[string]any, req any) bool {
+	raw, err := json.Marshal(changes)
+	if err == nil {
+		err = json.Unmarshal(raw, req)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read changes"})
+		return false
+	}
+
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// deleteChangeRequests removes the change requests matching the condition
+func deleteChangeRequests(db *gorm.DB, query interface{}, args ...interface{}) error {
+	return db.Where(query, args...).Delete(&models.ChangeRequest{}).Error
+}
+
+func isChangeRequestStatus(status models.ChangeRequestStatus) bool {
+	switch status {
+	case models.ChangeRequestStatusPending, models.ChangeRequestStatusApproved, models.ChangeRequestStatusRejected:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"jam-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestApproveChangeRequestAppliesOnce(t *testing.T) {
+	s := newAPIServer(t)
+	s.do(http.MethodPost, "/api/v1/bands", gin.H{"band_name": "goose", "genre": "rock", "description": "From Connecticut"}, http.StatusCreated)
+
+	fan := s.register("fan@example.com", "fan")
+	var changeRequest models.ChangeRequest
+	s.as(fan.Token, func() {
+		changeRequest = decode[models.ChangeRequest](t, s.do(http.MethodPost, "/api/v1/bands/goose/changes",
+			gin.H{"changes": gin.H{"genre": "jam"}, "comment": "They jam"}, http.StatusCreated))
+	})
+
+	approved := decode[models.ChangeRequest](t, s.do(http.MethodPost, "/api/v1/changes/"+changeRequest.ID.String()+"/approve",
+		gin.H{"note": "Thanks"}, http.StatusOK))
+	if approved.Status != models.ChangeRequestStatusApproved || approved.ReviewerID == nil || *approved.ReviewerID != s.userID {
+		t.Errorf("approved request = %+v, want approved by the editor", approved)
+	}
+
+	band := decode[models.Band](t, s.do(http.MethodGet, "/api/v1/bands/goose", nil, http.StatusOK))
+	if band.Genre != "jam" || band.Description != "From Connecticut" {
+		t.Errorf("band = %+v, want the new genre and the old description", band)
+	}
+
+	s.do(http.MethodPost, "/api/v1/changes/"+changeRequest.ID.String()+"/approve", nil, http.StatusConflict)
+	s.do(http.MethodPost, "/api/v1/changes/"+changeRequest.ID.String()+"/reject", nil, http.StatusConflict)
+}
+
+func TestApproveChangeRequestValidatesMergedChanges(t *testing.T) {
+	s := newAPIServer(t)
+	s.do(http.MethodPost, "/api/v1/venues", gin.H{"name": "Red Rocks", "city": "Morrison", "state": "CO", "country": "USA"}, http.StatusCreated)
+
+	changeRequest := decode[models.ChangeRequest](t, s.do(http.MethodPost, "/api/v1/venues/red-rocks/changes",
+		gin.H{"changes": gin.H{"address": strings.Repeat("x", 201)}}, http.StatusCreated))
+
+	s.do(http.MethodPost, "/api/v1/changes/"+changeRequest.ID.String()+"/approve", nil, http.StatusBadRequest)
+
+	// The claim was rolled back with the failed update, so the request can still be reviewed
+	var got models.ChangeRequest
+	if err := s.db.First(&got, "id = ?", changeRequest.ID).Error; err != nil {
+		t.Fatalf("load change request: %v", err)
+	}
+	if got.Status != models.ChangeRequestStatusPending || got.ReviewerID != nil {
+		t.Errorf("change request = %+v after a failed approval, want it still pending", got)
+	}
+
+	var venue models.Venue
+	if err := s.db.First(&venue, "name = ?", "red rocks").Error; err != nil {
+		t.Fatalf("load venue: %v", err)
+	}
+	if venue.Address != "" {
+		t.Errorf("address = %q, want the invalid change left out", venue.Address)
+	}
+
+	s.do(http.MethodPost, "/api/v1/changes/"+changeRequest.ID.String()+"/reject", gin.H{"note": "Too long"}, http.StatusOK)
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"jam-tracker/internal/database"
+	"jam-tracker/internal/models"
+	"jam-tracker/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CreateFestivalRequest struct {
+	Name        string `json:"name" binding:"required,min=1,max=200"`
+	Description string `json:"description" binding:"omitempty,max=1000"`
+	StartDate   string `json:"start_date" binding:"required"` // Format: "2024-06-13"
+	EndDate     string `json:"end_date" binding:"required"`
+}
+
+type UpdateFestivalRequest struct {
+	Name        string `json:"name" binding:"required,min=1,max=200"`
+	Description string `json:"description" binding:"omitempty,max=1000"`
+	StartDate   string `json:"start_date" binding:"required"`
+	EndDate     string `json:"end_date" binding:"required"`
+}
+
+// festivalListOptions are the sorts GetFestivals accepts
+var festivalListOptions = ListOptions[models.Festival]{
+	Spec: repository.SortSpec[models.Festival]{
+		Fields: map[string]repository.SortField[models.Festival]{
+			"start_date": {Column: "start_date", Type: repository.SortText, Value: func(f models.Festival) any { return f.StartDate }},
+			"name":       {Column: "name", Type: repository.SortText, Value: func(f models.Festival) any { return f.Name }},
+			"created_at": {Column: "created_at", Type: repository.SortTime, Value: func(f models.Festival) any { return f.CreatedAt }},
+		},
+		IDColumn: "id",
+		ID:       func(f models.Festival) uuid.UUID { return f.ID },
+	},
+	DefaultSort:  "start_date",
+	DefaultOrder: "desc", // Most recent first
+}
+
+func (h *Handler) CreateFestival(c *gin.Context) {
+	var req CreateFestivalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := validateFestivalDates(req.StartDate, req.EndDate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	festival := models.Festival{
+		Name:        strings.TrimSpace(req.Name),
+		Description: strings.TrimSpace(req.Description),
+		StartDate:   req.StartDate,
+		EndDate:     req.EndDate,
+	}
+
+	if err := h.DB.Create(&festival).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create festival"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, festival)
+}
+
+// GetFestivals lists festivals, optionally filtered by ?name=
+func (h *Handler) GetFestivals(c *gin.Context) {
+	query := h.DB.Model(&models.Festival{})
+	if name := strings.TrimSpace(c.Query("name")); name != "" {
+		query = query.Where(database.DialectOf(h.DB).Contains("name"), database.ContainsPattern(name))
+	}
+
+	festivals, ok := paginateQuery(c, query, festivalListOptions)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, festivals)
+}
+
+// GetFestival returns a festival with its shows by day and stage
+func (h *Handler) GetFestival(c *gin.Context) {
+	festival, ok := h.findFestival(c)
+	if !ok {
+		return
+	}
+
+	if err := repository.PreloadShow(h.DB, "").Where("festival_id = ?", festival.ID).
+		Order("local_date, stage").Order(database.DialectOf(h.DB).Time("date")).
+		Find(&festival.Shows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch festival shows"})
+		return
+	}
+
+	c.JSON(http.StatusOK, festival)
+}
+
+func (h *Handler) UpdateFestival(c *gin.Context) {
+	var req UpdateFestivalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := validateFestivalDates(req.StartDate, req.EndDate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	festival, ok := h.findFestival(c)
+	if !ok {
+		return
+	}
+
+	// The shows of the festival have to stay within its dates
+	var outside int64
+	if err := h.DB.Model(&models.Show{}).
+		Where("festival_id = ? AND (local_date < ? OR local_date > ?)", festival.ID, req.StartDate, req.EndDate).
+		Count(&outside).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check festival shows"})
+		return
+	}
+	if outside > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":       "Festival has shows outside these dates",
+			"shows_count": outside,
+		})
+		return
+	}
+
+	festival.Name = strings.TrimSpace(req.Name)
+	festival.Description = strings.TrimSpace(req.Description)
+	festival.StartDate = req.StartDate
+	festival.EndDate = req.EndDate
+
+	if err := h.DB.Save(&festival).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update festival"})
+		return
+	}
+
+	c.JSON(http.StatusOK, festival)
+}
+
+// DeleteFestival removes a festival. Its shows stay, no longer grouped.
+func (h *Handler) DeleteFestival(c *gin.Context) {
+	festival, ok := h.findFestival(c)
+	if !ok {
+		return
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Show{}).Where("festival_id = ?", festival.ID).Update("festival_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Festival{}, "id = ?", festival.ID).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete festival"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Festival deleted successfully"})
+}
+
+// findFestival loads the festival in the :id parameter. It writes the error
+// response itself and returns false when there is none.
+func (h *Handler) findFestival(c *gin.Context) (models.Festival, bool) {
+	var festival models.Festival
+
+	festivalID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid festival ID"})
+		return festival, false
+	}
+
+	if err := h.DB.Where("id = ?", festivalID).First(&festival).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Festival not found"})
+		return festival, false
+	}
+	return festival, true
+}
+
+// findShowFestival looks up the festival a show belongs to, which has to include
+// the day of the show. An empty ID means no festival. It writes the error
+// response itself and returns false when the festival does not fit.
+func (h *Handler) findShowFestival(c *gin.Context, festivalID string, localDate string) (*uuid.UUID, bool) {
+	if festivalID == "" {
+		return nil, true
+	}
+
+	id, err := uuid.Parse(festivalID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid festival ID"})
+		return nil, false
+	}
+
+	var festival models.Festival
+	if err := h.DB.Where("id = ?", id).First(&festival).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Festival not found"})
+		return nil, false
+	}
+
+	if localDate < festival.StartDate || localDate > festival.EndDate {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Show date is outside the festival dates"})
+		return nil, false
+	}
+	return &festival.ID, true
+}
+
+// validateFestivalDates checks that both dates are YYYY-MM-DD and in order
+func validateFestivalDates(startDate, endDate string) error {
+	if parseDateParam(startDate) == nil || parseDateParam(endDate) == nil {
+		return errors.New("start_date and end_date must be YYYY-MM-DD")
+	}
+	if endDate < startDate {
+		return errors.New("end_date must not be before start_date")
+	}
+	return nil
+}
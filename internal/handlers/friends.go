@@ -0,0 +1,333 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"jam-tracker/internal/database"
+	"jam-tracker/internal/models"
+	"jam-tracker/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Feed item types
+const (
+	FeedItemAttended   = "attended"
+	FeedItemRated      = "rated"
+	FeedItemGoing      = "going"
+	FeedItemInterested = "interested"
+	FeedItemMissed     = "missed"
+)
+
+type FollowRequest struct {
+	Username string `json:"username" binding:"required,min=3"`
+}
+
+// UserSummary is the public view of another user
+type UserSummary struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+}
+
+type FollowResponse struct {
+	ID         uuid.UUID           `json:"id"`
+	Status     models.FollowStatus `json:"status"`
+	Follower   UserSummary         `json:"follower"`
+	Followee   UserSummary         `json:"followee"`
+	AcceptedAt *time.Time          `json:"accepted_at,omitempty"`
+	CreatedAt  time.Time           `json:"created_at"`
+}
+
+type FriendsResponse struct {
+	Following []UserSummary `json:"following"`
+	Followers []UserSummary `json:"followers"`
+}
+
+type FeedItem struct {
+	Type       string                `json:"type"` // attended, rated, going, interested or missed
+	OccurredAt time.Time             `json:"occurred_at"`
+	User       UserSummary           `json:"user"`
+	Attendance models.ShowAttendance `json:"attendance"`
+}
+
+// RequestFollow sends a follow request to another user
+func (h *Handler) RequestFollow(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	var req FollowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Find the user to follow
+	var followee models.User
+	if err := h.DB.Where("username = ?", strings.TrimSpace(req.Username)).First(&followee).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if followee.ID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot follow yourself"})
+		return
+	}
+
+	// Check if a request already exists
+	var existingFollow models.Follow
+	if err := h.DB.Where("follower_id = ? AND followee_id = ?", userID, followee.ID).First(&existingFollow).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Follow request already %s", existingFollow.Status)})
+		return
+	}
+
+	follow := models.Follow{
+		FollowerID: userID,
+		FolloweeID: followee.ID,
+		Status:     models.FollowStatusPending,
+	}
+
+	if err := h.DB.Create(&follow).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create follow request"})
+		return
+	}
+
+	if err := h.DB.Preload("Follower").Preload("Followee").First(&follow, follow.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load follow request"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, newFollowResponse(follow))
+}
+
+// GetFollowRequests returns pending follow requests sent to and by the current user
+func (h *Handler) GetFollowRequests(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	var follows []models.Follow
+	if err := h.DB.Preload("Follower").Preload("Followee").
+		Where("(followee_id = ? OR follower_id = ?) AND status = ?", userID, userID, models.FollowStatusPending).
+		Order("created_at DESC").
+		Find(&follows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch follow requests"})
+		return
+	}
+
+	incoming := []FollowResponse{}
+	outgoing := []FollowResponse{}
+	for _, follow := range follows {
+		if follow.FolloweeID == userID {
+			incoming = append(incoming, newFollowResponse(follow))
+		} else {
+			outgoing = append(outgoing, newFollowResponse(follow))
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"incoming": incoming, "outgoing": outgoing})
+}
+
+// AcceptFollowRequest accepts a pending follow request sent to the current user
+func (h *Handler) AcceptFollowRequest(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	followID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid follow request ID"})
+		return
+	}
+
+	var follow models.Follow
+	if err := h.DB.Where("id = ? AND followee_id = ?", followID, userID).First(&follow).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Follow request not found"})
+		return
+	}
+
+	if follow.Status == models.FollowStatusAccepted {
+		c.JSON(http.StatusConflict, gin.H{"error": "Follow request already accepted"})
+		return
+	}
+
+	now := time.Now()
+	follow.Status = models.FollowStatusAccepted
+	follow.AcceptedAt = &now
+
+	if err := h.DB.Save(&follow).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept follow request"})
+		return
+	}
+
+	if err := h.DB.Preload("Follower").Preload("Followee").First(&follow, follow.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load follow request"})
+		return
+	}
+
+	c.JSON(http.StatusOK, newFollowResponse(follow))
+}
+
+// DeleteFollowRequest rejects a request sent to the current user or cancels one they sent
+func (h *Handler) DeleteFollowRequest(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	followID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid follow request ID"})
+		return
+	}
+
+	var follow models.Follow
+	if err := h.DB.Where("id = ? AND (followee_id = ? OR follower_id = ?) AND status = ?",
+		followID, userID, userID, models.FollowStatusPending).First(&follow).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Follow request not found"})
+		return
+	}
+
+	if err := h.DB.Delete(&follow).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete follow request"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Follow request deleted successfully"})
+}
+
+// GetFriends returns the users the current user follows and the users following them
+func (h *Handler) GetFriends(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	var follows []models.Follow
+	if err := h.DB.Preload("Follower").Preload("Followee").
+		Where("(followee_id = ? OR follower_id = ?) AND status = ?", userID, userID, models.FollowStatusAccepted).
+		Order("accepted_at DESC").
+		Find(&follows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch friends"})
+		return
+	}
+
+	response := FriendsResponse{Following: []UserSummary{}, Followers: []UserSummary{}}
+	for _, follow := range follows {
+		if follow.FollowerID == userID {
+			response.Following = append(response.Following, newUserSummary(follow.Followee))
+		} else {
+			response.Followers = append(response.Followers, newUserSummary(follow.Follower))
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Unfollow stops the current user from following another user
+func (h *Handler) Unfollow(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	followeeID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	result := h.DB.Where("follower_id = ? AND followee_id = ?", userID, followeeID).Delete(&models.Follow{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unfollow user"})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "You are not following this user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Unfollowed user successfully"})
+}
+
+// feedListOptions order the feed by when the activity happened
+var feedListOptions = ListOptions[models.ShowAttendance]{
+	Spec: repository.SortSpec[models.ShowAttendance]{
+		Fields: map[string]repository.SortField[models.ShowAttendance]{
+			"occurred_at": {Column: "show_attendances.updated_at", Type: repository.SortTime, Value: func(a models.ShowAttendance) any { return a.UpdatedAt }},
+		},
+		IDColumn: "show_attendances.id",
+		ID:       func(a models.ShowAttendance) uuid.UUID { return a.ID },
+	},
+	DefaultSort:  "occurred_at",
+	DefaultOrder: "desc", // Newest first
+}
+
+// GetFeed returns the activity of followed users, newest first
+func (h *Handler) GetFeed(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	followees := h.DB.Model(&models.Follow{}).Select("followee_id").
+		Where("follower_id = ? AND status = ?", userID, models.FollowStatusAccepted)
+
+	// Going entries only show up while the show is still ahead
+	dialect := database.DialectOf(h.DB)
+	query := h.DB.Preload("User").Preload("Show.Band").Preload("Show.Venue").
+		Joins("JOIN shows ON shows.id = show_attendances.show_id").
+		Where("show_attendances.user_id IN (?)", followees).
+		Where("show_attendances.status <> ? OR "+dialect.Time("shows.date")+" >= "+dialect.Time("?"), models.AttendanceStatusGoing, time.Now())
+
+	attendances, ok := paginateQuery(c, query, feedListOptions)
+	if !ok {
+		return
+	}
+
+	items := make([]FeedItem, 0, len(attendances))
+	for _, attendance := range attendances {
+		user := attendance.User
+		attendance.User = models.User{}
+
+		items = append(items, FeedItem{
+			Type:       feedItemType(attendance),
+			OccurredAt: attendance.UpdatedAt,
+			User:       newUserSummary(user),
+			Attendance: attendance,
+		})
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+func feedItemType(attendance models.ShowAttendance) string {
+	switch attendance.Status {
+	case models.AttendanceStatusGoing:
+		return FeedItemGoing
+	case models.AttendanceStatusInterested:
+		return FeedItemInterested
+	case models.AttendanceStatusMissed:
+		return FeedItemMissed
+	}
+
+	if attendance.Rating != nil {
+		return FeedItemRated
+	}
+	return FeedItemAttended
+}
+
+func newUserSummary(user models.User) UserSummary {
+	return UserSummary{
+		ID:        user.ID,
+		Username:  user.Username,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+	}
+}
+
+func newFollowResponse(follow models.Follow) FollowResponse {
+	return FollowResponse{
+		ID:         follow.ID,
+		Status:     follow.Status,
+		Follower:   newUserSummary(follow.Follower),
+		Followee:   newUserSummary(follow.Followee),
+		AcceptedAt: follow.AcceptedAt,
+		CreatedAt:  follow.CreatedAt,
+	}
+}
+
+// deleteFollows removes every follow relationship involving the user
+func deleteFollows(db *gorm.DB, userID uuid.UUID) error {
+	return db.Where("follower_id = ? OR followee_id = ?", userID, userID).Delete(&models.Follow{}).Error
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"jam-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetFeedShowsGoingOnlyUntilTheShow(t *testing.T) {
+	s := newAPIServer(t)
+	s.do(http.MethodPost, "/api/v1/venues", gin.H{"name": "Red Rocks", "city": "Morrison", "state": "CO", "country": "USA"}, http.StatusCreated)
+	for _, name := range []string{"goose", "phish"} {
+		s.do(http.MethodPost, "/api/v1/bands", gin.H{"band_name": name}, http.StatusCreated)
+	}
+
+	// Shows are stored at the venue, hours behind UTC, which must not make a show
+	// later today look like it already happened
+	denver, err := time.LoadLocation("America/Denver")
+	if err != nil {
+		t.Fatalf("load timezone: %v", err)
+	}
+	now := time.Now().In(denver)
+	soon := decode[models.Show](t, s.do(http.MethodPost, "/api/v1/shows",
+		gin.H{"band_name": "goose", "venue_name": "red rocks", "date": now.Add(2 * time.Hour).Format(time.RFC3339)}, http.StatusCreated))
+	earlier := decode[models.Show](t, s.do(http.MethodPost, "/api/v1/shows",
+		gin.H{"band_name": "phish", "venue_name": "red rocks", "date": now.Add(-2 * time.Hour).Format(time.RFC3339)}, http.StatusCreated))
+
+	s.do(http.MethodPost, "/api/v1/shows/"+soon.ID.String()+"/attend", gin.H{"status": "going"}, http.StatusCreated)
+	going := decode[models.ShowAttendance](t, s.do(http.MethodPost, "/api/v1/shows/"+earlier.ID.String()+"/attend", gin.H{"status": "attended"}, http.StatusCreated))
+	if err := s.db.Model(&models.ShowAttendance{}).Where("id = ?", going.ID).Update("status", models.AttendanceStatusGoing).Error; err != nil {
+		t.Fatalf("mark attendance going: %v", err)
+	}
+
+	fan := s.register("fan@example.com", "fan")
+	var follow FollowResponse
+	s.as(fan.Token, func() {
+		follow = decode[FollowResponse](t, s.do(http.MethodPost, "/api/v1/friends/requests", gin.H{"username": "editor"}, http.StatusCreated))
+	})
+	s.do(http.MethodPost, "/api/v1/friends/requests/"+follow.ID.String()+"/accept", nil, http.StatusOK)
+
+	var feed []FeedItem
+	s.as(fan.Token, func() {
+		feed = decode[[]FeedItem](t, s.do(http.MethodGet, "/api/v1/feed", nil, http.StatusOK))
+	})
+	if len(feed) != 1 || feed[0].Type != FeedItemGoing || feed[0].Attendance.ShowID != soon.ID {
+		t.Errorf("feed = %+v, want only going to the show later today", feed)
+	}
+
+	// The editor follows nobody
+	if feed := decode[[]FeedItem](t, s.do(http.MethodGet, "/api/v1/feed", nil, http.StatusOK)); len(feed) != 0 {
+		t.Errorf("feed of a user following nobody = %+v, want empty", feed)
+	}
+}
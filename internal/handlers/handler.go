@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"jam-tracker/internal/geo"
+	"jam-tracker/internal/mailer"
+	"jam-tracker/internal/ratelimit"
+	"jam-tracker/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// Handler holds the dependencies of the HTTP handlers. Users, bands, venues,
+// shows and attendance records go through the repositories; the other features
+// still query the database directly. Handlers that only use the repositories
+// are tested on repository.NewMemoryRepositories without a database.
+type Handler struct {
+	repository.Repositories
+	DB *gorm.DB
+
+	Mailer     mailer.Mailer   // Sends password resets and email verifications
+	Geocoder   geo.Geocoder    // Finds the coordinates of venues and homes
+	RateLimits ratelimit.Store // Token buckets of RateLimit
+}
+
+// NewHandler returns a Handler backed by the database
+func NewHandler(db *gorm.DB, mail mailer.Mailer, geocoder geo.Geocoder, rateLimits ratelimit.Store) *Handler {
+	return &Handler{
+		Repositories: repository.NewGormRepositories(db),
+		DB:           db,
+		Mailer:       mail,
+		Geocoder:     geocoder,
+		RateLimits:   rateLimits,
+	}
+}
+
+// withDB returns a copy of the handler that goes through db, such as a transaction
+func (h *Handler) withDB(db *gorm.DB) *Handler {
+	tx := *h
+	tx.Repositories = repository.NewGormRepositories(db)
+	tx.DB = db
+	return &tx
+}
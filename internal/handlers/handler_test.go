@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"jam-tracker/internal/database"
+	"jam-tracker/internal/geo"
+	"jam-tracker/internal/mailer"
+	"jam-tracker/internal/models"
+	"jam-tracker/internal/ratelimit"
+	"jam-tracker/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const testPassword = "secret1"
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	os.Setenv("JWT_SECRET", "test-secret")
+	os.Exit(m.Run())
+}
+
+// testServer serves the handlers to a signed in editor. newTestServer serves the
+// repository backed handlers on in-memory repositories, newAPIServer every route
+// on a fresh SQLite database.
+type testServer struct {
+	t      *testing.T
+	router *gin.Engine
+	userID uuid.UUID
+	token  string // Access token sent with requests, empty on in-memory servers
+	db     *gorm.DB
+	mail   *testMailer
+}
+
+func newTestServer(t *testing.T) *testServer {
+	h := &Handler{Repositories: repository.NewMemoryRepositories(), Geocoder: geo.NewGazetteer()}
+	s := &testServer{t: t, router: gin.New(), userID: uuid.New()}
+
+	api := s.router.Group("/api/v1", func(c *gin.Context) {
+		c.Set("user_id", s.userID)
+		c.Set("role", models.RoleEditor)
+		c.Next()
+	})
+
+	api.POST("/bands", h.CreateBand)
+	api.GET("/bands", h.GetBands)
+	api.GET("/bands/:id", h.GetBand)
+	api.PUT("/bands/:id", h.UpdateBand)
+
+	api.POST("/venues", h.CreateVenue)
+	api.GET("/venues", h.GetVenues)
+	api.GET("/venues/:id", h.GetVenue)
+
+	api.POST("/shows", h.CreateShow)
+	api.GET("/shows", h.GetShows)
+	api.POST("/shows/:id/attend", h.AttendShow)
+	api.PUT("/attendances/:id", h.UpdateAttendance)
+
+	api.GET("/profile/upcoming", h.GetUpcomingShows)
+	api.GET("/profile/history", h.GetShowHistory)
+
+	return s
+}
+
+func newAPIServer(t *testing.T) *testServer {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")+"?_foreign_keys=on&_busy_timeout=5000"),
+		&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if _, err := database.MigrateUp(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	s := &testServer{t: t, router: gin.New(), db: db, mail: &testMailer{}}
+	if err := s.router.SetTrustedProxies(nil); err != nil {
+		t.Fatalf("trust no proxies: %v", err)
+	}
+	SetupRoutes(s.router, NewHandler(db, s.mail, geo.NewGazetteer(), ratelimit.NewMemoryStore()))
+
+	editor := s.register("editor@example.com", "editor")
+	s.setRole(editor.User.ID, models.RoleEditor)
+	s.userID, s.token = editor.User.ID, editor.Token
+	return s
+}
+
+// register signs up a user with testPassword and returns their tokens
+func (s *testServer) register(email, username string) AuthResponse {
+	s.t.Helper()
+	return decode[AuthResponse](s.t, s.do(http.MethodPost, "/api/v1/auth/register",
+		gin.H{"email": email, "username": username, "password": testPassword}, http.StatusCreated))
+}
+
+func (s *testServer) setRole(userID uuid.UUID, role models.Role) {
+	s.t.Helper()
+	if err := s.db.Model(&models.User{}).Where("id = ?", userID).Update("role", role).Error; err != nil {
+		s.t.Fatalf("set role: %v", err)
+	}
+}
+
+// as runs fn with requests sent by the user with the access token
+func (s *testServer) as(token string, fn func()) {
+	previous := s.token
+	s.token = token
+	defer func() { s.token = previous }()
+	fn()
+}
+
+// do sends body as JSON and fails the test unless the response has the wanted status
+func (s *testServer) do(method, path string, body any, wantStatus int) *httptest.ResponseRecorder {
+	s.t.Helper()
+
+	rec := s.send(method, path, body, nil)
+	if rec.Code != wantStatus {
+		s.t.Fatalf("%s %s: got status %d, want %d: %s", method, path, rec.Code, wantStatus, rec.Body.String())
+	}
+	return rec
+}
+
+// send sends body as JSON with extra headers and returns the response, whatever its status
+func (s *testServer) send(method, path string, body any, header http.Header) *httptest.ResponseRecorder {
+	s.t.Helper()
+
+	var raw []byte
+	if body != nil {
+		var err error
+		if raw, err = json.Marshal(body); err != nil {
+			s.t.Fatalf("encode request body: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, bytes.NewReader(raw))
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// decode reads a JSON response into a T
+func decode[T any](t *testing.T, rec *httptest.ResponseRecorder) T {
+	t.Helper()
+
+	var v T
+	if err := json.Unmarshal(rec.Body.Bytes(), &v); err != nil {
+		t.Fatalf("decode response %s: %v", rec.Body.String(), err)
+	}
+	return v
+}
+
+// errorMessage returns the "error" field of a response
+func errorMessage(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	return decode[map[string]any](t, rec)["error"].(string)
+}
+
+// testMailer keeps the messages it is asked to send
+type testMailer struct {
+	mu       sync.Mutex
+	messages []mailer.Message
+}
+
+func (m *testMailer) Send(ctx context.Context, msg mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// last returns the last message sent to an address
+func (m *testMailer) last(t *testing.T, to string) mailer.Message {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].To == to {
+			return m.messages[i]
+		}
+	}
+	t.Fatalf("no message sent to %s", to)
+	return mailer.Message{}
+}
@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"jam-tracker/internal/models"
+	"jam-tracker/internal/repository"
+	"jam-tracker/internal/setlistfm"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const maxSetlistFileSize = 5 << 20 // 5 MB
+
+type ImportSetlistResponse struct {
+	Show           models.Show `json:"show"`
+	ShowCreated    bool        `json:"show_created"`
+	SetlistCreated bool        `json:"setlist_created"`
+}
+
+// ImportSetlistFile imports a setlist from an uploaded setlist.fm API JSON file
+func (h *Handler) ImportSetlistFile(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSetlistFileSize+multipartOverhead)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		respondUploadError(c, err)
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSetlistFileSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+		return
+	}
+
+	setlist, err := setlistfm.Parse(data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.respondImport(c, setlist)
+}
+
+// ImportSetlistFromAPI fetches a setlist from setlist.fm by ID and imports it
+func (h *Handler) ImportSetlistFromAPI(c *gin.Context) {
+	setlistID := strings.TrimSpace(c.Param("setlist_id"))
+	if setlistID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Setlist ID is required"})
+		return
+	}
+
+	setlist, err := setlistfm.NewClientFromEnv().GetSetlist(c.Request.Context(), setlistID)
+	if errors.Is(err, setlistfm.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("Failed to fetch setlist: %v", err)})
+		return
+	}
+
+	h.respondImport(c, setlist)
+}
+
+func (h *Handler) respondImport(c *gin.Context, setlist *setlistfm.Setlist) {
+	location, err := importedLocation(setlist.Venue.City)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Geocode a new venue before the transaction, so a slow geocoder does not hold it open
+	h.locate(c.Request.Context(), &location, "")
+
+	var result ImportSetlistResponse
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		result, err = importSetlist(tx, setlist, location)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import setlist"})
+		return
+	}
+
+	status := http.StatusOK
+	if result.ShowCreated {
+		status = http.StatusCreated
+	}
+	c.JSON(status, result)
+}
+
+// importedLocation normalizes the city of a setlist.fm venue like the location of
+// a venue created through the API
+func importedLocation(city setlistfm.City) (models.Location, error) {
+	req := LocationRequest{City: city.Name, State: city.State, Country: city.Country.Name}
+	if strings.TrimSpace(req.State) == "" {
+		req.State = city.StateCode
+	}
+
+	// setlist.fm says "United States", venues have always said "usa"
+	if strings.EqualFold(city.Country.Code, "US") {
+		req.Country = "usa"
+	}
+
+	// setlist.fm knows where the city is, which beats guessing from its name
+	if city.Coords != (setlistfm.Coords{}) {
+		req.Latitude, req.Longitude = &city.Coords.Lat, &city.Coords.Long
+	}
+	return normalizeLocation(req)
+}
+
+// importSetlist creates or matches the band, venue, show and setlist rows for a
+// setlist.fm setlist. A new venue gets the location of importedLocation. Importing
+// the same setlist again finds the show by its setlist.fm ID and leaves an
+// existing setlist untouched.
+func importSetlist(tx *gorm.DB, setlist *setlistfm.Setlist, location models.Location) (ImportSetlistResponse, error) {
+	var result ImportSetlistResponse
+
+	showDate, err := setlist.Date()
+	if err != nil {
+		return result, err
+	}
+
+	// Find or create the band
+	var band models.Band
+	normalizedBandName := strings.ToLower(strings.TrimSpace(setlist.Artist.Name))
+	if err := tx.Where(models.Band{Name: normalizedBandName}).FirstOrCreate(&band).Error; err != nil {
+		return result, err
+	}
+
+	// Find or create the venue, matching the state by name or code
+	normalizedVenueName := strings.ToLower(strings.TrimSpace(setlist.Venue.Name))
+	normalizedStateCode := normalizePlaceName(setlist.Venue.City.StateCode)
+
+	var venue models.Venue
+	err = tx.Where("name = ? AND city = ? AND (state = ? OR state = ?)",
+		normalizedVenueName, location.City, location.State, normalizedStateCode).First(&venue).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		venue = models.Venue{Name: normalizedVenueName, Location: location, Timezone: guessTimezone(location)}
+		err = tx.Create(&venue).Error
+	}
+	if err != nil {
+		return result, err
+	}
+
+	// setlist.fm only gives the calendar day at the venue
+	localDate := showDate.Format("2006-01-02")
+
+	// Find the show by setlist.fm ID, then by band, venue and date. Setlists are
+	// the headliner's, so only shows the band headlines match.
+	var show models.Show
+	err = tx.Where("setlist_id = ?", setlist.ID).First(&show).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = tx.Where("band_id = ? AND venue_id = ? AND local_date = ?", band.ID, venue.ID, localDate).First(&show).Error
+	}
+
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		show = models.Show{
+			BandID:       band.ID,
+			VenueID:      venue.ID,
+			Date:         time.Date(showDate.Year(), showDate.Month(), showDate.Day(), 0, 0, 0, 0, venue.TimeZone()),
+			LocalDate:    localDate,
+			SetlistID:    setlist.ID,
+			Notes:        strings.TrimSpace(setlist.Info),
+			Performances: []models.ShowPerformance{{BandID: band.ID, Position: 1}},
+		}
+		if err := tx.Create(&show).Error; err != nil {
+			return result, err
+		}
+		result.ShowCreated = true
+	case err != nil:
+		return result, err
+	case show.SetlistID != setlist.ID:
+		show.SetlistID = setlist.ID
+		if err := tx.Save(&show).Error; err != nil {
+			return result, err
+		}
+	}
+
+	// Only record the setlist if the show does not have one yet
+	var setCount int64
+	if err := tx.Model(&models.Set{}).Where("show_id = ?", show.ID).Count(&setCount).Error; err != nil {
+		return result, err
+	}
+
+	if setCount == 0 {
+		created, err := createImportedSetlist(tx, show, band.ID, setlist.Sets.Set)
+		if err != nil {
+			return result, err
+		}
+		result.SetlistCreated = created
+	}
+
+	if err := repository.PreloadShow(tx, "").First(&show, "id = ?", show.ID).Error; err != nil {
+		return result, err
+	}
+
+	sets, err := loadSetlist(tx, show.ID)
+	if err != nil {
+		return result, err
+	}
+	show.Sets = sets
+	show.Setlist = renderSetlist(sets)
+
+	result.Show = show
+	return result, nil
+}
+
+// createImportedSetlist creates the sets and entries of an imported setlist played
+// by the band, skipping songs played from tape or without a name
+func createImportedSetlist(tx *gorm.DB, show models.Show, bandID uuid.UUID, importedSets []setlistfm.Set) (bool, error) {
+	created := false
+	setNumber := 0
+
+	for _, importedSet := range importedSets {
+		var songs []setlistfm.Song
+		for _, song := range importedSet.Song {
+			if !song.Tape && strings.TrimSpace(song.Name) != "" {
+				songs = append(songs, song)
+			}
+		}
+		if len(songs) == 0 {
+			continue
+		}
+
+		setNumber++
+		set := models.Set{
+			ShowID:   show.ID,
+			BandID:   bandID,
+			Name:     importedSetName(importedSet, setNumber),
+			Position: setNumber,
+		}
+		if err := tx.Create(&set).Error; err != nil {
+			return false, err
+		}
+
+		for i, importedSong := range songs {
+			song, err := findOrCreateSong(tx, bandID, importedSong.Name)
+			if err != nil {
+				return false, err
+			}
+
+			entry := models.SetlistEntry{
+				ShowID:     show.ID,
+				SetID:      set.ID,
+				SongID:     song.ID,
+				Position:   i + 1,
+				Transition: models.TransitionStop,
+				Teases:     []string{},
+				JamNotes:   strings.TrimSpace(importedSong.Info),
+			}
+			if err := tx.Create(&entry).Error; err != nil {
+				return false, err
+			}
+		}
+
+		created = true
+	}
+
+	return created, nil
+}
+
+// importedSetName uses the setlist.fm set name if there is one, otherwise "Set N" or "Encore"
+func importedSetName(set setlistfm.Set, setNumber int) string {
+	switch {
+	case strings.TrimSpace(set.Name) != "":
+		return strings.TrimSpace(set.Name)
+	case set.Encore == 1:
+		return "Encore"
+	case set.Encore > 1:
+		return fmt.Sprintf("Encore %d", set.Encore)
+	default:
+		return fmt.Sprintf("Set %d", setNumber)
+	}
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"jam-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// importSetlistFile uploads a setlist.fm setlist as a file and returns the import result
+func (s *testServer) importSetlistFile(setlist gin.H, wantStatus int) ImportSetlistResponse {
+	s.t.Helper()
+
+	data, err := json.Marshal(setlist)
+	if err != nil {
+		s.t.Fatalf("encode setlist: %v", err)
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	file, err := form.CreateFormFile("file", "setlist.json")
+	if err == nil {
+		_, err = file.Write(data)
+	}
+	if err == nil {
+		err = form.Close()
+	}
+	if err != nil {
+		s.t.Fatalf("build upload: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/import/setlistfm", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+s.token)
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	if rec.Code != wantStatus {
+		s.t.Fatalf("import setlist: got status %d, want %d: %s", rec.Code, wantStatus, rec.Body.String())
+	}
+	return decode[ImportSetlistResponse](s.t, rec)
+}
+
+func setlistFixture(id, date string, city gin.H) gin.H {
+	return gin.H{
+		"id":        id,
+		"eventDate": date,
+		"artist":    gin.H{"name": "Goose"},
+		"venue":     gin.H{"name": "Red Rocks Amphitheatre", "city": city},
+		"sets": gin.H{"set": []gin.H{
+			{"song": []gin.H{{"name": "Arcadia"}, {"name": "Intro", "tape": true}, {"name": "Hot Tea"}}},
+			{"encore": 1, "song": []gin.H{{"name": "Madhuvan"}}},
+		}},
+	}
+}
+
+func TestImportSetlistIsIdempotent(t *testing.T) {
+	s := newAPIServer(t)
+	s.do(http.MethodPost, "/api/v1/venues",
+		gin.H{"name": "Red Rocks Amphitheatre", "city": "Morrison", "state": "CO", "country": "usa"}, http.StatusCreated)
+
+	// setlist.fm spells the state and country out, the existing venue still matches
+	city := gin.H{"name": "Morrison", "state": "Colorado", "stateCode": "CO", "country": gin.H{"code": "US", "name": "United States"}}
+	first := s.importSetlistFile(setlistFixture("63a8e2b1", "10-09-2025", city), http.StatusCreated)
+	if !first.ShowCreated || !first.SetlistCreated {
+		t.Fatalf("first import = %+v, want the show and setlist created", first)
+	}
+	if first.Show.Venue.City != "morrison" || first.Show.LocalDate != "2025-09-10" {
+		t.Errorf("show = %+v, want the existing venue on 2025-09-10", first.Show)
+	}
+	if len(first.Show.Sets) != 2 || len(first.Show.Sets[0].Entries) != 2 {
+		t.Errorf("sets = %+v, want a set of two songs without the tape and an encore", first.Show.Sets)
+	}
+
+	again := s.importSetlistFile(setlistFixture("63a8e2b1", "10-09-2025", city), http.StatusOK)
+	if again.ShowCreated || again.SetlistCreated || again.Show.ID != first.Show.ID {
+		t.Errorf("second import = %+v, want the same show left as it was", again)
+	}
+
+	// A new venue is stored like one created through the API
+	city = gin.H{"name": "  New   York ", "state": "New York", "stateCode": "NY", "country": gin.H{"code": "US", "name": "United States"}}
+	other := s.importSetlistFile(setlistFixture("73a8e2b2", "12-09-2025", city), http.StatusCreated)
+	venue := other.Show.Venue
+	if venue.City != "new york" || venue.State != "new york" || venue.Country != "usa" || venue.Timezone != "America/New_York" {
+		t.Errorf("venue = %+v, want new york, usa in America/New_York", venue)
+	}
+	if venue.Latitude == nil || venue.Longitude == nil {
+		t.Errorf("venue = %+v, want it geocoded", venue)
+	}
+	s.importSetlistFile(setlistFixture("73a8e2b2", "12-09-2025", city), http.StatusOK)
+
+	for _, count := range []struct {
+		model any
+		want  int64
+	}{
+		{&models.Venue{}, 2},
+		{&models.Show{}, 2},
+		{&models.Set{}, 4},
+		{&models.SetlistEntry{}, 6},
+	} {
+		var got int64
+		if err := s.db.Model(count.model).Count(&got).Error; err != nil {
+			t.Fatalf("count: %v", err)
+		}
+		if got != count.want {
+			t.Errorf("%T count = %d after importing twice, want %d", count.model, got, count.want)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"jam-tracker/internal/geo"
+	"jam-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultRadiusKm = 50.0
+	maxRadiusKm     = 1000.0
+)
+
+// parseNear reads ?near=lat,lng and ?radius_km=. It returns nil when near is not
+// set. It writes the error response itself and returns false when the request
+// should stop.
+func parseNear(c *gin.Context) (*geo.Circle, bool) {
+	near := c.Query("near")
+	radiusParam := c.Query("radius_km")
+	if near == "" {
+		if radiusParam != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "radius_km requires near"})
+			return nil, false
+		}
+		return nil, true
+	}
+
+	center, err := geo.ParsePoint(near)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "near must be lat,lng, e.g. 39.7392,-104.9903"})
+		return nil, false
+	}
+
+	radius := defaultRadiusKm
+	if radiusParam != "" {
+		radius, err = strconv.ParseFloat(radiusParam, 64)
+		if err != nil || radius <= 0 || radius > maxRadiusKm {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("radius_km must be greater than 0 and at most %g", maxRadiusKm)})
+			return nil, false
+		}
+	}
+
+	return &geo.Circle{Center: center, RadiusKm: radius}, true
+}
+
+// LocationRequest is a place as clients send it. Venue requests embed it, users
+// send it as their home location.
+type LocationRequest struct {
+	City    string `json:"city" binding:"max=200"`
+	State   string `json:"state" binding:"max=100"`
+	Country string `json:"country" binding:"max=100"` // Defaults to usa
+	// Geocoded from the city when left out
+	Latitude  *float64 `json:"latitude" binding:"omitempty,min=-90,max=90"`
+	Longitude *float64 `json:"longitude" binding:"omitempty,min=-180,max=180"`
+}
+
+// normalizeLocation checks a location and stores its names lowercase with
+// single spaces. An empty request is an empty location.
+func normalizeLocation(req LocationRequest) (models.Location, error) {
+	location := models.Location{
+		City:      normalizePlaceName(req.City),
+		State:     normalizePlaceName(req.State),
+		Country:   normalizePlaceName(req.Country),
+		Latitude:  req.Latitude,
+		Longitude: req.Longitude,
+	}
+
+	if (location.Latitude == nil) != (location.Longitude == nil) {
+		return models.Location{}, errors.New("latitude and longitude must be given together")
+	}
+	if location == (models.Location{}) {
+		return location, nil
+	}
+	if location.City == "" {
+		return models.Location{}, errors.New("city is required for a location")
+	}
+
+	// Same default as venues have always had
+	if location.Country == "" {
+		location.Country = "usa"
+	}
+	return location, nil
+}
+
+func normalizePlaceName(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+// keepCoordinates carries the coordinates of a place over to its new location
+// when it did not move and none were given, so it is not geocoded again
+func keepCoordinates(next *models.Location, previous models.Location) {
+	if next.Latitude == nil && next.City == previous.City && next.State == previous.State && next.Country == previous.Country {
+		next.Latitude, next.Longitude = previous.Latitude, previous.Longitude
+	}
+}
+
+// locate geocodes a location that has no coordinates yet. A place the geocoder
+// does not know is kept without coordinates, it just will not show up in
+// searches by distance.
+func (h *Handler) locate(ctx context.Context, location *models.Location, street string) {
+	if _, ok := location.Point(); ok || location.City == "" {
+		return
+	}
+
+	point, err := h.Geocoder.Geocode(ctx, geo.Address{
+		Street:  street,
+		City:    location.City,
+		State:   location.State,
+		Country: location.Country,
+	})
+	if err != nil {
+		if !errors.Is(err, geo.ErrNotFound) {
+			log.Printf("Failed to geocode %s, %s: %v", location.City, location.State, err)
+		}
+		return
+	}
+	location.Latitude, location.Longitude = &point.Lat, &point.Lng
+}
+
+// guessTimezone returns the timezone of a location from its state or country,
+// UTC when they do not tell
+func guessTimezone(location models.Location) string {
+	timezone := geo.Timezone(geo.Address{City: location.City, State: location.State, Country: location.Country})
+	if timezone == "" {
+		return "UTC"
+	}
+	return timezone
+}
+
+// validateTimezone checks that a timezone is a known IANA name, e.g. America/Denver
+func validateTimezone(timezone string) error {
+	if _, err := time.LoadLocation(timezone); err != nil || timezone == "" || timezone == "Local" {
+		return fmt.Errorf("unknown timezone %q, expected an IANA name like America/Denver", timezone)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"jam-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNormalizeLocation(t *testing.T) {
+	lat, lng := 39.6654, -105.2057
+	tests := []struct {
+		name    string
+		req     LocationRequest
+		want    models.Location
+		wantErr bool
+	}{
+		{name: "empty", req: LocationRequest{}, want: models.Location{}},
+		{name: "blank", req: LocationRequest{City: "  ", State: " "}, want: models.Location{}},
+		{name: "names", req: LocationRequest{City: "  Morrison ", State: "CO", Country: "United  States"},
+			want: models.Location{City: "morrison", State: "co", Country: "united states"}},
+		{name: "default country", req: LocationRequest{City: "New   York City"},
+			want: models.Location{City: "new york city", Country: "usa"}},
+		{name: "coordinates", req: LocationRequest{City: "Morrison", Latitude: &lat, Longitude: &lng},
+			want: models.Location{City: "morrison", Country: "usa", Latitude: &lat, Longitude: &lng}},
+		{name: "latitude alone", req: LocationRequest{City: "Morrison", Latitude: &lat}, wantErr: true},
+		{name: "no city", req: LocationRequest{State: "CO"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := normalizeLocation(tt.req)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: location = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUpdateProfileHomeLocation(t *testing.T) {
+	s := newAPIServer(t)
+	lat, lng := 39.74, -104.99
+	profile := func(location gin.H) gin.H {
+		body := gin.H{"first_name": "Ed", "last_name": "Itor"}
+		if location != nil {
+			body["location"] = location
+		}
+		return body
+	}
+
+	user := decode[models.User](t, s.do(http.MethodPut, "/api/v1/profile",
+		profile(gin.H{"city": " Denver ", "state": "CO", "latitude": lat, "longitude": lng}), http.StatusOK))
+	if user.Location.City != "denver" || user.Location.Country != "usa" || user.Location.Latitude == nil || *user.Location.Latitude != lat {
+		t.Fatalf("location = %+v, want denver, co, usa at the given point", user.Location)
+	}
+
+	// Left out keeps the location, the same place keeps its coordinates
+	user = decode[models.User](t, s.do(http.MethodPut, "/api/v1/profile", profile(nil), http.StatusOK))
+	if user.Location.City != "denver" {
+		t.Errorf("location after leaving it out = %+v, want it kept", user.Location)
+	}
+	user = decode[models.User](t, s.do(http.MethodPut, "/api/v1/profile", profile(gin.H{"city": "DENVER", "state": "co"}), http.StatusOK))
+	if user.Location.Latitude == nil || *user.Location.Latitude != lat || *user.Location.Longitude != lng {
+		t.Errorf("location after saving the same place = %+v, want the coordinates kept", user.Location)
+	}
+
+	s.do(http.MethodPut, "/api/v1/profile", profile(gin.H{"state": "co"}), http.StatusBadRequest)
+
+	// An empty location clears it
+	user = decode[models.User](t, s.do(http.MethodPut, "/api/v1/profile", profile(gin.H{}), http.StatusOK))
+	if user.Location != (models.Location{}) {
+		t.Errorf("location after clearing it = %+v, want none", user.Location)
+	}
+}
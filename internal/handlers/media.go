@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"jam-tracker/internal/media"
+	"jam-tracker/internal/models"
+	"jam-tracker/internal/repository"
+	"jam-tracker/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Leave room for the other multipart fields on top of the file itself
+const multipartOverhead = 1 << 20
+
+type UploadMediaRequest struct {
+	Kind    string `form:"kind" binding:"omitempty,oneof=photo ticket_stub"` // Defaults to "photo"
+	Caption string `form:"caption" binding:"omitempty,max=500"`
+}
+
+// mediaListOptions are the sorts GetAttendanceMedia accepts
+var mediaListOptions = ListOptions[models.Media]{
+	Spec: repository.SortSpec[models.Media]{
+		Fields: map[string]repository.SortField[models.Media]{
+			"created_at": {Column: "media.created_at", Type: repository.SortTime, Value: func(m models.Media) any { return m.CreatedAt }},
+		},
+		IDColumn: "media.id",
+		ID:       func(m models.Media) uuid.UUID { return m.ID },
+	},
+	DefaultSort:  "created_at",
+	DefaultOrder: "asc",
+}
+
+// UploadMedia attaches a photo or ticket stub to one of the current user's attendances
+func (h *Handler) UploadMedia(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	attendanceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attendance ID"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, media.MaxUploadSize+multipartOverhead)
+
+	var req UploadMediaRequest
+	if err := c.ShouldBind(&req); err != nil {
+		respondUploadError(c, err)
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		respondUploadError(c, err)
+		return
+	}
+
+	if fileHeader.Size > media.MaxUploadSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": media.ErrTooLarge.Error()})
+		return
+	}
+
+	// Find the attendance record
+	var attendance models.ShowAttendance
+	if err := h.DB.Where("id = ?", attendanceID).First(&attendance).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attendance record not found"})
+		return
+	}
+
+	// Verify the attendance belongs to the authenticated user
+	if attendance.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only add media to your own attendance records"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, media.MaxUploadSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+		return
+	}
+
+	// Validate the file and build the thumbnail
+	img, err := media.Process(data)
+	switch {
+	case errors.Is(err, media.ErrTooLarge), errors.Is(err, media.ErrTooManyPixels):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, media.ErrUnsupportedType):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process image"})
+		return
+	}
+
+	kind := models.MediaKindPhoto
+	if req.Kind != "" {
+		kind = models.MediaKind(req.Kind)
+	}
+
+	item := models.Media{
+		ID:           uuid.New(),
+		AttendanceID: attendance.ID,
+		UserID:       userID,
+		Kind:         kind,
+		Caption:      strings.TrimSpace(req.Caption),
+		ContentType:  img.ContentType,
+		Size:         int64(len(data)),
+		Width:        img.Width,
+		Height:       img.Height,
+		TakenAt:      img.TakenAt,
+	}
+	item.StorageKey = fmt.Sprintf("attendances/%s/%s%s", attendance.ID, item.ID, img.Extension)
+	item.ThumbnailKey = fmt.Sprintf("attendances/%s/%s_thumb.jpg", attendance.ID, item.ID)
+
+	// Store the original and the thumbnail
+	ctx := c.Request.Context()
+	if err := storage.Media.Put(ctx, item.StorageKey, bytes.NewReader(data), item.Size, item.ContentType); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store file"})
+		return
+	}
+
+	if err := storage.Media.Put(ctx, item.ThumbnailKey, bytes.NewReader(img.Thumbnail), int64(len(img.Thumbnail)), media.ThumbnailMIMEType); err != nil {
+		storage.Media.Delete(ctx, item.StorageKey)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store thumbnail"})
+		return
+	}
+
+	if err := h.DB.Create(&item).Error; err != nil {
+		storage.Media.Delete(ctx, item.StorageKey)
+		storage.Media.Delete(ctx, item.ThumbnailKey)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save media"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, item)
+}
+
+// GetAttendanceMedia lists the media attached to one of the current user's attendances
+func (h *Handler) GetAttendanceMedia(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	attendanceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attendance ID"})
+		return
+	}
+
+	// Find the attendance record
+	var attendance models.ShowAttendance
+	if err := h.DB.Where("id = ?", attendanceID).First(&attendance).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attendance record not found"})
+		return
+	}
+
+	// Verify the attendance belongs to the authenticated user
+	if attendance.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only view media of your own attendance records"})
+		return
+	}
+
+	items, ok := paginateQuery(c, h.DB.Where("attendance_id = ?", attendance.ID), mediaListOptions)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// GetMediaFile streams the original uploaded file
+func (h *Handler) GetMediaFile(c *gin.Context) {
+	h.serveMedia(c, false)
+}
+
+// GetMediaThumbnail streams the generated thumbnail
+func (h *Handler) GetMediaThumbnail(c *gin.Context) {
+	h.serveMedia(c, true)
+}
+
+// DeleteMedia removes an uploaded file and its thumbnail
+func (h *Handler) DeleteMedia(c *gin.Context) {
+	item, ok := h.findOwnMedia(c)
+	if !ok {
+		return
+	}
+
+	if err := deleteMedia(c.Request.Context(), h.DB, "id = ?", item.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete media"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Media deleted successfully"})
+}
+
+func (h *Handler) serveMedia(c *gin.Context, thumbnail bool) {
+	item, ok := h.findOwnMedia(c)
+	if !ok {
+		return
+	}
+
+	key, contentType, size := item.StorageKey, item.ContentType, item.Size
+	if thumbnail {
+		key, contentType, size = item.ThumbnailKey, media.ThumbnailMIMEType, -1
+	}
+
+	reader, err := storage.Media.Get(c.Request.Context(), key)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Media file not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read media file"})
+		return
+	}
+	defer reader.Close()
+
+	c.DataFromReader(http.StatusOK, size, contentType, reader, nil)
+}
+
+// findOwnMedia loads the media from the :id param and checks it belongs to the current user.
+// It writes the error response itself and returns false when the request should stop.
+func (h *Handler) findOwnMedia(c *gin.Context) (models.Media, bool) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	mediaID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid media ID"})
+		return models.Media{}, false
+	}
+
+	var item models.Media
+	if err := h.DB.Where("id = ?", mediaID).First(&item).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Media not found"})
+		return models.Media{}, false
+	}
+
+	if item.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only access your own media"})
+		return models.Media{}, false
+	}
+
+	return item, true
+}
+
+func respondUploadError(c *gin.Context, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": media.ErrTooLarge.Error()})
+		return
+	}
+
+	if errors.Is(err, http.ErrMissingFile) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
+
+// deleteMedia removes the stored files and rows of all media matching the condition
+func deleteMedia(ctx context.Context, db *gorm.DB, query interface{}, args ...interface{}) error {
+	var items []models.Media
+	if err := db.Where(query, args...).Find(&items).Error; err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if err := storage.Media.Delete(ctx, item.StorageKey); err != nil {
+			return err
+		}
+		if err := storage.Media.Delete(ctx, item.ThumbnailKey); err != nil {
+			return err
+		}
+	}
+
+	return db.Where(query, args...).Delete(&models.Media{}).Error
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"jam-tracker/internal/models"
+	"jam-tracker/internal/totp"
+
+	"github.com/gin-gonic/gin"
+)
+
+// enableMFA turns on two-factor login for the current user and returns the secret
+// and recovery codes
+func (s *testServer) enableMFA() (string, []string) {
+	s.t.Helper()
+	enrolled := decode[struct {
+		Secret string `json:"secret"`
+	}](s.t, s.do(http.MethodPost, "/api/v1/profile/mfa/enroll", nil, http.StatusOK))
+
+	enabled := decode[struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}](s.t, s.do(http.MethodPost, "/api/v1/profile/mfa/enable",
+		gin.H{"code": totpCode(s.t, enrolled.Secret, totp.Step(time.Now()))}, http.StatusOK))
+	return enrolled.Secret, enabled.RecoveryCodes
+}
+
+// totpCode returns the code of a secret for a time step
+func totpCode(t *testing.T, secret string, step int64) string {
+	t.Helper()
+	code, err := totp.Code(secret, step)
+	if err != nil {
+		t.Fatalf("totp code: %v", err)
+	}
+	return code
+}
+
+func TestVerifyMFACodeRefusesReplays(t *testing.T) {
+	s := newAPIServer(t)
+	_, codes := s.enableMFA()
+
+	var user models.User
+	if err := s.db.First(&user, "id = ?", s.userID).Error; err != nil {
+		t.Fatalf("load user: %v", err)
+	}
+
+	// Enabling used up its step, the next one is still within the skew
+	step := user.TOTPLastStep
+	for _, tt := range []struct {
+		name string
+		code string
+		want bool
+	}{
+		{"code of the step used to enable", totpCode(t, user.TOTPSecret, step), false},
+		{"code of the next step", totpCode(t, user.TOTPSecret, step+1), true},
+		{"same code again", totpCode(t, user.TOTPSecret, step+1), false},
+		{"older code after a newer one", totpCode(t, user.TOTPSecret, step-1), false},
+		{"recovery code", codes[0], true},
+		{"same recovery code again", codes[0], false},
+		{"recovery code typed without dash in lower case", strings.ToLower(strings.ReplaceAll(codes[1], "-", "")), true},
+	} {
+		ok, err := verifyMFACode(s.db, user, tt.code)
+		if err != nil {
+			t.Fatalf("%s: verifyMFACode: %v", tt.name, err)
+		}
+		if ok != tt.want {
+			t.Errorf("%s: verifyMFACode = %v, want %v", tt.name, ok, tt.want)
+		}
+	}
+}
+
+func TestDisableMFALocksOutAfterWrongCodes(t *testing.T) {
+	s := newAPIServer(t)
+	secret, _ := s.enableMFA()
+	next := totp.Step(time.Now()) + 1
+
+	for i := 0; i < maxFailedLogins-1; i++ {
+		s.do(http.MethodPost, "/api/v1/profile/mfa/recovery-codes", gin.H{"code": "000000"}, http.StatusUnauthorized)
+	}
+	s.do(http.MethodPost, "/api/v1/profile/mfa/disable",
+		gin.H{"password": "wrong password", "code": "000000"}, http.StatusUnauthorized)
+
+	// Locked, so even the right password and code are refused
+	right := gin.H{"password": testPassword, "code": totpCode(t, secret, next)}
+	rec := s.do(http.MethodPost, "/api/v1/profile/mfa/disable", right, http.StatusTooManyRequests)
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("locked response has no Retry-After")
+	}
+	s.do(http.MethodPost, "/api/v1/profile/mfa/recovery-codes", gin.H{"code": totpCode(t, secret, next)}, http.StatusTooManyRequests)
+
+	if err := s.db.Model(&models.User{}).Where("id = ?", s.userID).
+		Update("locked_until", time.Now().Add(-time.Second)).Error; err != nil {
+		t.Fatalf("expire lockout: %v", err)
+	}
+	s.do(http.MethodPost, "/api/v1/profile/mfa/disable", right, http.StatusOK)
+
+	var user models.User
+	if err := s.db.First(&user, "id = ?", s.userID).Error; err != nil {
+		t.Fatalf("load user: %v", err)
+	}
+	if user.TOTPEnabledAt != nil || user.FailedLogins != 0 {
+		t.Errorf("totp_enabled_at = %v, failed_logins = %d, want MFA off and the count cleared", user.TOTPEnabledAt, user.FailedLogins)
+	}
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"jam-tracker/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 100
+
+	// Response headers carrying the pagination state
+	HeaderTotalCount = "X-Total-Count"
+	HeaderNextCursor = "X-Next-Cursor"
+)
+
+// ListOptions are the sorts a list endpoint accepts and its default order
+type ListOptions[T any] struct {
+	Spec         repository.SortSpec[T]
+	DefaultSort  string
+	DefaultOrder string // "asc" or "desc"
+}
+
+// pageCursor is the decoded form of the opaque ?cursor= value. It remembers the
+// sort it was created for so it cannot be replayed against a different order.
+type pageCursor struct {
+	Sort  string          `json:"s"`
+	Order string          `json:"o"`
+	Value json.RawMessage `json:"v"`
+	ID    uuid.UUID       `json:"id"`
+}
+
+// paginate applies ?limit=, ?sort=, ?order= and ?cursor=, loads one page with load
+// and sets the X-Total-Count and X-Next-Cursor headers. It writes the error
+// response itself and returns false when the request should stop.
+func paginate[T any](c *gin.Context, opts ListOptions[T], load func(repository.PageRequest) (repository.Page[T], error)) ([]T, bool) {
+	limit := defaultPageLimit
+	if limitParam := c.Query("limit"); limitParam != "" {
+		parsed, err := strconv.Atoi(limitParam)
+		if err != nil || parsed < 1 || parsed > maxPageLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxPageLimit)})
+			return nil, false
+		}
+		limit = parsed
+	}
+
+	sortKey := c.DefaultQuery("sort", opts.DefaultSort)
+	field, ok := opts.Spec.Fields[sortKey]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("sort must be one of %s", strings.Join(opts.Spec.Keys(), ", "))})
+		return nil, false
+	}
+
+	order := strings.ToLower(c.DefaultQuery("order", opts.DefaultOrder))
+	if order != "asc" && order != "desc" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "order must be asc or desc"})
+		return nil, false
+	}
+
+	req := repository.PageRequest{Limit: limit, Sort: sortKey, Desc: order == "desc"}
+
+	// Continue after the last row of the previous page
+	if cursorParam := c.Query("cursor"); cursorParam != "" {
+		cursor, err := decodePageCursor(cursorParam, sortKey, order, field.Type)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return nil, false
+		}
+		req.After = cursor
+	}
+
+	page, err := load(req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch results"})
+		return nil, false
+	}
+
+	c.Header(HeaderTotalCount, strconv.FormatInt(page.Total, 10))
+
+	if page.Next != nil {
+		cursor, err := encodePageCursor(sortKey, order, *page.Next)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build cursor"})
+			return nil, false
+		}
+		c.Header(HeaderNextCursor, cursor)
+	}
+
+	return page.Items, true
+}
+
+// paginateQuery is paginate for features that query the database directly
+func paginateQuery[T any](c *gin.Context, query *gorm.DB, opts ListOptions[T]) ([]T, bool) {
+	return paginate(c, opts, func(req repository.PageRequest) (repository.Page[T], error) {
+		return repository.Paginate(query, opts.Spec, req)
+	})
+}
+
+func encodePageCursor(sortKey, order string, cursor repository.Cursor) (string, error) {
+	rawValue, err := json.Marshal(cursor.Value)
+	if err != nil {
+		return "", err
+	}
+
+	raw, err := json.Marshal(pageCursor{Sort: sortKey, Order: order, Value: rawValue, ID: cursor.ID})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func decodePageCursor(cursor, sortKey, order string, valueType repository.SortType) (*repository.Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded pageCursor
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil, err
+	}
+
+	if decoded.Sort != sortKey || decoded.Order != order {
+		return nil, fmt.Errorf("cursor was created for sort %s %s", decoded.Sort, decoded.Order)
+	}
+
+	var value any
+	switch valueType {
+	case repository.SortTime:
+		var t time.Time
+		err = json.Unmarshal(decoded.Value, &t)
+		value = t
+	case repository.SortNumber:
+		var n float64
+		err = json.Unmarshal(decoded.Value, &n)
+		value = n
+	default:
+		var text string
+		err = json.Unmarshal(decoded.Value, &text)
+		value = text
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &repository.Cursor{Value: value, ID: decoded.ID}, nil
+}
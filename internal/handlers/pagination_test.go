@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"jam-tracker/internal/models"
+	"jam-tracker/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestPageCursorRoundTrip(t *testing.T) {
+	id := uuid.New()
+	tests := []struct {
+		name      string
+		valueType repository.SortType
+		value     any
+	}{
+		{"text", repository.SortText, "king gizzard"},
+		{"time", repository.SortTime, time.Date(2025, 9, 10, 19, 30, 0, 0, time.FixedZone("MDT", -6*60*60))},
+		{"number", repository.SortNumber, 4.5},
+	}
+	for _, tt := range tests {
+		encoded, err := encodePageCursor("name", "desc", repository.Cursor{Value: tt.value, ID: id})
+		if err != nil {
+			t.Fatalf("%s: encodePageCursor: %v", tt.name, err)
+		}
+
+		cursor, err := decodePageCursor(encoded, "name", "desc", tt.valueType)
+		if err != nil {
+			t.Fatalf("%s: decodePageCursor: %v", tt.name, err)
+		}
+		if cursor.ID != id {
+			t.Errorf("%s: ID = %s, want %s", tt.name, cursor.ID, id)
+		}
+		if want, ok := tt.value.(time.Time); ok {
+			if got, _ := cursor.Value.(time.Time); !got.Equal(want) {
+				t.Errorf("%s: value = %v, want %v", tt.name, cursor.Value, want)
+			}
+		} else if cursor.Value != tt.value {
+			t.Errorf("%s: value = %#v, want %#v", tt.name, cursor.Value, tt.value)
+		}
+
+		// A cursor only continues the sort it was made for
+		if _, err := decodePageCursor(encoded, "genre", "desc", tt.valueType); err == nil {
+			t.Errorf("%s: cursor accepted for another sort", tt.name)
+		}
+		if _, err := decodePageCursor(encoded, "name", "asc", tt.valueType); err == nil {
+			t.Errorf("%s: cursor accepted for another order", tt.name)
+		}
+	}
+
+	for _, cursor := range []string{"not base64!", "bm90IGpzb24", ""} {
+		if _, err := decodePageCursor(cursor, "name", "desc", repository.SortText); err == nil {
+			t.Errorf("decodePageCursor(%q) accepted an invalid cursor", cursor)
+		}
+	}
+
+	// The value must have the type of the sort field
+	encoded, err := encodePageCursor("name", "asc", repository.Cursor{Value: "yesterday", ID: id})
+	if err != nil {
+		t.Fatalf("encodePageCursor: %v", err)
+	}
+	if _, err := decodePageCursor(encoded, "name", "asc", repository.SortTime); err == nil {
+		t.Error("text cursor accepted for a time sort")
+	}
+}
+
+func TestPaginateChecksParameters(t *testing.T) {
+	s := newTestServer(t)
+	s.do(http.MethodPost, "/api/v1/bands", gin.H{"band_name": "goose"}, http.StatusCreated)
+
+	cursor := s.do(http.MethodGet, "/api/v1/bands?limit=1&sort=name", nil, http.StatusOK).Header().Get(HeaderNextCursor)
+	if cursor != "" {
+		t.Errorf("%s = %q with a single band, want none", HeaderNextCursor, cursor)
+	}
+
+	tests := []struct {
+		query     string
+		wantError string
+	}{
+		{"sort=password", "sort must be one of created_at, genre, name"},
+		{"sort=name%3Bdrop%20table%20bands", "sort must be one of"},
+		{"order=up", "order must be asc or desc"},
+		{"limit=0", "limit must be between 1 and 100"},
+		{"limit=101", "limit must be between 1 and 100"},
+		{"limit=ten", "limit must be between 1 and 100"},
+		{"cursor=garbage", "Invalid cursor"},
+	}
+	for _, tt := range tests {
+		body := s.do(http.MethodGet, "/api/v1/bands?"+tt.query, nil, http.StatusBadRequest).Body.String()
+		if !strings.Contains(body, tt.wantError) {
+			t.Errorf("?%s: error = %s, want %q", tt.query, body, tt.wantError)
+		}
+	}
+
+	for _, query := range []string{"", "sort=genre&order=DESC", "limit=100", "sort=created_at&order=asc"} {
+		s.do(http.MethodGet, "/api/v1/bands?"+query, nil, http.StatusOK)
+	}
+}
+
+func TestPaginateQueryByTime(t *testing.T) {
+	s := newAPIServer(t)
+	for _, name := range []string{"phish", "goose", "dead"} {
+		s.do(http.MethodPost, "/api/v1/bands", gin.H{"band_name": name}, http.StatusCreated)
+	}
+
+	// Goose and dead are created in the same instant and are paged through once each, by ID
+	created := time.Date(2025, 9, 10, 20, 0, 0, 0, time.UTC)
+	s.db.Model(&models.Band{}).Where("name = ?", "phish").Update("created_at", created.Add(-time.Hour))
+	s.db.Model(&models.Band{}).Where("name IN ?", []string{"goose", "dead"}).Update("created_at", created)
+	var tied []models.Band
+	s.db.Where("name IN ?", []string{"goose", "dead"}).Find(&tied)
+	sort.Slice(tied, func(i, j int) bool { return tied[i].ID.String() > tied[j].ID.String() })
+	want := tied[0].Name + "," + tied[1].Name + ",phish"
+
+	var names []string
+	cursor := ""
+	for page := 0; page < 4; page++ {
+		rec := s.do(http.MethodGet, "/api/v1/bands?sort=created_at&order=desc&limit=2&cursor="+cursor, nil, http.StatusOK)
+		for _, band := range decode[[]models.Band](t, rec) {
+			names = append(names, band.Name)
+		}
+		if cursor = rec.Header().Get(HeaderNextCursor); cursor == "" {
+			break
+		}
+	}
+	if got := strings.Join(names, ","); got != want {
+		t.Errorf("bands newest first = %s, want %s", got, want)
+	}
+}
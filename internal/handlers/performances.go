@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"jam-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// PerformanceRequest is one band on the bill of a show
+type PerformanceRequest struct {
+	BandName string `json:"band_name" binding:"required,min=1"`
+	SetStart string `json:"set_start"` // "21:00" on the day of the show, or a date and time like the show date
+	SetEnd   string `json:"set_end"`   // Before set_start for sets running past midnight
+}
+
+const invalidSetTimeMessage = "Invalid set time. Use HH:MM, YYYY-MM-DD HH:MM, or ISO format"
+
+// buildBill finds the bands on the bill of a show, in billing order. Without
+// performances the bill is the band alone or, when updating current, the band
+// followed by the openers of current, whose set times move along with the show.
+// It writes the error response itself and returns false when the bill is invalid.
+func (h *Handler) buildBill(c *gin.Context, bandName string, performances []PerformanceRequest,
+	venue models.Venue, showDate time.Time, current *models.Show) ([]models.ShowPerformance, bool) {
+	normalizedBandName := strings.ToLower(strings.TrimSpace(bandName))
+
+	if len(performances) == 0 {
+		if normalizedBandName == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "band_name or performances is required"})
+			return nil, false
+		}
+
+		headliner, err := h.Bands.FindByName(c.Request.Context(), normalizedBandName)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Band not found"})
+			return nil, false
+		}
+
+		bill := []models.ShowPerformance{{BandID: headliner.ID, Band: headliner}}
+		if current != nil {
+			shift := showDate.Sub(current.Date)
+			for _, performance := range current.Performances {
+				performance.SetStart = shiftTime(performance.SetStart, shift)
+				performance.SetEnd = shiftTime(performance.SetEnd, shift)
+
+				switch performance.BandID {
+				case headliner.ID:
+					bill[0].SetStart, bill[0].SetEnd = performance.SetStart, performance.SetEnd
+				case current.BandID:
+					// A replaced headliner leaves the bill
+				default:
+					bill = append(bill, performance)
+				}
+			}
+		}
+		return numberBill(bill), true
+	}
+
+	if normalizedBandName != "" && normalizedBandName != strings.ToLower(strings.TrimSpace(performances[0].BandName)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "band_name must be the first band of performances"})
+		return nil, false
+	}
+
+	localDate := venue.LocalDate(showDate)
+	bill := make([]models.ShowPerformance, 0, len(performances))
+	for _, req := range performances {
+		band, err := h.Bands.FindByName(c.Request.Context(), strings.ToLower(strings.TrimSpace(req.BandName)))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Band not found", "band_name": req.BandName})
+			return nil, false
+		}
+
+		if slices.ContainsFunc(bill, func(p models.ShowPerformance) bool { return p.BandID == band.ID }) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s is on the bill more than once", band.Name)})
+			return nil, false
+		}
+
+		setStart, setEnd, err := parseSetTimes(req, venue, localDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return nil, false
+		}
+
+		bill = append(bill, models.ShowPerformance{BandID: band.ID, Band: band, SetStart: setStart, SetEnd: setEnd})
+	}
+	return numberBill(bill), true
+}
+
+// numberBill sets the billing position of each performance from its place in the bill
+func numberBill(bill []models.ShowPerformance) []models.ShowPerformance {
+	for i := range bill {
+		bill[i].Position = i + 1
+	}
+	return bill
+}
+
+// checkBillConflicts writes a conflict and returns false when a band on the bill
+// already plays another show at the venue on the same day
+func (h *Handler) checkBillConflicts(c *gin.Context, bill []models.ShowPerformance, venueID uuid.UUID, localDate string, showID uuid.UUID) bool {
+	for _, performance := range bill {
+		existingShow, err := h.Shows.FindOnDate(c.Request.Context(), performance.BandID, venueID, localDate)
+		if err == nil && existingShow.ID != showID {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":     "Show already exists for this band, venue, and date",
+				"band_name": performance.Band.Name,
+			})
+			return false
+		}
+	}
+	return true
+}
+
+// parseSetTimes parses the start and end of a set. Times of day are on the day of
+// the show at the venue, and an end before the start is past midnight.
+func parseSetTimes(req PerformanceRequest, venue models.Venue, localDate string) (*time.Time, *time.Time, error) {
+	setStart, ok := parseSetTime(req.SetStart, venue, localDate)
+	if !ok {
+		return nil, nil, errors.New(invalidSetTimeMessage)
+	}
+	setEnd, ok := parseSetTime(req.SetEnd, venue, localDate)
+	if !ok {
+		return nil, nil, errors.New(invalidSetTimeMessage)
+	}
+
+	if setStart != nil && setEnd != nil && !setEnd.After(*setStart) {
+		if _, err := time.Parse("15:04", req.SetEnd); err != nil {
+			return nil, nil, errors.New("set_end must be after set_start")
+		}
+		nextDay := setEnd.AddDate(0, 0, 1)
+		setEnd = &nextDay
+	}
+	return setStart, setEnd, nil
+}
+
+// parseSetTime parses a set time as HH:MM on the day of the show at the venue or
+// like a show date. Empty values are nil.
+func parseSetTime(value string, venue models.Venue, localDate string) (*time.Time, bool) {
+	if value == "" {
+		return nil, true
+	}
+	if _, err := time.Parse("15:04", value); err == nil {
+		value = localDate + " " + value
+	}
+
+	setTime, ok := parseShowDate(value, venue)
+	if !ok {
+		return nil, false
+	}
+	return &setTime, true
+}
+
+// shiftTime moves an optional time by d
+func shiftTime(t *time.Time, d time.Duration) *time.Time {
+	if t == nil {
+		return nil
+	}
+	shifted := t.Add(d)
+	return &shifted
+}
+
+// caughtPerformances picks the performances of the named bands from the bill of
+// a show. Only shows the user attended can record the sets they caught.
+func caughtPerformances(show models.Show, status models.AttendanceStatus, bandNames []string) ([]models.ShowPerformance, error) {
+	caught := []models.ShowPerformance{}
+	if len(bandNames) == 0 {
+		return caught, nil
+	}
+
+	if status != models.AttendanceStatusAttended {
+		return nil, errors.New("Only attended shows can record the sets caught")
+	}
+
+	for _, bandName := range bandNames {
+		normalizedBandName := strings.ToLower(strings.TrimSpace(bandName))
+		i := slices.IndexFunc(show.Performances, func(p models.ShowPerformance) bool { return p.Band.Name == normalizedBandName })
+		if i < 0 {
+			return nil, fmt.Errorf("%s is not on the bill of this show", normalizedBandName)
+		}
+
+		performance := show.Performances[i]
+		if !slices.ContainsFunc(caught, func(p models.ShowPerformance) bool { return p.ID == performance.ID }) {
+			caught = append(caught, performance)
+		}
+	}
+	return caught, nil
+}
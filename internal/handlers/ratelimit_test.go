@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"jam-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimitIgnoresForwardedFor(t *testing.T) {
+	s := newAPIServer(t)
+	s.token = ""
+
+	// Registering the editor took one of the 20 auth requests a minute
+	login := gin.H{"email": "nobody@example.com", "password": testPassword}
+	for i := 1; i < 20; i++ {
+		header := http.Header{"X-Forwarded-For": {fmt.Sprintf("203.0.113.%d", i)}}
+		if rec := s.send(http.MethodPost, "/api/v1/auth/login", login, header); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("login %d: got status %d, want 401", i, rec.Code)
+		}
+	}
+
+	header := http.Header{"X-Forwarded-For": {"198.51.100.1"}}
+	rec := s.send(http.MethodPost, "/api/v1/auth/login", login, header)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("login from a new forwarded address: got status %d, want 429", rec.Code)
+	}
+	if retryAfter, _ := strconv.Atoi(rec.Header().Get("Retry-After")); retryAfter < 1 || retryAfter > 3 {
+		t.Errorf("Retry-After = %q, want the 3 seconds until the next token", rec.Header().Get("Retry-After"))
+	}
+}
+
+func TestRecordFailedLoginBacksOff(t *testing.T) {
+	s := newAPIServer(t)
+	user := s.register("fan@example.com", "fan").User
+
+	tests := []struct {
+		failures int
+		lockout  time.Duration // Zero while unlocked
+	}{
+		{1, 0},
+		{4, 0},
+		{5, 30 * time.Second},
+		{6, time.Minute},
+		{7, 2 * time.Minute},
+		{11, 32 * time.Minute},
+		{12, time.Hour},
+		{40, time.Hour},
+	}
+
+	failures := 0
+	for _, tt := range tests {
+		for ; failures < tt.failures; failures++ {
+			if err := recordFailedLogin(s.db, user.ID); err != nil {
+				t.Fatalf("recordFailedLogin: %v", err)
+			}
+		}
+
+		var got models.User
+		if err := s.db.First(&got, "id = ?", user.ID).Error; err != nil {
+			t.Fatalf("load user: %v", err)
+		}
+		if got.FailedLogins != tt.failures {
+			t.Errorf("after %d failures: failed_logins = %d", tt.failures, got.FailedLogins)
+		}
+
+		switch {
+		case tt.lockout == 0 && got.LockedUntil != nil:
+			t.Errorf("after %d failures: locked until %v, want unlocked", tt.failures, got.LockedUntil)
+		case tt.lockout == 0:
+		case got.LockedUntil == nil:
+			t.Errorf("after %d failures: unlocked, want locked for %v", tt.failures, tt.lockout)
+		default:
+			if remaining := time.Until(*got.LockedUntil); remaining > tt.lockout || remaining < tt.lockout-5*time.Second {
+				t.Errorf("after %d failures: locked for %v, want %v", tt.failures, remaining.Round(time.Second), tt.lockout)
+			}
+		}
+	}
+}
+
+func TestLoginLocksOutAfterFailures(t *testing.T) {
+	s := newAPIServer(t)
+	user := s.register("fan@example.com", "fan").User
+	s.token = ""
+
+	wrong := gin.H{"email": "fan@example.com", "password": "wrong password"}
+	for i := 0; i < maxFailedLogins; i++ {
+		s.do(http.MethodPost, "/api/v1/auth/login", wrong, http.StatusUnauthorized)
+	}
+
+	// Even the right password is refused while locked
+	right := gin.H{"email": "fan@example.com", "password": testPassword}
+	rec := s.do(http.MethodPost, "/api/v1/auth/login", right, http.StatusTooManyRequests)
+	if retryAfter, _ := strconv.Atoi(rec.Header().Get("Retry-After")); retryAfter < 25 || retryAfter > 30 {
+		t.Errorf("Retry-After = %q, want about 30 seconds", rec.Header().Get("Retry-After"))
+	}
+
+	// Once the lockout ran out the right password works and clears the count
+	if err := s.db.Model(&models.User{}).Where("id = ?", user.ID).
+		Update("locked_until", time.Now().Add(-time.Second)).Error; err != nil {
+		t.Fatalf("expire lockout: %v", err)
+	}
+	s.do(http.MethodPost, "/api/v1/auth/login", right, http.StatusOK)
+
+	var got models.User
+	if err := s.db.First(&got, "id = ?", user.ID).Error; err != nil {
+		t.Fatalf("load user: %v", err)
+	}
+	if got.FailedLogins != 0 || got.LockedUntil != nil {
+		t.Errorf("failed_logins = %d, locked_until = %v after logging in, want both cleared", got.FailedLogins, got.LockedUntil)
+	}
+}
@@ -0,0 +1,481 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"jam-tracker/internal/database"
+	"jam-tracker/internal/geo"
+	"jam-tracker/internal/models"
+	"jam-tracker/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultRecommendationLimit = 20
+	maxRecommendationLimit     = 100
+	neutralRating              = 3.0 // Midpoint of the 1-5 scale
+
+	recommendationWindow        = 365 * 24 * time.Hour // How far ahead shows are recommended
+	maxRecommendationCandidates = 1000                 // Soonest upcoming shows scored per request
+	maxRecommendationRatings    = 10000                // Most recent band ratings of other users used per request
+)
+
+// Recommendation strategies selectable with ?strategy=
+const (
+	StrategyCollaborative = "collab"
+	StrategyContent       = "content"
+	StrategyHybrid        = "hybrid"
+)
+
+// Weights used to blend signals into a single 0-1 score
+const (
+	hybridCollaborativeWeight = 0.6
+	hybridContentWeight       = 0.4
+
+	contentGenreWeight    = 0.5
+	contentVenueWeight    = 0.3
+	contentLocationWeight = 0.2
+)
+
+type RecommendationResponse struct {
+	Show        models.Show `json:"show"`
+	Score       float64     `json:"score"`       // Relevance from 0 to 1
+	Explanation string      `json:"explanation"` // e.g. "Because you saw goose"
+}
+
+// userBandRating is a user's average rating across all shows of a band
+type userBandRating struct {
+	UserID uuid.UUID
+	BandID uuid.UUID
+	Rating float64
+}
+
+// bandScore is a predicted preference for a band and the reason behind it
+type bandScore struct {
+	Score       float64
+	Explanation string
+}
+
+// contentProfile captures what a user likes about the shows they rated
+type contentProfile struct {
+	GenreRatings map[string]float64    // Average rating per band genre
+	VenueRatings map[uuid.UUID]float64 // Average rating per venue
+	VenueNames   map[uuid.UUID]string
+	Home         models.Location // Where the user lives
+}
+
+// GetRecommendations ranks upcoming shows for the current user
+func (h *Handler) GetRecommendations(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	limit := defaultRecommendationLimit
+	if limitParam := c.Query("limit"); limitParam != "" {
+		parsed, err := strconv.Atoi(limitParam)
+		if err != nil || parsed < 1 || parsed > maxRecommendationLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxRecommendationLimit)})
+			return
+		}
+		limit = parsed
+	}
+
+	strategy := c.DefaultQuery("strategy", StrategyHybrid)
+	if strategy != StrategyCollaborative && strategy != StrategyContent && strategy != StrategyHybrid {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "strategy must be one of collab, content or hybrid"})
+		return
+	}
+
+	// Find the current user
+	var user models.User
+	if err := h.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	// The soonest upcoming shows the user has not already marked attendance for
+	dialect := database.DialectOf(h.DB)
+	now := time.Now()
+	var shows []models.Show
+	if err := repository.PreloadShow(h.DB, "").
+		Where(dialect.Time("date")+" >= "+dialect.Time("?"), now).
+		Where(dialect.Time("date")+" < "+dialect.Time("?"), now.Add(recommendationWindow)).
+		Where("id NOT IN (?)", h.DB.Model(&models.ShowAttendance{}).Select("show_id").Where("user_id = ?", userID)).
+		Order("date").Limit(maxRecommendationCandidates).
+		Find(&shows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load upcoming shows"})
+		return
+	}
+
+	var collabScores map[uuid.UUID]bandScore
+	if strategy != StrategyContent {
+		var candidateIDs []uuid.UUID
+		seen := make(map[uuid.UUID]bool)
+		for _, show := range shows {
+			for _, band := range billBands(show) {
+				if !seen[band.ID] {
+					seen[band.ID] = true
+					candidateIDs = append(candidateIDs, band.ID)
+				}
+			}
+		}
+
+		scores, err := loadCollaborativeScores(h.DB, userID, candidateIDs)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load ratings"})
+			return
+		}
+		collabScores = scores
+	}
+
+	var profile *contentProfile
+	if strategy != StrategyCollaborative {
+		p, err := loadContentProfile(h.DB, user)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load user preferences"})
+			return
+		}
+		profile = p
+	}
+
+	recommendations := []RecommendationResponse{}
+	for _, show := range shows {
+		var collab, content bandScore
+		if collabScores != nil {
+			collab = collaborativeShowScore(collabScores, show)
+		}
+		if profile != nil {
+			content = contentShowScore(profile, show)
+		}
+
+		score := blendScores(strategy, collab, content)
+		if score.Score <= 0 {
+			continue
+		}
+
+		recommendations = append(recommendations, RecommendationResponse{
+			Show:        show,
+			Score:       math.Round(score.Score*100) / 100,
+			Explanation: score.Explanation,
+		})
+	}
+
+	// Best score first, soonest show breaks ties
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		if recommendations[i].Score != recommendations[j].Score {
+			return recommendations[i].Score > recommendations[j].Score
+		}
+		return recommendations[i].Show.Date.Before(recommendations[j].Show.Date)
+	})
+
+	if len(recommendations) > limit {
+		recommendations = recommendations[:limit]
+	}
+
+	c.JSON(http.StatusOK, recommendations)
+}
+
+// blendScores combines the collaborative and content scores for the chosen strategy.
+// In hybrid mode a missing signal does not drag the other one down, so new users
+// still get content-based results.
+func blendScores(strategy string, collab, content bandScore) bandScore {
+	switch strategy {
+	case StrategyCollaborative:
+		return collab
+	case StrategyContent:
+		return content
+	}
+
+	if collab.Score == 0 {
+		return content
+	}
+	if content.Score == 0 {
+		return collab
+	}
+
+	blended := bandScore{
+		Score:       hybridCollaborativeWeight*collab.Score + hybridContentWeight*content.Score,
+		Explanation: collab.Explanation,
+	}
+	if hybridContentWeight*content.Score > hybridCollaborativeWeight*collab.Score {
+		blended.Explanation = content.Explanation
+	}
+	return blended
+}
+
+// loadCollaborativeScores scores the candidate bands for the user. Only the ratings
+// that can count are loaded: those of the user, and those other users gave the
+// bands the user rated or the candidates, if they rated a band the user rated too.
+func loadCollaborativeScores(db *gorm.DB, userID uuid.UUID, candidateIDs []uuid.UUID) (map[uuid.UUID]bandScore, error) {
+	var ratings []userBandRating
+	if err := bandRatings(db).Where("show_attendances.user_id = ?", userID).Scan(&ratings).Error; err != nil {
+		return nil, err
+	}
+	if len(ratings) == 0 || len(candidateIDs) == 0 {
+		return map[uuid.UUID]bandScore{}, nil
+	}
+
+	ratedIDs := make([]uuid.UUID, len(ratings))
+	for i, r := range ratings {
+		ratedIDs[i] = r.BandID
+	}
+
+	// The most recent ratings win when there are more than the cap
+	var others []userBandRating
+	coRaters := bandRatings(db).Select("show_attendances.user_id").Where("show_performances.band_id IN ?", ratedIDs)
+	if err := bandRatings(db).
+		Joins("JOIN shows ON shows.id = show_attendances.show_id").
+		Where("show_attendances.user_id <> ? AND show_attendances.user_id IN (?)", userID, coRaters).
+		Where("show_performances.band_id IN ?", append(ratedIDs, candidateIDs...)).
+		Order("MAX(shows.date) DESC").Limit(maxRecommendationRatings).
+		Scan(&others).Error; err != nil {
+		return nil, err
+	}
+	ratings = append(ratings, others...)
+
+	// Names of the rated bands are needed for the explanations
+	var bands []models.Band
+	if err := db.Where("id IN ?", ratedIDs).Find(&bands).Error; err != nil {
+		return nil, err
+	}
+	bandNames := make(map[uuid.UUID]string, len(bands))
+	for _, band := range bands {
+		bandNames[band.ID] = band.Name
+	}
+
+	return collaborativeBandScores(ratings, userID, bandNames), nil
+}
+
+// bandRatings averages each user's attended show ratings per band on the bill
+func bandRatings(db *gorm.DB) *gorm.DB {
+	return db.Model(&models.ShowAttendance{}).
+		Select("show_attendances.user_id, show_performances.band_id, AVG(show_attendances.rating) AS rating").
+		Joins("JOIN show_performances ON show_performances.show_id = show_attendances.show_id").
+		Where("show_attendances.rating IS NOT NULL AND show_attendances.status = ?", models.AttendanceStatusAttended).
+		Group("show_attendances.user_id, show_performances.band_id")
+}
+
+// collaborativeShowScore is the best score of the bands on the bill of a show
+func collaborativeShowScore(scores map[uuid.UUID]bandScore, show models.Show) bandScore {
+	var best bandScore
+	for _, band := range billBands(show) {
+		if score := scores[band.ID]; score.Score > best.Score {
+			best = score
+		}
+	}
+	return best
+}
+
+// billBands returns the bands on the bill of a show, or the headliner alone when
+// the bill was not loaded
+func billBands(show models.Show) []models.Band {
+	if len(show.Performances) == 0 {
+		return []models.Band{show.Band}
+	}
+	bands := make([]models.Band, len(show.Performances))
+	for i, performance := range show.Performances {
+		bands[i] = performance.Band
+	}
+	return bands
+}
+
+// collaborativeBandScores predicts the user's preference for bands they have not rated yet
+// using item-based collaborative filtering: bands are similar when the same users
+// rated both of them, and a band's score is the user's own ratings weighted by how
+// similar each rated band is to it.
+func collaborativeBandScores(ratings []userBandRating, userID uuid.UUID, bandNames map[uuid.UUID]string) map[uuid.UUID]bandScore {
+	// Index ratings by band and by user
+	byBand := make(map[uuid.UUID]map[uuid.UUID]float64)
+	for _, r := range ratings {
+		if byBand[r.BandID] == nil {
+			byBand[r.BandID] = make(map[uuid.UUID]float64)
+		}
+		byBand[r.BandID][r.UserID] = r.Rating
+	}
+
+	userRatings := make(map[uuid.UUID]float64)
+	for bandID, users := range byBand {
+		if rating, ok := users[userID]; ok {
+			userRatings[bandID] = rating
+		}
+	}
+
+	scores := make(map[uuid.UUID]bandScore)
+	for candidateID, candidateUsers := range byBand {
+		if _, rated := userRatings[candidateID]; rated {
+			continue
+		}
+
+		var weighted, similaritySum, bestContribution float64
+		var bestBandID uuid.UUID
+		for ratedID, rating := range userRatings {
+			similarity := cosineSimilarity(byBand[ratedID], candidateUsers)
+			if similarity <= 0 {
+				continue
+			}
+
+			weighted += similarity * rating
+			similaritySum += similarity
+
+			// The rated band that contributes the most becomes the explanation
+			if contribution := similarity * rating; contribution > bestContribution {
+				bestContribution = contribution
+				bestBandID = ratedID
+			}
+		}
+
+		if similaritySum == 0 {
+			continue
+		}
+
+		scores[candidateID] = bandScore{
+			Score:       normalizeRating(weighted / similaritySum),
+			Explanation: fmt.Sprintf("Because you saw %s", bandNames[bestBandID]),
+		}
+	}
+
+	return scores
+}
+
+// cosineSimilarity compares two bands by the ratings of the users who rated both.
+// Ratings are centered on the neutral rating so that loving one band and disliking
+// the other counts against similarity.
+func cosineSimilarity(a, b map[uuid.UUID]float64) float64 {
+	var dot, normA, normB float64
+	for userID, ratingA := range a {
+		ratingB, ok := b[userID]
+		if !ok {
+			continue
+		}
+		ratingA -= neutralRating
+		ratingB -= neutralRating
+		dot += ratingA * ratingB
+		normA += ratingA * ratingA
+		normB += ratingB * ratingB
+	}
+
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// loadContentProfile builds the user's genre and venue preferences from their own ratings
+func loadContentProfile(db *gorm.DB, user models.User) (*contentProfile, error) {
+	var attendances []models.ShowAttendance
+	if err := repository.PreloadShow(db, "Show.").
+		Where("user_id = ? AND rating IS NOT NULL AND status = ?", user.ID, models.AttendanceStatusAttended).
+		Find(&attendances).Error; err != nil {
+		return nil, err
+	}
+
+	genreTotals := make(map[string][]float64)
+	venueTotals := make(map[uuid.UUID][]float64)
+	profile := &contentProfile{
+		GenreRatings: make(map[string]float64),
+		VenueRatings: make(map[uuid.UUID]float64),
+		VenueNames:   make(map[uuid.UUID]string),
+	}
+
+	for _, attendance := range attendances {
+		for _, genre := range billGenres(attendance.Show) {
+			genreTotals[genre] = append(genreTotals[genre], *attendance.Rating)
+		}
+		venueTotals[attendance.Show.VenueID] = append(venueTotals[attendance.Show.VenueID], *attendance.Rating)
+		profile.VenueNames[attendance.Show.VenueID] = attendance.Show.Venue.Name
+	}
+
+	for genre, ratings := range genreTotals {
+		profile.GenreRatings[genre] = average(ratings)
+	}
+	for venueID, ratings := range venueTotals {
+		profile.VenueRatings[venueID] = average(ratings)
+	}
+
+	profile.Home = user.Location
+
+	return profile, nil
+}
+
+// contentShowScore scores a show by how well its genre, venue and location match the profile
+func contentShowScore(profile *contentProfile, show models.Show) bandScore {
+	var score, bestContribution float64
+	var explanation string
+
+	consider := func(contribution float64, reason string) {
+		score += contribution
+		if contribution > bestContribution {
+			bestContribution = contribution
+			explanation = reason
+		}
+	}
+
+	// The best liked genre on the bill counts
+	var genreRating float64
+	var likedGenre string
+	for _, genre := range billGenres(show) {
+		if rating, ok := profile.GenreRatings[genre]; ok && (likedGenre == "" || rating > genreRating) {
+			genreRating, likedGenre = rating, genre
+		}
+	}
+	if likedGenre != "" {
+		consider(contentGenreWeight*normalizeRating(genreRating), fmt.Sprintf("Because you like %s", likedGenre))
+	}
+
+	if rating, ok := profile.VenueRatings[show.VenueID]; ok {
+		consider(contentVenueWeight*normalizeRating(rating), fmt.Sprintf("Because you enjoyed shows at %s", profile.VenueNames[show.VenueID]))
+	}
+
+	// Prefer the distance, fall back to the names when coordinates are missing
+	home, homeKnown := profile.Home.Point()
+	venue, venueKnown := show.Venue.Point()
+	switch {
+	case homeKnown && venueKnown && geo.Distance(home, venue) <= defaultRadiusKm:
+		consider(contentLocationWeight, fmt.Sprintf("Because it is near %s", profile.Home.City))
+	case profile.Home.City != "" && show.Venue.City == profile.Home.City:
+		consider(contentLocationWeight, fmt.Sprintf("Because it is in %s", show.Venue.City))
+	case profile.Home.State != "" && show.Venue.State == profile.Home.State:
+		consider(contentLocationWeight/2, fmt.Sprintf("Because it is in %s", show.Venue.State))
+	}
+
+	return bandScore{Score: score, Explanation: explanation}
+}
+
+// normalizeRating maps a 1-5 rating onto 0-1
+func normalizeRating(rating float64) float64 {
+	return math.Max(0, math.Min(1, (rating-1)/4))
+}
+
+// billGenres returns the distinct genres of the bands on the bill of a show
+func billGenres(show models.Show) []string {
+	var genres []string
+	for _, band := range billBands(show) {
+		if genre := normalizeGenre(band.Genre); genre != "" && !slices.Contains(genres, genre) {
+			genres = append(genres, genre)
+		}
+	}
+	return genres
+}
+
+func normalizeGenre(genre string) string {
+	return strings.ToLower(strings.TrimSpace(genre))
+}
+
+func average(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"jam-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestRecommendationsScoreTheWholeBill(t *testing.T) {
+	headliner := models.Band{ID: uuid.New(), Name: "ween", Genre: "Rock"}
+	opener := models.Band{ID: uuid.New(), Name: "dogs in a pile", Genre: "Jam"}
+	show := models.Show{BandID: headliner.ID, Band: headliner, Performances: []models.ShowPerformance{
+		{BandID: headliner.ID, Band: headliner, Position: 1},
+		{BandID: opener.ID, Band: opener, Position: 2},
+	}}
+
+	scores := map[uuid.UUID]bandScore{opener.ID: {Score: 0.8, Explanation: "Because you saw goose"}}
+	if got := collaborativeShowScore(scores, show); got != scores[opener.ID] {
+		t.Errorf("collaborative score = %+v, want the opener's", got)
+	}
+
+	profile := &contentProfile{GenreRatings: map[string]float64{"rock": 2, "jam": 5}}
+	if got := contentShowScore(profile, show); got.Score != contentGenreWeight || got.Explanation != "Because you like jam" {
+		t.Errorf("content score = %+v, want the best liked genre on the bill", got)
+	}
+
+	// Without the bill the headliner stands alone
+	show.Performances = nil
+	if got := collaborativeShowScore(scores, show); got.Score != 0 {
+		t.Errorf("collaborative score without a bill = %+v, want none", got)
+	}
+}
+
+func TestGetRecommendationsFromOtherFans(t *testing.T) {
+	s := newAPIServer(t)
+	s.do(http.MethodPost, "/api/v1/venues", gin.H{"name": "Red Rocks", "city": "Morrison", "state": "CO", "country": "USA"}, http.StatusCreated)
+	for _, name := range []string{"goose", "phish", "ween", "dogs in a pile"} {
+		s.do(http.MethodPost, "/api/v1/bands", gin.H{"band_name": name}, http.StatusCreated)
+	}
+	createShow := func(date time.Time, bands ...string) models.Show {
+		bill := make([]gin.H, len(bands))
+		for i, band := range bands {
+			bill[i] = gin.H{"band_name": band}
+		}
+		return decode[models.Show](t, s.do(http.MethodPost, "/api/v1/shows",
+			gin.H{"venue_name": "red rocks", "date": date.Format("2006-01-02"), "performances": bill}, http.StatusCreated))
+	}
+	rate := func(show models.Show, rating float64) {
+		s.do(http.MethodPost, "/api/v1/shows/"+show.ID.String()+"/attend", gin.H{"status": "attended", "rating": rating}, http.StatusCreated)
+	}
+
+	now := time.Now()
+	goose := createShow(now.AddDate(0, -2, 0), "goose")
+	phish := createShow(now.AddDate(0, -1, 0), "phish", "dogs in a pile")
+	opened := createShow(now.AddDate(0, 1, 0), "ween", "dogs in a pile")
+	createShow(now.AddDate(0, 2, 0), "ween")
+	createShow(now.AddDate(2, 0, 0), "phish")
+
+	// The editor and a fan loved goose, the fan loved phish and its opener too
+	rate(goose, 5)
+	fan := s.register("fan@example.com", "fan")
+	s.as(fan.Token, func() {
+		rate(goose, 5)
+		rate(phish, 5)
+	})
+
+	recommendations := decode[[]RecommendationResponse](t, s.do(http.MethodGet, "/api/v1/recommendations?strategy=collab", nil, http.StatusOK))
+	if len(recommendations) != 1 || recommendations[0].Show.ID != opened.ID {
+		t.Fatalf("recommendations = %+v, want only the show dogs in a pile open, phish is too far ahead", recommendations)
+	}
+	if recommendations[0].Score != 1 || recommendations[0].Explanation != "Because you saw goose" {
+		t.Errorf("recommendation = %+v, want a full score because of goose", recommendations[0])
+	}
+
+	// Without ratings of their own nobody is similar to a new user
+	newcomer := s.register("new@example.com", "newcomer")
+	s.as(newcomer.Token, func() {
+		if recommendations := decode[[]RecommendationResponse](t, s.do(http.MethodGet, "/api/v1/recommendations?strategy=collab", nil, http.StatusOK)); len(recommendations) != 0 {
+			t.Errorf("recommendations for a new user = %+v, want none", recommendations)
+		}
+	})
+}
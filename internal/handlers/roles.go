@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"jam-tracker/internal/models"
+	"jam-tracker/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type UpdateRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// RequireRole only lets through users whose role includes the given one.
+// It must run after AuthMiddleware.
+func (h *Handler) RequireRole(role models.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uuid.UUID)
+
+		// Load the role on every request so revoking it takes effect immediately
+		user, err := h.Users.FindByID(c.Request.Context(), userID)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			c.Abort()
+			return
+		}
+
+		if !user.Role.Includes(role) {
+			c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("This action requires the %s role", role)})
+			c.Abort()
+			return
+		}
+
+		c.Set("user_role", user.Role)
+		c.Next()
+	}
+}
+
+// GrantRole sets the role of a user
+func (h *Handler) GrantRole(c *gin.Context) {
+	var req UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role := models.Role(req.Role)
+	if !role.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role must be one of user, editor or admin"})
+		return
+	}
+
+	h.setRole(c, role)
+}
+
+// RevokeRole takes a user back to the user role
+func (h *Handler) RevokeRole(c *gin.Context) {
+	h.setRole(c, models.RoleUser)
+}
+
+func (h *Handler) setRole(c *gin.Context, role models.Role) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	user, err := h.Users.FindByID(c.Request.Context(), userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load user"})
+		return
+	}
+
+	// Keep at least one admin around to grant roles
+	if user.Role == models.RoleAdmin && role != models.RoleAdmin {
+		admins, err := h.Users.CountByRole(c.Request.Context(), models.RoleAdmin)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count admins"})
+			return
+		}
+		if admins <= 1 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Cannot remove the last admin"})
+			return
+		}
+	}
+
+	user.Role = role
+	if err := h.Users.Update(c.Request.Context(), &user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+		return
+	}
+
+	user.Password = ""
+	c.JSON(http.StatusOK, user)
+}
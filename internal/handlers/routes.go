@@ -1,11 +1,14 @@
 package handlers
 
 import (
+	"jam-tracker/internal/models"
+	"jam-tracker/internal/ratelimit"
+
 	"github.com/gin-gonic/gin"
 )
 
 // SetupRoutes configures all the routes for our application
-func SetupRoutes(router *gin.Engine) {
+func SetupRoutes(router *gin.Engine, h *Handler) {
 	// Health check endpoint
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{
@@ -17,50 +20,131 @@ func SetupRoutes(router *gin.Engine) {
 	// API v1 routes
 	v1 := router.Group("/api/v1")
 	{
-		// Authentication routes
+		// Authentication routes, limited tightly per IP address against password guessing
 		auth := v1.Group("/auth")
+		auth.Use(h.RateLimit("auth", ratelimit.PerMinute(20)))
 		{
-			auth.POST("/register", RegisterUser)
-			auth.POST("/login", LoginUser)
+			auth.POST("/register", h.RegisterUser)
+			auth.POST("/login", h.LoginUser)
+			auth.POST("/login/mfa", h.LoginMFA)
+			auth.POST("/refresh", h.RefreshSession)
+			auth.POST("/logout", h.Logout)
+			auth.POST("/forgot-password", h.ForgotPassword)
+			auth.POST("/reset-password", h.ResetPassword)
+			auth.POST("/verify-email", h.VerifyEmail)
 		}
 
 		// Protected routes
 		protected := v1.Group("/")
-		protected.Use(AuthMiddleware())
+		protected.Use(h.AuthMiddleware(), h.RateLimit("api", ratelimit.PerMinute(300)))
 		{
+			// Catalog changes need the editor role
+			editor := h.RequireRole(models.RoleEditor)
+
 			// User routes
-			protected.GET("/profile", GetUserProfile)
-			protected.PUT("/profile", UpdateUserProfile)
-			protected.DELETE("/profile", DeleteUserAccount)
+			protected.GET("/profile", h.GetUserProfile)
+			protected.PUT("/profile", h.UpdateUserProfile)
+			protected.DELETE("/profile", h.DeleteUserAccount)
+			protected.POST("/profile/verify-email", h.ResendVerificationEmail)
+			protected.POST("/profile/mfa/enroll", h.EnrollMFA)
+			protected.POST("/profile/mfa/enable", h.EnableMFA)
+			protected.POST("/profile/mfa/disable", h.DisableMFA)
+			protected.POST("/profile/mfa/recovery-codes", h.RegenerateRecoveryCodes)
+			protected.GET("/profile/upcoming", h.GetUpcomingShows)
+			protected.GET("/profile/history", h.GetShowHistory)
+			protected.GET("/profile/stats", h.GetProfileStats)
+			protected.GET("/profile/songs", h.GetProfileSongs)
+			protected.GET("/profile/sessions", h.GetSessions)
+			protected.DELETE("/profile/sessions/:id", h.DeleteSession)
+			protected.GET("/profile/changes", h.GetMyChangeRequests)
 
 			// Show routes
-			protected.POST("/shows", CreateShow)
-			protected.GET("/shows", GetShows)
-			protected.GET("/shows/:id", GetShow)
-			protected.PUT("/shows/:id", UpdateShow)
-			protected.DELETE("/shows/:id", DeleteShow)
+			protected.POST("/shows", editor, h.CreateShow)
+			protected.GET("/shows", h.GetShows)
+			protected.GET("/shows/:id", h.GetShow)
+			protected.PUT("/shows/:id", editor, h.UpdateShow)
+			protected.DELETE("/shows/:id", editor, h.DeleteShow)
+			protected.POST("/shows/:id/changes", h.SuggestShowChange)
+
+			// Setlist routes
+			protected.POST("/shows/:id/setlist", editor, h.CreateSetlist)
+			protected.GET("/shows/:id/setlist", h.GetSetlist)
+			protected.PUT("/shows/:id/setlist/order", editor, h.ReorderSetlist)
+			protected.PUT("/shows/:id/setlist/entries/:entry_id", editor, h.UpdateSetlistEntry)
+			protected.DELETE("/shows/:id/setlist", editor, h.DeleteSetlist)
 
 			// Show attendance routes
-			protected.POST("/shows/:id/attend", AttendShow)
-			protected.PUT("/attendances/:id", UpdateAttendance)
-			protected.DELETE("/attendances/:id", DeleteAttendance)
+			protected.POST("/shows/:id/attend", h.AttendShow)
+			protected.PUT("/attendances/:id", h.UpdateAttendance)
+			protected.DELETE("/attendances/:id", h.DeleteAttendance)
+
+			// Media routes
+			protected.POST("/attendances/:id/media", h.UploadMedia)
+			protected.GET("/attendances/:id/media", h.GetAttendanceMedia)
+			protected.GET("/media/:id", h.GetMediaFile)
+			protected.GET("/media/:id/thumbnail", h.GetMediaThumbnail)
+			protected.DELETE("/media/:id", h.DeleteMedia)
+
+			// Import routes
+			protected.POST("/import/setlistfm", editor, h.ImportSetlistFile)
+			protected.POST("/import/setlistfm/:setlist_id", editor, h.ImportSetlistFromAPI)
 
 			// Band routes
-			protected.POST("/bands", CreateBand)
-			protected.PUT("/bands/:id", UpdateBand)
-			protected.GET("/bands", GetBands)
-			protected.GET("/bands/:id", GetBand)
-			protected.DELETE("/bands/:id", DeleteBand)
+			protected.POST("/bands", editor, h.CreateBand)
+			protected.PUT("/bands/:id", editor, h.UpdateBand)
+			protected.GET("/bands", h.GetBands)
+			protected.GET("/bands/:id", h.GetBand)
+			protected.DELETE("/bands/:id", editor, h.DeleteBand)
+			protected.POST("/bands/:id/changes", h.SuggestBandChange)
+			protected.GET("/bands/:id/songs/:song", h.GetBandSong)
 
 			// Venue routes
-			protected.POST("/venues", CreateVenue)
-			protected.GET("/venues", GetVenues)
-			protected.GET("/venues/:id", GetVenue)
-			protected.PUT("/venues/:id", UpdateVenue)
-			protected.DELETE("/venues/:id", DeleteVenue)
+			protected.POST("/venues", editor, h.CreateVenue)
+			protected.GET("/venues", h.GetVenues)
+			protected.GET("/venues/:id", h.GetVenue)
+			protected.PUT("/venues/:id", editor, h.UpdateVenue)
+			protected.DELETE("/venues/:id", editor, h.DeleteVenue)
+			protected.POST("/venues/:id/changes", h.SuggestVenueChange)
+
+			// Festival routes
+			protected.POST("/festivals", editor, h.CreateFestival)
+			protected.GET("/festivals", h.GetFestivals)
+			protected.GET("/festivals/:id", h.GetFestival)
+			protected.PUT("/festivals/:id", editor, h.UpdateFestival)
+			protected.DELETE("/festivals/:id", editor, h.DeleteFestival)
+
+			// Tour routes
+			protected.POST("/tours", editor, h.CreateTour)
+			protected.POST("/tours/group", editor, h.GroupTours)
+			protected.GET("/tours", h.GetTours)
+			protected.GET("/tours/:id", h.GetTour)
+			protected.PUT("/tours/:id", editor, h.UpdateTour)
+			protected.DELETE("/tours/:id", editor, h.DeleteTour)
+
+			// Change request moderation
+			protected.GET("/changes", editor, h.GetChangeRequests)
+			protected.POST("/changes/:id/approve", editor, h.ApproveChangeRequest)
+			protected.POST("/changes/:id/reject", editor, h.RejectChangeRequest)
+
+			// Friend routes
+			protected.GET("/friends", h.GetFriends)
+			protected.DELETE("/friends/:user_id", h.Unfollow)
+			protected.POST("/friends/requests", h.RequestFollow)
+			protected.GET("/friends/requests", h.GetFollowRequests)
+			protected.POST("/friends/requests/:id/accept", h.AcceptFollowRequest)
+			protected.DELETE("/friends/requests/:id", h.DeleteFollowRequest)
+			protected.GET("/feed", h.GetFeed)
 
 			// Recommendation routes
-			protected.GET("/recommendations", GetRecommendations)
+			protected.GET("/recommendations", h.GetRecommendations)
+		}
+
+		// Admin routes
+		admin := v1.Group("/admin")
+		admin.Use(h.AuthMiddleware(), h.RateLimit("api", ratelimit.PerMinute(300)), h.RequireRole(models.RoleAdmin))
+		{
+			admin.PUT("/users/:id/role", h.GrantRole)
+			admin.DELETE("/users/:id/role", h.RevokeRole)
 		}
 	}
 }
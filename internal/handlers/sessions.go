@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+
+	"jam-tracker/internal/database"
+	"jam-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 30 * 24 * time.Hour // Extended on every refresh
+)
+
+var (
+	errInvalidRefreshToken = errors.New("invalid refresh token")
+	errSessionInactive     = errors.New("session revoked or expired")
+)
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// SessionResponse is one signed-in device of the current user
+type SessionResponse struct {
+	ID         uuid.UUID `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	Current    bool      `json:"current"` // Session of the token making the request
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"` // Last sign-in or refresh
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// RefreshSession trades a refresh token for a new access token and a new refresh token
+func (h *Handler) RefreshSession(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, refreshToken, err := h.rotateSession(c, req.RefreshToken)
+	if errors.Is(err, errInvalidRefreshToken) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh session"})
+		return
+	}
+
+	user, err := h.Users.FindByID(c.Request.Context(), session.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	response, err := newAuthResponse(user, session, refreshToken)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Logout revokes the session of a refresh token
+func (h *Handler) Logout(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var session models.Session
+	if err := h.DB.Where("refresh_token_hash = ? AND revoked_at IS NULL", hashToken(req.RefreshToken)).First(&session).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+		return
+	}
+
+	if err := revokeSession(h.DB, session.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// GetSessions returns the active sessions of the current user
+func (h *Handler) GetSessions(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	currentID := c.MustGet("session_id").(uuid.UUID)
+
+	dialect := database.DialectOf(h.DB)
+	var sessions []models.Session
+	if err := h.DB.Where("user_id = ? AND revoked_at IS NULL", userID).
+		Where(dialect.Time("expires_at")+" > "+dialect.Time("?"), time.Now()).
+		Order(dialect.Time("last_used_at") + " DESC").
+		Find(&sessions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sessions"})
+		return
+	}
+
+	response := make([]SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, SessionResponse{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IPAddress,
+			Current:    session.ID == currentID,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteSession revokes one of the current user's sessions
+func (h *Handler) DeleteSession(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	var session models.Session
+	if err := h.DB.Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).First(&session).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+
+	if err := revokeSession(h.DB, session.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked successfully"})
+}
+
+// startSession opens a session for a user who just signed in and returns its refresh token
+func (h *Handler) startSession(c *gin.Context, userID uuid.UUID) (models.Session, string, error) {
+	refreshToken, err := generateToken()
+	if err != nil {
+		return models.Session{}, "", err
+	}
+
+	now := time.Now()
+	session := models.Session{
+		UserID:           userID,
+		RefreshTokenHash: hashToken(refreshToken),
+		UserAgent:        c.Request.UserAgent(),
+		IPAddress:        c.ClientIP(),
+		ExpiresAt:        now.Add(refreshTokenTTL),
+		LastUsedAt:       now,
+	}
+
+	if err := h.DB.Create(&session).Error; err != nil {
+		return models.Session{}, "", err
+	}
+	return session, refreshToken, nil
+}
+
+// rotateSession replaces a refresh token with a new one. Presenting a token that was
+// already rotated out means it leaked, so the whole session is revoked.
+func (h *Handler) rotateSession(c *gin.Context, refreshToken string) (models.Session, string, error) {
+	hash := hashToken(refreshToken)
+
+	var session models.Session
+	err := h.DB.Where("refresh_token_hash = ?", hash).First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if h.DB.Where("previous_token_hash = ?", hash).First(&session).Error == nil {
+			if err := revokeSession(h.DB, session.ID); err != nil {
+				return models.Session{}, "", err
+			}
+		}
+		return models.Session{}, "", errInvalidRefreshToken
+	}
+	if err != nil {
+		return models.Session{}, "", err
+	}
+
+	now := time.Now()
+	if session.RevokedAt != nil || now.After(session.ExpiresAt) {
+		return models.Session{}, "", errInvalidRefreshToken
+	}
+
+	newToken, err := generateToken()
+	if err != nil {
+		return models.Session{}, "", err
+	}
+
+	session.PreviousTokenHash = session.RefreshTokenHash
+	session.RefreshTokenHash = hashToken(newToken)
+	session.UserAgent = c.Request.UserAgent()
+	session.IPAddress = c.ClientIP()
+	session.ExpiresAt = now.Add(refreshTokenTTL)
+	session.LastUsedAt = now
+
+	// Only one of two concurrent refreshes with the same token wins
+	result := h.DB.Model(&models.Session{}).
+		Where("id = ? AND refresh_token_hash = ?", session.ID, hash).
+		Updates(map[string]any{
+			"previous_token_hash": session.PreviousTokenHash,
+			"refresh_token_hash":  session.RefreshTokenHash,
+			"user_agent":          session.UserAgent,
+			"ip_address":          session.IPAddress,
+			"expires_at":          session.ExpiresAt,
+			"last_used_at":        session.LastUsedAt,
+		})
+	if result.Error != nil {
+		return models.Session{}, "", result.Error
+	}
+	if result.RowsAffected == 0 {
+		return models.Session{}, "", errInvalidRefreshToken
+	}
+
+	return session, newToken, nil
+}
+
+// activeSession loads a session that is neither revoked nor expired
+func activeSession(db *gorm.DB, sessionID, userID uuid.UUID) (models.Session, error) {
+	var session models.Session
+	if err := db.Where("id = ? AND user_id = ?", sessionID, userID).First(&session).Error; err != nil {
+		return session, err
+	}
+
+	if session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return session, errSessionInactive
+	}
+	return session, nil
+}
+
+func revokeSession(db *gorm.DB, sessionID uuid.UUID) error {
+	return db.Model(&models.Session{}).Where("id = ?", sessionID).Update("revoked_at", time.Now()).Error
+}
+
+// revokeUserSessions signs a user out of every device
+func revokeUserSessions(db *gorm.DB, userID uuid.UUID) error {
+	return db.Model(&models.Session{}).Where("user_id = ? AND revoked_at IS NULL", userID).Update("revoked_at", time.Now()).Error
+}
+
+// deleteSessions removes every session of a user
+func deleteSessions(db *gorm.DB, userID uuid.UUID) error {
+	return db.Where("user_id = ?", userID).Delete(&models.Session{}).Error
+}
+
+func generateToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// hashToken hashes a refresh or mailed token for storage. Tokens are random, so a fast hash is enough.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
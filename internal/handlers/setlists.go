@@ -0,0 +1,420 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"jam-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type SetlistSongRequest struct {
+	Name            string   `json:"name" binding:"required,min=1,max=200"`
+	Transition      string   `json:"transition" binding:"omitempty,oneof=stop > ->"` // Transition into the next song
+	DurationSeconds *int     `json:"duration_seconds" binding:"omitempty,min=1"`
+	Teases          []string `json:"teases" binding:"omitempty,dive,min=1,max=200"`
+	JamNotes        string   `json:"jam_notes" binding:"omitempty,max=1000"`
+}
+
+type SetlistSetRequest struct {
+	Name     string               `json:"name" binding:"required,min=1,max=50"`  // e.g. "Set 1", "Set 2", "Encore"
+	BandName string               `json:"band_name" binding:"omitempty,max=200"` // Band on the bill playing the set, the headliner by default
+	Songs    []SetlistSongRequest `json:"songs" binding:"required,min=1,dive"`
+}
+
+type CreateSetlistRequest struct {
+	Sets []SetlistSetRequest `json:"sets" binding:"required,min=1,dive"`
+}
+
+type ReorderSetlistRequest struct {
+	SetID    string   `json:"set_id" binding:"required"`
+	EntryIDs []string `json:"entry_ids" binding:"required,min=1"` // Every entry of the set, in the new order
+}
+
+type UpdateSetlistEntryRequest struct {
+	Transition      string   `json:"transition" binding:"omitempty,oneof=stop > ->"`
+	DurationSeconds *int     `json:"duration_seconds" binding:"omitempty,min=1"`
+	Teases          []string `json:"teases" binding:"omitempty,dive,min=1,max=200"`
+	JamNotes        string   `json:"jam_notes" binding:"omitempty,max=1000"`
+}
+
+type SetlistResponse struct {
+	ShowID  uuid.UUID    `json:"show_id"`
+	Setlist string       `json:"setlist"` // Canonical rendering, e.g. "Set 1: Tweezer > Reba -> Tweezer"
+	Sets    []models.Set `json:"sets"`
+}
+
+// CreateSetlist records the full setlist for a show
+func (h *Handler) CreateSetlist(c *gin.Context) {
+	showID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid show ID"})
+		return
+	}
+
+	var req CreateSetlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Verify show exists
+	var show models.Show
+	if err := h.DB.Preload("Performances.Band").Where("id = ?", showID).First(&show).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Show not found"})
+		return
+	}
+
+	// Find the band playing each set
+	setBandIDs := make([]uuid.UUID, len(req.Sets))
+	for i, setReq := range req.Sets {
+		bandID, err := setBand(show, setReq.BandName)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		setBandIDs[i] = bandID
+	}
+
+	// Check if the show already has a setlist
+	var setCount int64
+	if err := h.DB.Model(&models.Set{}).Where("show_id = ?", show.ID).Count(&setCount).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing setlist"})
+		return
+	}
+
+	if setCount > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Setlist already exists for this show. Delete it first to replace it."})
+		return
+	}
+
+	// Create sets, songs and entries in one transaction
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		for i, setReq := range req.Sets {
+			set := models.Set{
+				ShowID:   show.ID,
+				BandID:   setBandIDs[i],
+				Name:     strings.TrimSpace(setReq.Name),
+				Position: i + 1,
+			}
+			if err := tx.Create(&set).Error; err != nil {
+				return err
+			}
+
+			for j, songReq := range setReq.Songs {
+				song, err := findOrCreateSong(tx, set.BandID, songReq.Name)
+				if err != nil {
+					return err
+				}
+
+				entry := models.SetlistEntry{
+					ShowID:          show.ID,
+					SetID:           set.ID,
+					SongID:          song.ID,
+					Position:        j + 1,
+					Transition:      parseTransition(songReq.Transition),
+					DurationSeconds: songReq.DurationSeconds,
+					Teases:          normalizeTeases(songReq.Teases),
+					JamNotes:        strings.TrimSpace(songReq.JamNotes),
+				}
+				if err := tx.Create(&entry).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create setlist"})
+		return
+	}
+
+	sets, err := loadSetlist(h.DB, show.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load setlist"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, SetlistResponse{ShowID: show.ID, Setlist: renderSetlist(sets), Sets: sets})
+}
+
+// GetSetlist returns the full setlist for a show
+func (h *Handler) GetSetlist(c *gin.Context) {
+	showID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid show ID"})
+		return
+	}
+
+	// Verify show exists
+	var show models.Show
+	if err := h.DB.Where("id = ?", showID).First(&show).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Show not found"})
+		return
+	}
+
+	sets, err := loadSetlist(h.DB, show.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load setlist"})
+		return
+	}
+
+	c.JSON(http.StatusOK, SetlistResponse{ShowID: show.ID, Setlist: renderSetlist(sets), Sets: sets})
+}
+
+// ReorderSetlist changes the order of the songs within one set of a show
+func (h *Handler) ReorderSetlist(c *gin.Context) {
+	showID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid show ID"})
+		return
+	}
+
+	var req ReorderSetlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	setID, err := uuid.Parse(req.SetID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid set ID"})
+		return
+	}
+
+	// Find the set, making sure it belongs to this show
+	var set models.Set
+	if err := h.DB.Where("id = ? AND show_id = ?", setID, showID).First(&set).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Set not found for this show"})
+		return
+	}
+
+	var entries []models.SetlistEntry
+	if err := h.DB.Where("set_id = ?", set.ID).Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load set entries"})
+		return
+	}
+
+	// The new order must list every entry of the set exactly once
+	if len(req.EntryIDs) != len(entries) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "entry_ids must list every entry in the set exactly once"})
+		return
+	}
+
+	existing := make(map[uuid.UUID]bool, len(entries))
+	for _, entry := range entries {
+		existing[entry.ID] = true
+	}
+
+	order := make([]uuid.UUID, 0, len(req.EntryIDs))
+	seen := make(map[uuid.UUID]bool, len(req.EntryIDs))
+	for _, idStr := range req.EntryIDs {
+		entryID, err := uuid.Parse(idStr)
+		if err != nil || !existing[entryID] || seen[entryID] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "entry_ids must list every entry in the set exactly once"})
+			return
+		}
+		seen[entryID] = true
+		order = append(order, entryID)
+	}
+
+	// Update positions
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		for i, entryID := range order {
+			if err := tx.Model(&models.SetlistEntry{}).Where("id = ?", entryID).Update("position", i+1).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder setlist"})
+		return
+	}
+
+	sets, err := loadSetlist(h.DB, showID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load setlist"})
+		return
+	}
+
+	c.JSON(http.StatusOK, SetlistResponse{ShowID: showID, Setlist: renderSetlist(sets), Sets: sets})
+}
+
+// UpdateSetlistEntry updates the segue and jam annotations of a single setlist entry
+func (h *Handler) UpdateSetlistEntry(c *gin.Context) {
+	showID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid show ID"})
+		return
+	}
+
+	entryID, err := uuid.Parse(c.Param("entry_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid setlist entry ID"})
+		return
+	}
+
+	var req UpdateSetlistEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Find the entry, making sure it belongs to this show
+	var entry models.SetlistEntry
+	if err := h.DB.Where("id = ? AND show_id = ?", entryID, showID).First(&entry).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Setlist entry not found for this show"})
+		return
+	}
+
+	// Update the annotations
+	entry.Transition = parseTransition(req.Transition)
+	entry.DurationSeconds = req.DurationSeconds
+	entry.Teases = normalizeTeases(req.Teases)
+	entry.JamNotes = strings.TrimSpace(req.JamNotes)
+
+	if err := h.DB.Save(&entry).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update setlist entry"})
+		return
+	}
+
+	// Reload with song
+	if err := h.DB.Preload("Song").First(&entry, entry.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load setlist entry"})
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// DeleteSetlist removes the setlist of a show so it can be recorded again
+func (h *Handler) DeleteSetlist(c *gin.Context) {
+	showID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid show ID"})
+		return
+	}
+
+	// Verify show exists
+	var show models.Show
+	if err := h.DB.Where("id = ?", showID).First(&show).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Show not found"})
+		return
+	}
+
+	if err := deleteSetlist(h.DB, show.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete setlist"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Setlist deleted successfully"})
+}
+
+// setBand finds the band on the bill of a show playing a set, the headliner when none is named
+func setBand(show models.Show, bandName string) (uuid.UUID, error) {
+	normalizedBandName := strings.ToLower(strings.TrimSpace(bandName))
+	if normalizedBandName == "" {
+		return show.BandID, nil
+	}
+
+	i := slices.IndexFunc(show.Performances, func(p models.ShowPerformance) bool { return p.Band.Name == normalizedBandName })
+	if i < 0 {
+		return uuid.Nil, fmt.Errorf("%s is not on the bill of this show", normalizedBandName)
+	}
+	return show.Performances[i].BandID, nil
+}
+
+// findOrCreateSong looks up a song in the band's catalog by name, creating it if needed.
+// A new song keeps the name as written for display.
+func findOrCreateSong(tx *gorm.DB, bandID uuid.UUID, name string) (models.Song, error) {
+	displayName := strings.Join(strings.Fields(name), " ")
+	normalizedName := strings.ToLower(displayName)
+	if normalizedName == "" {
+		return models.Song{}, errors.New("song name is required")
+	}
+
+	var song models.Song
+	err := tx.Where(models.Song{BandID: bandID, Name: normalizedName}).
+		Attrs(models.Song{DisplayName: displayName}).
+		FirstOrCreate(&song).Error
+	return song, err
+}
+
+// loadSetlist loads the sets of a show with their entries in order
+func loadSetlist(db *gorm.DB, showID uuid.UUID) ([]models.Set, error) {
+	sets := []models.Set{}
+	err := db.Where("show_id = ?", showID).
+		Order("position ASC").
+		Preload("Entries", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC")
+		}).
+		Preload("Entries.Song").
+		Find(&sets).Error
+	return sets, err
+}
+
+// deleteSetlist removes all sets and entries of a show
+func deleteSetlist(db *gorm.DB, showID uuid.UUID) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("show_id = ?", showID).Delete(&models.SetlistEntry{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("show_id = ?", showID).Delete(&models.Set{}).Error
+	})
+}
+
+// parseTransition maps a request transition to the model value, defaulting to a full stop
+func parseTransition(transition string) models.Transition {
+	switch models.Transition(transition) {
+	case models.TransitionSegue, models.TransitionJam:
+		return models.Transition(transition)
+	default:
+		return models.TransitionStop
+	}
+}
+
+// normalizeTeases trims and lowercases teased song names to match the song catalog
+func normalizeTeases(teases []string) []string {
+	normalized := []string{}
+	for _, tease := range teases {
+		if name := strings.ToLower(strings.TrimSpace(tease)); name != "" {
+			normalized = append(normalized, name)
+		}
+	}
+	return normalized
+}
+
+// renderSetlist renders sets in the canonical "Set 1: Tweezer > Reba -> Tweezer; Encore: ..." form
+func renderSetlist(sets []models.Set) string {
+	renderedSets := make([]string, 0, len(sets))
+	for _, set := range sets {
+		var b strings.Builder
+		b.WriteString(set.Name)
+		b.WriteString(": ")
+
+		for i, entry := range set.Entries {
+			b.WriteString(entry.Song.DisplayName)
+
+			// A stop between songs is a comma; the last song only shows a segue or jam
+			switch {
+			case entry.Transition == models.TransitionSegue || entry.Transition == models.TransitionJam:
+				b.WriteString(" " + string(entry.Transition))
+				if i < len(set.Entries)-1 {
+					b.WriteString(" ")
+				}
+			case i < len(set.Entries)-1:
+				b.WriteString(", ")
+			}
+		}
+
+		renderedSets = append(renderedSets, b.String())
+	}
+	return strings.Join(renderedSets, "; ")
+}
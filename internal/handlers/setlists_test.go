@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"jam-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newSetlistTestServer returns an API server with a goose show at red rocks
+func newSetlistTestServer(t *testing.T) (*testServer, models.Show) {
+	s := newAPIServer(t)
+	s.do(http.MethodPost, "/api/v1/bands", gin.H{"band_name": "goose"}, http.StatusCreated)
+	s.do(http.MethodPost, "/api/v1/venues", gin.H{"name": "Red Rocks", "city": "Morrison", "state": "CO"}, http.StatusCreated)
+	show := decode[models.Show](t, s.do(http.MethodPost, "/api/v1/shows",
+		gin.H{"band_name": "goose", "venue_name": "red rocks", "date": "2025-09-10"}, http.StatusCreated))
+	return s, show
+}
+
+// songNames returns the display names of the songs of a set in order
+func songNames(set models.Set) []string {
+	names := make([]string, len(set.Entries))
+	for i, entry := range set.Entries {
+		names[i] = entry.Song.DisplayName
+	}
+	return names
+}
+
+func TestCreateSetlist(t *testing.T) {
+	s, show := newSetlistTestServer(t)
+	path := "/api/v1/shows/" + show.ID.String() + "/setlist"
+
+	created := decode[SetlistResponse](t, s.do(http.MethodPost, path, gin.H{"sets": []gin.H{
+		{"name": "Set 1", "songs": []gin.H{{"name": "Arcadia"}, {"name": "  Hot   Tea "}}},
+		{"name": "Encore", "songs": []gin.H{{"name": "arcadia"}}},
+	}}, http.StatusCreated))
+	if len(created.Sets) != 2 || created.Sets[0].Name != "Set 1" || created.Sets[1].Name != "Encore" {
+		t.Fatalf("sets = %+v, want Set 1 and Encore in order", created.Sets)
+	}
+	if names := songNames(created.Sets[0]); len(names) != 2 || names[0] != "Arcadia" || names[1] != "Hot Tea" {
+		t.Errorf("Set 1 = %v, want Arcadia, Hot Tea", names)
+	}
+
+	// A song played twice is one song of the band
+	if created.Sets[0].Entries[0].SongID != created.Sets[1].Entries[0].SongID {
+		t.Error("Arcadia in the encore is a different song than in Set 1")
+	}
+
+	got := decode[SetlistResponse](t, s.do(http.MethodGet, path, nil, http.StatusOK))
+	if len(got.Sets) != 2 || len(got.Sets[0].Entries) != 2 || len(got.Sets[1].Entries) != 1 {
+		t.Errorf("stored sets = %+v, want the sets as created", got.Sets)
+	}
+
+	s.do(http.MethodPost, path, gin.H{"sets": []gin.H{{"name": "Set 1", "songs": []gin.H{{"name": "Tumble"}}}}}, http.StatusConflict)
+	s.do(http.MethodPost, path, gin.H{"sets": []gin.H{{"name": "Set 1", "songs": []gin.H{}}}}, http.StatusBadRequest)
+
+	// Deleting the setlist lets it be recorded again
+	s.do(http.MethodDelete, path, nil, http.StatusOK)
+	s.do(http.MethodPost, path, gin.H{"sets": []gin.H{{"name": "Set 1", "songs": []gin.H{{"name": "Tumble"}}}}}, http.StatusCreated)
+}
+
+func TestReorderSetlist(t *testing.T) {
+	s, show := newSetlistTestServer(t)
+	path := "/api/v1/shows/" + show.ID.String() + "/setlist"
+
+	created := decode[SetlistResponse](t, s.do(http.MethodPost, path, gin.H{"sets": []gin.H{
+		{"name": "Set 1", "songs": []gin.H{{"name": "Arcadia"}, {"name": "Hot Tea"}, {"name": "Tumble"}}},
+		{"name": "Encore", "songs": []gin.H{{"name": "Madhuvan"}}},
+	}}, http.StatusCreated))
+	set, encore := created.Sets[0], created.Sets[1]
+	arcadia, hotTea, tumble := set.Entries[0].ID.String(), set.Entries[1].ID.String(), set.Entries[2].ID.String()
+
+	reordered := decode[SetlistResponse](t, s.do(http.MethodPut, path+"/order",
+		gin.H{"set_id": set.ID, "entry_ids": []string{tumble, arcadia, hotTea}}, http.StatusOK))
+	if names := songNames(reordered.Sets[0]); len(names) != 3 || names[0] != "Tumble" || names[1] != "Arcadia" || names[2] != "Hot Tea" {
+		t.Errorf("Set 1 = %v, want Tumble, Arcadia, Hot Tea", names)
+	}
+	for i, entry := range reordered.Sets[0].Entries {
+		if entry.Position != i+1 {
+			t.Errorf("%s is at position %d, want %d", entry.Song.DisplayName, entry.Position, i+1)
+		}
+	}
+	if names := songNames(reordered.Sets[1]); len(names) != 1 || names[0] != "Madhuvan" {
+		t.Errorf("Encore = %v, want it untouched", names)
+	}
+
+	// The new order must list every entry of the set exactly once
+	for _, entryIDs := range [][]string{
+		{tumble, arcadia},
+		{tumble, arcadia, arcadia},
+		{tumble, arcadia, encore.Entries[0].ID.String()},
+		{tumble, arcadia, "not-an-id"},
+	} {
+		s.do(http.MethodPut, path+"/order", gin.H{"set_id": set.ID, "entry_ids": entryIDs}, http.StatusBadRequest)
+	}
+
+	// Sets of other shows are not found through this one
+	other := decode[models.Show](t, s.do(http.MethodPost, "/api/v1/shows",
+		gin.H{"band_name": "goose", "venue_name": "red rocks", "date": "2025-09-11"}, http.StatusCreated))
+	s.do(http.MethodPut, "/api/v1/shows/"+other.ID.String()+"/setlist/order",
+		gin.H{"set_id": set.ID, "entry_ids": []string{arcadia, hotTea, tumble}}, http.StatusNotFound)
+
+	got := decode[SetlistResponse](t, s.do(http.MethodGet, path, nil, http.StatusOK))
+	if names := songNames(got.Sets[0]); names[0] != "Tumble" {
+		t.Errorf("stored Set 1 = %v, want the order of the successful reorder", names)
+	}
+}
+
+func TestRenderSetlist(t *testing.T) {
+	entry := func(name string, transition models.Transition) models.SetlistEntry {
+		return models.SetlistEntry{Song: models.Song{Name: strings.ToLower(name), DisplayName: name}, Transition: transition}
+	}
+
+	tests := []struct {
+		name string
+		sets []models.Set
+		want string
+	}{
+		{"no sets", nil, ""},
+		{
+			"stops, segues and jams",
+			[]models.Set{{Name: "Set 1", Entries: []models.SetlistEntry{
+				entry("Tweezer", models.TransitionSegue),
+				entry("Reba", models.TransitionJam),
+				entry("Tweezer", models.TransitionStop),
+				entry("Sample in a Jar", models.TransitionStop),
+			}}},
+			"Set 1: Tweezer > Reba -> Tweezer, Sample in a Jar",
+		},
+		{
+			"several sets",
+			[]models.Set{
+				{Name: "Set 1", Entries: []models.SetlistEntry{entry("Arcadia", models.TransitionStop), entry("Hot Tea", models.TransitionStop)}},
+				{Name: "Encore", Entries: []models.SetlistEntry{entry("Madhuvan", models.TransitionStop)}},
+			},
+			"Set 1: Arcadia, Hot Tea; Encore: Madhuvan",
+		},
+		{
+			"last song jams into the next set",
+			[]models.Set{
+				{Name: "Set 1", Entries: []models.SetlistEntry{entry("Arcadia", models.TransitionStop), entry("Hot Tea", models.TransitionJam)}},
+				{Name: "Set 2", Entries: []models.SetlistEntry{entry("Tumble", models.TransitionStop)}},
+			},
+			"Set 1: Arcadia, Hot Tea ->; Set 2: Tumble",
+		},
+	}
+	for _, tt := range tests {
+		if got := renderSetlist(tt.sets); got != tt.want {
+			t.Errorf("%s: renderSetlist = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSetlistAnnotations(t *testing.T) {
+	s, show := newSetlistTestServer(t)
+	path := "/api/v1/shows/" + show.ID.String() + "/setlist"
+
+	duration := 1260
+	created := decode[SetlistResponse](t, s.do(http.MethodPost, path, gin.H{"sets": []gin.H{
+		{"name": "Set 1", "songs": []gin.H{
+			{"name": "Arcadia", "transition": ">"},
+			{"name": "Hot Tea", "transition": "->", "duration_seconds": duration, "teases": []string{" Tweezer "}, "jam_notes": " Type II "},
+			{"name": "Tumble"},
+		}},
+	}}, http.StatusCreated))
+	if created.Setlist != "Set 1: Arcadia > Hot Tea -> Tumble" {
+		t.Errorf("setlist = %q, want the segue and jam rendered", created.Setlist)
+	}
+
+	hotTea := created.Sets[0].Entries[1]
+	if hotTea.DurationSeconds == nil || *hotTea.DurationSeconds != duration || len(hotTea.Teases) != 1 || hotTea.Teases[0] != "tweezer" || hotTea.JamNotes != "Type II" {
+		t.Errorf("Hot Tea = %+v, want its duration and the tease and jam notes trimmed", hotTea)
+	}
+	if tumble := created.Sets[0].Entries[2]; tumble.Transition != models.TransitionStop {
+		t.Errorf("Tumble transition = %q, want a stop by default", tumble.Transition)
+	}
+
+	s.do(http.MethodPost, "/api/v1/shows/"+show.ID.String()+"/setlist",
+		gin.H{"sets": []gin.H{{"name": "Set 2", "songs": []gin.H{{"name": "Tumble", "transition": "=>"}}}}}, http.StatusBadRequest)
+
+	// Updating an entry replaces all of its annotations
+	entryPath := path + "/entries/" + hotTea.ID.String()
+	updated := decode[models.SetlistEntry](t, s.do(http.MethodPut, entryPath, gin.H{"transition": ">"}, http.StatusOK))
+	if updated.Transition != models.TransitionSegue || updated.DurationSeconds != nil || len(updated.Teases) != 0 || updated.JamNotes != "" {
+		t.Errorf("updated entry = %+v, want a segue and no other annotations", updated)
+	}
+	if updated.Song.DisplayName != "Hot Tea" {
+		t.Errorf("updated entry song = %q, want Hot Tea", updated.Song.DisplayName)
+	}
+
+	got := decode[SetlistResponse](t, s.do(http.MethodGet, path, nil, http.StatusOK))
+	if got.Setlist != "Set 1: Arcadia > Hot Tea > Tumble" {
+		t.Errorf("setlist = %q after the update, want Hot Tea to segue", got.Setlist)
+	}
+
+	s.do(http.MethodPut, entryPath, gin.H{"duration_seconds": 0}, http.StatusBadRequest)
+	s.do(http.MethodPut, "/api/v1/shows/"+show.ID.String()+"/setlist/entries/"+created.Sets[0].ID.String(), gin.H{}, http.StatusNotFound)
+}
@@ -1,115 +1,114 @@
 package handlers
 
 import (
-	"jam-tracker/internal/database"
+	"errors"
 	"jam-tracker/internal/models"
+	"jam-tracker/internal/repository"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type CreateShowRequest struct {
-	BandName  string `json:"band_name" binding:"required,min=1"`
-	VenueName string `json:"venue_name" binding:"required,min=1"`
-	Date      string `json:"date" binding:"required"` // Format: "2024-12-25"
-	Notes     string `json:"notes" binding:"omitempty,max=500"`
+	BandName     string               `json:"band_name" binding:"required_without=Performances"` // Headliner
+	VenueName    string               `json:"venue_name" binding:"required,min=1"`
+	Date         string               `json:"date" binding:"required"`                      // Format: "2024-12-25"
+	Performances []PerformanceRequest `json:"performances" binding:"omitempty,max=50,dive"` // Bill in billing order, headliner first
+	FestivalID   string               `json:"festival_id" binding:"omitempty,uuid"`
+	Stage        string               `json:"stage" binding:"omitempty,max=100"`
+	Notes        string               `json:"notes" binding:"omitempty,max=500"`
 }
 
 type UpdateShowRequest struct {
-	BandName  string `json:"band_name" binding:"required,min=1"`
-	VenueName string `json:"venue_name" binding:"required,min=1"`
-	Date      string `json:"date" binding:"required"`
-	Notes     string `json:"notes" binding:"omitempty,max=500"`
+	BandName     string               `json:"band_name" binding:"required_without=Performances"`
+	VenueName    string               `json:"venue_name" binding:"required,min=1"`
+	Date         string               `json:"date" binding:"required"`
+	Performances []PerformanceRequest `json:"performances" binding:"omitempty,max=50,dive"` // Left out keeps the openers
+	FestivalID   string               `json:"festival_id" binding:"omitempty,uuid"`
+	Stage        string               `json:"stage" binding:"omitempty,max=100"`
+	Notes        string               `json:"notes" binding:"omitempty,max=500"`
 }
 
 // Parse request body
 type AttendShowRequest struct {
-	Attended     bool     `json:"attended"`
+	Status       string   `json:"status" binding:"omitempty,oneof=attended interested going missed"` // Defaults to "attended"
 	Rating       *float64 `json:"rating" binding:"omitempty,min=1,max=5"`
 	FavoriteSong string   `json:"favorite_song" binding:"omitempty,max=200"`
 	Notes        string   `json:"notes" binding:"omitempty,max=500"`
+	CaughtBands  []string `json:"caught_bands" binding:"omitempty,max=50"` // Bands on the bill the user saw
 }
 
-func CreateShow(c *gin.Context) {
+func (h *Handler) CreateShow(c *gin.Context) {
 	var req CreateShowRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Find the band
-	var band models.Band
-	normalizedBandName := strings.ToLower(strings.TrimSpace(req.BandName))
-	if err := database.DB.Where("name = ?", normalizedBandName).First(&band).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Band not found"})
-		return
-	}
-
 	// Find the venue
-	var venue models.Venue
 	normalizedVenueName := strings.ToLower(strings.TrimSpace(req.VenueName))
-	if err := database.DB.Where("name = ?", normalizedVenueName).First(&venue).Error; err != nil {
+	venue, err := h.Venues.FindByName(c.Request.Context(), normalizedVenueName)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Venue not found"})
 		return
 	}
 
-	// Parse the date/time - try multiple formats
-	var showDate time.Time
-	var err error
+	// Parse the date/time at the venue
+	showDate, ok := parseShowDate(req.Date, venue)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": invalidShowDateMessage})
+		return
+	}
 
-	// Try ISO format with time first (2024-12-25T20:00:00Z)
-	showDate, err = time.Parse(time.RFC3339, req.Date)
-	if err != nil {
-		// Try date only format (2024-12-25)
-		showDate, err = time.Parse("2006-01-02", req.Date)
-		if err != nil {
-			// Try date with time format (2024-12-25 20:00)
-			showDate, err = time.Parse("2006-01-02 15:04", req.Date)
-			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{
-					"error": "Invalid date format. Use YYYY-MM-DD, YYYY-MM-DD HH:MM, or ISO format",
-				})
-				return
-			}
-		}
+	// Find the bands on the bill
+	bill, ok := h.buildBill(c, req.BandName, req.Performances, venue, showDate, nil)
+	if !ok {
+		return
 	}
 
-	// Check for duplicate show (same band, venue, and date)
-	var existingShow models.Show
-	if err := database.DB.Where("band_id = ? AND venue_id = ? AND DATE(date) = ?",
-		band.ID, venue.ID, showDate.Format("2006-01-02")).First(&existingShow).Error; err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "Show already exists for this band, venue, and date"})
+	// Check for duplicate show (a band on the bill at the same venue and date at the venue)
+	localDate := venue.LocalDate(showDate)
+	if !h.checkBillConflicts(c, bill, venue.ID, localDate, uuid.Nil) {
+		return
+	}
+
+	festivalID, ok := h.findShowFestival(c, req.FestivalID, localDate)
+	if !ok {
 		return
 	}
 
 	// Create the show
 	show := models.Show{
-		BandID:  band.ID,
-		VenueID: venue.ID,
-		Date:    showDate,
-		Notes:   strings.TrimSpace(req.Notes),
+		BandID:       bill[0].BandID,
+		VenueID:      venue.ID,
+		FestivalID:   festivalID,
+		Stage:        strings.TrimSpace(req.Stage),
+		Date:         showDate,
+		LocalDate:    localDate,
+		Notes:        strings.TrimSpace(req.Notes),
+		Performances: bill,
 	}
 
-	if err := database.DB.Create(&show).Error; err != nil {
+	if err := h.Shows.Create(c.Request.Context(), &show); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create show"})
 		return
 	}
 
-	// Preload band and venue for response
-	if err := database.DB.Preload("Band").Preload("Venue").First(&show, show.ID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load show details"})
-		return
-	}
-
 	c.JSON(http.StatusCreated, show)
 }
 
-func GetShows(c *gin.Context) {
-	var shows []models.Show
+// showListOptions are the sorts GetShows accepts
+var showListOptions = ListOptions[models.Show]{
+	Spec:         repository.ShowSorts,
+	DefaultSort:  "date",
+	DefaultOrder: "desc", // Most recent first
+}
 
+func (h *Handler) GetShows(c *gin.Context) {
 	// Get query parameters for filtering
 	bandName := c.Query("band")
 	venueName := c.Query("venue")
@@ -118,61 +117,50 @@ func GetShows(c *gin.Context) {
 	dateFrom := c.Query("date_from") // YYYY-MM-DD
 	dateTo := c.Query("date_to")     // YYYY-MM-DD
 
-	query := database.DB.Preload("Band").Preload("Venue")
+	var filter repository.ShowFilter
 
 	// Filter by band name
 	if bandName != "" {
-		normalizedBandName := strings.ToLower(strings.ReplaceAll(bandName, "-", " "))
-		query = query.Joins("JOIN bands ON shows.band_id = bands.id").
-			Where("LOWER(bands.name) LIKE ?", "%"+normalizedBandName+"%")
+		filter.Band = strings.ToLower(strings.ReplaceAll(bandName, "-", " "))
 	}
 
 	// Filter by venue name
 	if venueName != "" {
-		normalizedVenueName := strings.ToLower(strings.ReplaceAll(venueName, "-", " "))
-		query = query.Joins("JOIN venues ON shows.venue_id = venues.id").
-			Where("LOWER(venues.name) LIKE ?", "%"+normalizedVenueName+"%")
+		filter.Venue = strings.ToLower(strings.ReplaceAll(venueName, "-", " "))
 	}
 
 	// Filter by city
 	if city != "" {
-		normalizedCity := strings.ToLower(strings.ReplaceAll(city, "-", " "))
-		query = query.Joins("JOIN venues ON shows.venue_id = venues.id").
-			Where("LOWER(venues.city) LIKE ?", "%"+normalizedCity+"%")
+		filter.City = strings.ToLower(strings.ReplaceAll(city, "-", " "))
 	}
 
 	// Filter by state
 	if state != "" {
-		normalizedState := strings.ToLower(strings.ReplaceAll(state, "-", " "))
-		query = query.Joins("JOIN venues ON shows.venue_id = venues.id").
-			Where("LOWER(venues.state) LIKE ?", "%"+normalizedState+"%")
+		filter.State = strings.ToLower(strings.ReplaceAll(state, "-", " "))
 	}
 
 	// Filter by date range
-	if dateFrom != "" {
-		if fromDate, err := time.Parse("2006-01-02", dateFrom); err == nil {
-			query = query.Where("DATE(shows.date) >= ?", fromDate.Format("2006-01-02"))
-		}
-	}
+	filter.DateFrom = parseDateParam(dateFrom)
+	filter.DateTo = parseDateParam(dateTo)
 
-	if dateTo != "" {
-		if toDate, err := time.Parse("2006-01-02", dateTo); err == nil {
-			query = query.Where("DATE(shows.date) <= ?", toDate.Format("2006-01-02"))
-		}
+	// Filter by distance from the venue
+	near, ok := parseNear(c)
+	if !ok {
+		return
 	}
+	filter.Near = near
 
-	// Order by date (most recent first)
-	query = query.Order("shows.date DESC")
-
-	if err := query.Find(&shows).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch shows"})
+	shows, ok := paginate(c, showListOptions, func(page repository.PageRequest) (repository.Page[models.Show], error) {
+		return h.Shows.List(c.Request.Context(), filter, page)
+	})
+	if !ok {
 		return
 	}
 
 	c.JSON(http.StatusOK, shows)
 }
 
-func GetShow(c *gin.Context) {
+func (h *Handler) GetShow(c *gin.Context) {
 	showIDParam := c.Param("id")
 
 	showID, err := uuid.Parse(showIDParam)
@@ -181,16 +169,25 @@ func GetShow(c *gin.Context) {
 		return
 	}
 
-	var show models.Show
-	if err := database.DB.Preload("Band").Preload("Venue").Where("id = ?", showID).First(&show).Error; err != nil {
+	show, err := h.Shows.FindByID(c.Request.Context(), showID)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Show not found"})
 		return
 	}
 
+	// Load and render the setlist
+	sets, err := loadSetlist(h.DB, show.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load setlist"})
+		return
+	}
+	show.Sets = sets
+	show.Setlist = renderSetlist(sets)
+
 	c.JSON(http.StatusOK, show)
 }
 
-func UpdateShow(c *gin.Context) {
+func (h *Handler) UpdateShow(c *gin.Context) {
 	showIDParam := c.Param("id")
 
 	showID, err := uuid.Parse(showIDParam)
@@ -206,73 +203,79 @@ func UpdateShow(c *gin.Context) {
 	}
 
 	// Find the show
-	var show models.Show
-	if err := database.DB.Where("id = ?", showID).First(&show).Error; err != nil {
+	show, err := h.Shows.FindByID(c.Request.Context(), showID)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Show not found"})
 		return
 	}
 
-	// Find the band
-	var band models.Band
-	normalizedBandName := strings.ToLower(strings.TrimSpace(req.BandName))
-	if err := database.DB.Where("name = ?", normalizedBandName).First(&band).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Band not found"})
+	if !h.applyShowUpdate(c, &show, req) {
 		return
 	}
 
+	c.JSON(http.StatusOK, show)
+}
+
+// applyShowUpdate saves req onto show. It writes the error response itself and
+// returns false when the update failed.
+func (h *Handler) applyShowUpdate(c *gin.Context, show *models.Show, req UpdateShowRequest) bool {
 	// Find the venue
-	var venue models.Venue
 	normalizedVenueName := strings.ToLower(strings.TrimSpace(req.VenueName))
-	if err := database.DB.Where("name = ?", normalizedVenueName).First(&venue).Error; err != nil {
+	venue, err := h.Venues.FindByName(c.Request.Context(), normalizedVenueName)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Venue not found"})
-		return
+		return false
 	}
 
-	// Parse the date/time
-	var showDate time.Time
-	showDate, err = time.Parse(time.RFC3339, req.Date)
-	if err != nil {
-		showDate, err = time.Parse("2006-01-02", req.Date)
-		if err != nil {
-			showDate, err = time.Parse("2006-01-02 15:04", req.Date)
-			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{
-					"error": "Invalid date format. Use YYYY-MM-DD, YYYY-MM-DD HH:MM, or ISO format",
-				})
-				return
-			}
-		}
+	// Parse the date/time at the venue
+	showDate, ok := parseShowDate(req.Date, venue)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": invalidShowDateMessage})
+		return false
+	}
+
+	// Find the bands on the bill
+	bill, ok := h.buildBill(c, req.BandName, req.Performances, venue, showDate, show)
+	if !ok {
+		return false
 	}
 
 	// Check for duplicate show (excluding current show)
-	var existingShow models.Show
-	if err := database.DB.Where("band_id = ? AND venue_id = ? AND DATE(date) = ? AND id != ?",
-		band.ID, venue.ID, showDate.Format("2006-01-02"), show.ID).First(&existingShow).Error; err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "Show already exists for this band, venue, and date"})
-		return
+	localDate := venue.LocalDate(showDate)
+	if !h.checkBillConflicts(c, bill, venue.ID, localDate, show.ID) {
+		return false
+	}
+
+	festivalID, ok := h.findShowFestival(c, req.FestivalID, localDate)
+	if !ok {
+		return false
 	}
 
 	// Update the show
-	show.BandID = band.ID
+	show.BandID = bill[0].BandID
 	show.VenueID = venue.ID
+	show.FestivalID = festivalID
+	show.Stage = strings.TrimSpace(req.Stage)
 	show.Date = showDate
+	show.LocalDate = localDate
 	show.Notes = strings.TrimSpace(req.Notes)
+	show.Performances = bill
 
-	if err := database.DB.Save(&show).Error; err != nil {
+	if err := h.Shows.Update(c.Request.Context(), show); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update show"})
-		return
+		return false
 	}
 
-	// Reload with preloads
-	if err := database.DB.Preload("Band").Preload("Venue").First(&show, show.ID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load show details"})
-		return
+	// Bands that left the bill no longer tour with the show
+	if err := dropShowFromOtherBandsTours(h.DB, *show); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update show tours"})
+		return false
 	}
 
-	c.JSON(http.StatusOK, show)
+	return true
 }
 
-func DeleteShow(c *gin.Context) {
+func (h *Handler) DeleteShow(c *gin.Context) {
 	showIDParam := c.Param("id")
 
 	showID, err := uuid.Parse(showIDParam)
@@ -282,20 +285,45 @@ func DeleteShow(c *gin.Context) {
 	}
 
 	// Find the show
-	var show models.Show
-	if err := database.DB.Where("id = ?", showID).First(&show).Error; err != nil {
+	show, err := h.Shows.FindByID(c.Request.Context(), showID)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Show not found"})
 		return
 	}
 
+	// Delete media attached to the show's attendance records
+	if err := deleteMedia(c.Request.Context(), h.DB, "attendance_id IN (?)",
+		h.DB.Model(&models.ShowAttendance{}).Select("id").Where("show_id = ?", show.ID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete attendance media"})
+		return
+	}
+
 	// Delete associated attendance records first
-	if err := database.DB.Where("show_id = ?", show.ID).Delete(&models.ShowAttendance{}).Error; err != nil {
+	if err := h.Attendances.DeleteByShow(c.Request.Context(), show.ID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete attendance records"})
 		return
 	}
 
+	// Delete the setlist
+	if err := deleteSetlist(h.DB, show.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete setlist"})
+		return
+	}
+
+	// Delete suggested changes to the show
+	if err := deleteChangeRequests(h.DB, "target_type = ? AND target_id = ?", models.ChangeTargetShow, show.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete change requests"})
+		return
+	}
+
+	// Take the show off its tours
+	if err := h.DB.Where("show_id = ?", show.ID).Delete(&models.TourShow{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove show from tours"})
+		return
+	}
+
 	// Delete the show
-	if err := database.DB.Delete(&show).Error; err != nil {
+	if err := h.Shows.Delete(c.Request.Context(), show.ID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete show"})
 		return
 	}
@@ -303,7 +331,7 @@ func DeleteShow(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Show deleted successfully"})
 }
 
-func AttendShow(c *gin.Context) {
+func (h *Handler) AttendShow(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
@@ -324,37 +352,44 @@ func AttendShow(c *gin.Context) {
 	}
 
 	// Verify show exists
-	var show models.Show
-	if err := database.DB.Where("id = ?", showID).First(&show).Error; err != nil {
+	show, err := h.Shows.FindByID(c.Request.Context(), showID)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Show not found"})
 		return
 	}
 
 	// Check if user already has attendance for this show
-	var existingAttendance models.ShowAttendance
-	if err := database.DB.Where("user_id = ? AND show_id = ?", userID, showID).First(&existingAttendance).Error; err == nil {
+	if _, err := h.Attendances.FindByUserAndShow(c.Request.Context(), userID.(uuid.UUID), showID); err == nil {
 		c.JSON(http.StatusConflict, gin.H{"error": "Attendance already exists for this show. Use PUT to update."})
 		return
 	}
 
-	// Create attendance record
-	attendance := models.ShowAttendance{
-		UserID:       userID.(uuid.UUID),
-		ShowID:       showID,
-		Attended:     req.Attended,
-		Rating:       req.Rating,
-		FavoriteSong: strings.TrimSpace(req.FavoriteSong),
-		Notes:        strings.TrimSpace(req.Notes),
+	// Only shows that already happened can be rated
+	status := parseAttendanceStatus(req.Status)
+	if err := validateAttendanceRating(status, req.Rating, show); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	if err := database.DB.Create(&attendance).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create attendance record"})
+	caught, err := caughtPerformances(show, status, req.CaughtBands)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Preload relationships for response
-	if err := database.DB.Preload("Show.Band").Preload("Show.Venue").First(&attendance, attendance.ID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load attendance details"})
+	// Create attendance record
+	attendance := models.ShowAttendance{
+		UserID:             userID.(uuid.UUID),
+		ShowID:             showID,
+		Status:             status,
+		Rating:             req.Rating,
+		FavoriteSong:       strings.TrimSpace(req.FavoriteSong),
+		Notes:              strings.TrimSpace(req.Notes),
+		CaughtPerformances: caught,
+	}
+
+	if err := h.Attendances.Create(c.Request.Context(), &attendance); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create attendance record"})
 		return
 	}
 
@@ -362,7 +397,7 @@ func AttendShow(c *gin.Context) {
 }
 
 // UpdateAttendance - Update an existing attendance record
-func UpdateAttendance(c *gin.Context) {
+func (h *Handler) UpdateAttendance(c *gin.Context) {
 	// Get user ID from JWT token
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -380,10 +415,11 @@ func UpdateAttendance(c *gin.Context) {
 
 	// Parse request body
 	type UpdateAttendanceRequest struct {
-		Attended     bool     `json:"attended"`
+		Status       string   `json:"status" binding:"omitempty,oneof=attended interested going missed"`
 		Rating       *float64 `json:"rating" binding:"omitempty,min=1,max=5"`
 		FavoriteSong string   `json:"favorite_song" binding:"omitempty,max=200"`
 		Notes        string   `json:"notes" binding:"omitempty,max=500"`
+		CaughtBands  []string `json:"caught_bands" binding:"omitempty,max=50"` // Left out keeps the sets caught
 	}
 
 	var req UpdateAttendanceRequest
@@ -393,8 +429,8 @@ func UpdateAttendance(c *gin.Context) {
 	}
 
 	// Find the attendance record
-	var attendance models.ShowAttendance
-	if err := database.DB.Where("id = ?", attendanceID).First(&attendance).Error; err != nil {
+	attendance, err := h.Attendances.FindByID(c.Request.Context(), attendanceID)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Attendance record not found"})
 		return
 	}
@@ -405,28 +441,42 @@ func UpdateAttendance(c *gin.Context) {
 		return
 	}
 
+	// Only shows that already happened can be rated
+	status := parseAttendanceStatus(req.Status)
+	if err := validateAttendanceRating(status, req.Rating, attendance.Show); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Sets caught only count while the show counts as attended
+	caught := attendance.CaughtPerformances
+	if req.CaughtBands != nil {
+		caught, err = caughtPerformances(attendance.Show, status, req.CaughtBands)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	} else if status != models.AttendanceStatusAttended {
+		caught = []models.ShowPerformance{}
+	}
+
 	// Update the attendance
-	attendance.Attended = req.Attended
+	attendance.Status = status
 	attendance.Rating = req.Rating
 	attendance.FavoriteSong = strings.TrimSpace(req.FavoriteSong)
 	attendance.Notes = strings.TrimSpace(req.Notes)
+	attendance.CaughtPerformances = caught
 
-	if err := database.DB.Save(&attendance).Error; err != nil {
+	if err := h.Attendances.Update(c.Request.Context(), &attendance); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update attendance record"})
 		return
 	}
 
-	// Reload with relationships
-	if err := database.DB.Preload("Show.Band").Preload("Show.Venue").First(&attendance, attendance.ID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load attendance details"})
-		return
-	}
-
 	c.JSON(http.StatusOK, attendance)
 }
 
 // DeleteAttendance - Remove an attendance record
-func DeleteAttendance(c *gin.Context) {
+func (h *Handler) DeleteAttendance(c *gin.Context) {
 	// Get user ID from JWT token
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -443,8 +493,8 @@ func DeleteAttendance(c *gin.Context) {
 	}
 
 	// Find the attendance record
-	var attendance models.ShowAttendance
-	if err := database.DB.Where("id = ?", attendanceID).First(&attendance).Error; err != nil {
+	attendance, err := h.Attendances.FindByID(c.Request.Context(), attendanceID)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Attendance record not found"})
 		return
 	}
@@ -455,8 +505,14 @@ func DeleteAttendance(c *gin.Context) {
 		return
 	}
 
+	// Delete attached media
+	if err := deleteMedia(c.Request.Context(), h.DB, "attendance_id = ?", attendance.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete attendance media"})
+		return
+	}
+
 	// Delete the attendance record
-	if err := database.DB.Delete(&attendance).Error; err != nil {
+	if err := h.Attendances.Delete(c.Request.Context(), attendance.ID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete attendance record"})
 		return
 	}
@@ -464,6 +520,138 @@ func DeleteAttendance(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Attendance record deleted successfully"})
 }
 
-func GetRecommendations(c *gin.Context) {
-	c.JSON(http.StatusNotImplemented, gin.H{"message": "GetRecommendations not implemented yet"})
+// filterShowDates limits a query joined to shows to the date_from/date_to range (YYYY-MM-DD),
+// compared to the calendar day at the venue. Unparseable dates are ignored.
+func filterShowDates(query *gorm.DB, dateFrom, dateTo string) *gorm.DB {
+	if fromDate := parseDateParam(dateFrom); fromDate != nil {
+		query = query.Where("shows.local_date >= ?", fromDate.Format("2006-01-02"))
+	}
+
+	if toDate := parseDateParam(dateTo); toDate != nil {
+		query = query.Where("shows.local_date <= ?", toDate.Format("2006-01-02"))
+	}
+
+	return query
+}
+
+const invalidShowDateMessage = "Invalid date format. Use YYYY-MM-DD, YYYY-MM-DD HH:MM, or ISO format"
+
+// parseShowDate parses the date of a show as 2024-12-25, 2024-12-25 20:00 or ISO
+// format. Dates without an offset are read as local time at the venue.
+func parseShowDate(value string, venue models.Venue) (time.Time, bool) {
+	if date, err := time.Parse(time.RFC3339, value); err == nil {
+		return date, true
+	}
+	for _, layout := range []string{"2006-01-02", "2006-01-02 15:04"} {
+		if date, err := time.ParseInLocation(layout, value, venue.TimeZone()); err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseDateParam parses a YYYY-MM-DD query parameter, returning nil when it is empty or invalid
+func parseDateParam(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil
+	}
+	return &date
+}
+
+// attendanceListOptions are the sorts attendance lists accept
+func attendanceListOptions(defaultOrder string) ListOptions[models.ShowAttendance] {
+	return ListOptions[models.ShowAttendance]{
+		Spec:         repository.AttendanceSorts,
+		DefaultSort:  "date",
+		DefaultOrder: defaultOrder,
+	}
+}
+
+// GetUpcomingShows returns the upcoming shows the current user is going to or interested in
+func (h *Handler) GetUpcomingShows(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	statuses := []models.AttendanceStatus{models.AttendanceStatusGoing, models.AttendanceStatusInterested}
+	if status := c.Query("status"); status != "" {
+		if status != string(models.AttendanceStatusGoing) && status != string(models.AttendanceStatusInterested) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "status must be going or interested"})
+			return
+		}
+		statuses = []models.AttendanceStatus{models.AttendanceStatus(status)}
+	}
+
+	now := time.Now()
+	filter := repository.AttendanceFilter{UserID: userID, Statuses: statuses, ShowFrom: &now}
+
+	// Soonest first
+	attendances, ok := paginate(c, attendanceListOptions("asc"), func(page repository.PageRequest) (repository.Page[models.ShowAttendance], error) {
+		return h.Attendances.List(c.Request.Context(), filter, page)
+	})
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, attendances)
+}
+
+// GetShowHistory returns the current user's attendance records for shows that already happened
+func (h *Handler) GetShowHistory(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	now := time.Now()
+	filter := repository.AttendanceFilter{UserID: userID, ShowBefore: &now}
+
+	// Filter by status
+	if status := c.Query("status"); status != "" {
+		if !isAttendanceStatus(status) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "status must be one of attended, interested, going or missed"})
+			return
+		}
+		filter.Statuses = []models.AttendanceStatus{models.AttendanceStatus(status)}
+	}
+
+	// Most recent first
+	attendances, ok := paginate(c, attendanceListOptions("desc"), func(page repository.PageRequest) (repository.Page[models.ShowAttendance], error) {
+		return h.Attendances.List(c.Request.Context(), filter, page)
+	})
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, attendances)
+}
+
+// parseAttendanceStatus maps a request status to the model value, defaulting to attended
+func parseAttendanceStatus(status string) models.AttendanceStatus {
+	if status == "" {
+		return models.AttendanceStatusAttended
+	}
+	return models.AttendanceStatus(status)
+}
+
+func isAttendanceStatus(status string) bool {
+	switch models.AttendanceStatus(status) {
+	case models.AttendanceStatusAttended, models.AttendanceStatusInterested,
+		models.AttendanceStatusGoing, models.AttendanceStatusMissed:
+		return true
+	}
+	return false
+}
+
+// validateAttendanceRating blocks ratings on going/interested entries until the show has happened
+func validateAttendanceRating(status models.AttendanceStatus, rating *float64, show models.Show) error {
+	if rating == nil {
+		return nil
+	}
+
+	if (status == models.AttendanceStatusGoing || status == models.AttendanceStatusInterested) && show.Date.After(time.Now()) {
+		return errors.New("Shows you are going to or interested in cannot be rated until the show date has passed")
+	}
+
+	return nil
 }
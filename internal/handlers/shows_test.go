@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"jam-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newShowTestServer adds goose and phish and a venue in Denver
+func newShowTestServer(t *testing.T) *testServer {
+	s := newTestServer(t)
+	s.do(http.MethodPost, "/api/v1/bands", gin.H{"band_name": "goose"}, http.StatusCreated)
+	s.do(http.MethodPost, "/api/v1/bands", gin.H{"band_name": "phish"}, http.StatusCreated)
+	s.do(http.MethodPost, "/api/v1/venues", gin.H{"name": "Red Rocks", "city": "Denver", "state": "CO"}, http.StatusCreated)
+	return s
+}
+
+func TestCreateShowUsesVenueLocalDate(t *testing.T) {
+	s := newShowTestServer(t)
+
+	// 21:00 in Denver is already the next day in UTC
+	show := decode[models.Show](t, s.do(http.MethodPost, "/api/v1/shows",
+		gin.H{"band_name": "goose", "venue_name": "red rocks", "date": "2025-09-10 21:00"}, http.StatusCreated))
+
+	if show.LocalDate != "2025-09-10" {
+		t.Errorf("local_date = %q, want 2025-09-10", show.LocalDate)
+	}
+	if want := time.Date(2025, 9, 11, 3, 0, 0, 0, time.UTC); !show.Date.Equal(want) {
+		t.Errorf("date = %v, want %v", show.Date, want)
+	}
+	if len(show.Performances) != 1 || show.Performances[0].Band.Name != "goose" {
+		t.Errorf("performances = %+v, want goose alone", show.Performances)
+	}
+
+	s.do(http.MethodPost, "/api/v1/shows", gin.H{"band_name": "goose", "venue_name": "red rocks", "date": "9/10/2025"}, http.StatusBadRequest)
+	s.do(http.MethodPost, "/api/v1/shows", gin.H{"band_name": "dead", "venue_name": "red rocks", "date": "2025-09-10"}, http.StatusNotFound)
+	s.do(http.MethodPost, "/api/v1/shows", gin.H{"band_name": "goose", "venue_name": "msg", "date": "2025-09-10"}, http.StatusNotFound)
+}
+
+func TestCreateShowBill(t *testing.T) {
+	s := newShowTestServer(t)
+
+	show := decode[models.Show](t, s.do(http.MethodPost, "/api/v1/shows", gin.H{
+		"venue_name": "red rocks",
+		"date":       "2025-09-10",
+		"performances": []gin.H{
+			{"band_name": "phish", "set_start": "21:00", "set_end": "00:30"},
+			{"band_name": "goose", "set_start": "19:00", "set_end": "20:15"},
+		},
+	}, http.StatusCreated))
+
+	if len(show.Performances) != 2 {
+		t.Fatalf("performances = %+v, want two", show.Performances)
+	}
+	headliner, opener := show.Performances[0], show.Performances[1]
+	if headliner.Band.Name != "phish" || headliner.Position != 1 || show.BandID != headliner.BandID {
+		t.Errorf("headliner = %s at %d, want phish at 1 as the show band", headliner.Band.Name, headliner.Position)
+	}
+	if opener.Band.Name != "goose" || opener.Position != 2 {
+		t.Errorf("opener = %s at %d, want goose at 2", opener.Band.Name, opener.Position)
+	}
+
+	// The headliner's set runs past midnight
+	if headliner.SetStart == nil || headliner.SetEnd == nil || headliner.SetEnd.Sub(*headliner.SetStart) != 3*time.Hour+30*time.Minute {
+		t.Errorf("headliner set = %v to %v, want 3h30m", headliner.SetStart, headliner.SetEnd)
+	}
+
+	// A band on the bill cannot play the venue twice that day
+	rec := s.do(http.MethodPost, "/api/v1/shows", gin.H{"band_name": "goose", "venue_name": "red rocks", "date": "2025-09-10 23:00"}, http.StatusConflict)
+	if band := decode[map[string]any](t, rec)["band_name"]; band != "goose" {
+		t.Errorf("conflicting band = %v, want goose", band)
+	}
+
+	s.do(http.MethodPost, "/api/v1/shows", gin.H{
+		"venue_name":   "red rocks",
+		"date":         "2025-09-11",
+		"performances": []gin.H{{"band_name": "goose"}, {"band_name": "Goose"}},
+	}, http.StatusBadRequest)
+
+	s.do(http.MethodPost, "/api/v1/shows", gin.H{
+		"band_name":    "goose",
+		"venue_name":   "red rocks",
+		"date":         "2025-09-11",
+		"performances": []gin.H{{"band_name": "phish"}, {"band_name": "goose"}},
+	}, http.StatusBadRequest)
+}
+
+func TestGetShowsMatchesAnyBandOnTheBill(t *testing.T) {
+	s := newShowTestServer(t)
+	s.do(http.MethodPost, "/api/v1/shows", gin.H{
+		"venue_name":   "red rocks",
+		"date":         "2025-09-10",
+		"performances": []gin.H{{"band_name": "phish"}, {"band_name": "goose"}},
+	}, http.StatusCreated)
+	s.do(http.MethodPost, "/api/v1/shows", gin.H{"band_name": "phish", "venue_name": "red rocks", "date": "2025-09-12"}, http.StatusCreated)
+
+	tests := []struct {
+		query string
+		want  []string // Local dates, most recent first
+	}{
+		{"band=goose", []string{"2025-09-10"}},
+		{"band=phish", []string{"2025-09-12", "2025-09-10"}},
+		{"band=phish&date_to=2025-09-11", []string{"2025-09-10"}},
+		{"state=co&date_from=2025-09-11", []string{"2025-09-12"}},
+	}
+	for _, tt := range tests {
+		shows := decode[[]models.Show](t, s.do(http.MethodGet, "/api/v1/shows?"+tt.query, nil, http.StatusOK))
+
+		dates := make([]string, len(shows))
+		for i, show := range shows {
+			dates[i] = show.LocalDate
+		}
+		if len(dates) != len(tt.want) {
+			t.Errorf("?%s = %v, want %v", tt.query, dates, tt.want)
+			continue
+		}
+		for i := range dates {
+			if dates[i] != tt.want[i] {
+				t.Errorf("?%s = %v, want %v", tt.query, dates, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestAttendShowCaughtBands(t *testing.T) {
+	s := newShowTestServer(t)
+	show := decode[models.Show](t, s.do(http.MethodPost, "/api/v1/shows", gin.H{
+		"venue_name":   "red rocks",
+		"date":         "2025-09-10",
+		"performances": []gin.H{{"band_name": "phish"}, {"band_name": "goose"}},
+	}, http.StatusCreated))
+	path := "/api/v1/shows/" + show.ID.String() + "/attend"
+
+	s.do(http.MethodPost, path, gin.H{"caught_bands": []string{"dead"}}, http.StatusBadRequest)
+	s.do(http.MethodPost, path, gin.H{"status": "missed", "caught_bands": []string{"goose"}}, http.StatusBadRequest)
+
+	attendance := decode[models.ShowAttendance](t, s.do(http.MethodPost, path,
+		gin.H{"rating": 4.5, "caught_bands": []string{"Goose"}}, http.StatusCreated))
+	if attendance.Status != models.AttendanceStatusAttended {
+		t.Errorf("status = %q, want attended", attendance.Status)
+	}
+	if len(attendance.CaughtPerformances) != 1 || attendance.CaughtPerformances[0].Band.Name != "goose" {
+		t.Errorf("caught = %+v, want goose", attendance.CaughtPerformances)
+	}
+
+	s.do(http.MethodPost, path, gin.H{}, http.StatusConflict)
+
+	// Leaving caught_bands out keeps them, and a show not attended catches none
+	updatePath := "/api/v1/attendances/" + attendance.ID.String()
+	attendance = decode[models.ShowAttendance](t, s.do(http.MethodPut, updatePath, gin.H{"notes": "Great jam"}, http.StatusOK))
+	if len(attendance.CaughtPerformances) != 1 {
+		t.Errorf("caught after update = %+v, want goose kept", attendance.CaughtPerformances)
+	}
+
+	attendance = decode[models.ShowAttendance](t, s.do(http.MethodPut, updatePath, gin.H{"status": "missed"}, http.StatusOK))
+	if len(attendance.CaughtPerformances) != 0 {
+		t.Errorf("caught after missing the show = %+v, want none", attendance.CaughtPerformances)
+	}
+}
+
+func TestUpcomingShowsAndHistory(t *testing.T) {
+	s := newShowTestServer(t)
+	past := decode[models.Show](t, s.do(http.MethodPost, "/api/v1/shows",
+		gin.H{"band_name": "goose", "venue_name": "red rocks", "date": "2025-09-10"}, http.StatusCreated))
+	future := decode[models.Show](t, s.do(http.MethodPost, "/api/v1/shows",
+		gin.H{"band_name": "goose", "venue_name": "red rocks", "date": time.Now().AddDate(0, 1, 0).Format("2006-01-02")}, http.StatusCreated))
+
+	// Future shows cannot be rated yet
+	s.do(http.MethodPost, "/api/v1/shows/"+future.ID.String()+"/attend", gin.H{"status": "going", "rating": 5}, http.StatusBadRequest)
+
+	s.do(http.MethodPost, "/api/v1/shows/"+past.ID.String()+"/attend", gin.H{}, http.StatusCreated)
+	s.do(http.MethodPost, "/api/v1/shows/"+future.ID.String()+"/attend", gin.H{"status": "going"}, http.StatusCreated)
+
+	upcoming := decode[[]models.ShowAttendance](t, s.do(http.MethodGet, "/api/v1/profile/upcoming", nil, http.StatusOK))
+	if len(upcoming) != 1 || upcoming[0].ShowID != future.ID {
+		t.Errorf("upcoming = %+v, want the future show", upcoming)
+	}
+
+	history := decode[[]models.ShowAttendance](t, s.do(http.MethodGet, "/api/v1/profile/history", nil, http.StatusOK))
+	if len(history) != 1 || history[0].ShowID != past.ID {
+		t.Errorf("history = %+v, want the past show", history)
+	}
+
+	s.do(http.MethodGet, "/api/v1/profile/upcoming?status=attended", nil, http.StatusBadRequest)
+}
+
+func TestParseShowDate(t *testing.T) {
+	venue := models.Venue{Timezone: "America/Denver"}
+	tests := []struct {
+		value  string
+		want   time.Time
+		wantOK bool
+	}{
+		{"2025-09-10", time.Date(2025, 9, 10, 6, 0, 0, 0, time.UTC), true},
+		{"2025-09-10 21:00", time.Date(2025, 9, 11, 3, 0, 0, 0, time.UTC), true},
+		{"2025-01-10 21:00", time.Date(2025, 1, 11, 4, 0, 0, 0, time.UTC), true}, // Standard time
+		{"2025-09-10T21:00:00+02:00", time.Date(2025, 9, 10, 19, 0, 0, 0, time.UTC), true},
+		{"2025-09-10T21:00", time.Time{}, false},
+		{"9/10/2025", time.Time{}, false},
+		{"", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseShowDate(tt.value, venue)
+		if ok != tt.wantOK || !got.Equal(tt.want) {
+			t.Errorf("parseShowDate(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,328 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"jam-tracker/internal/database"
+	"jam-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// A song is a bust-out when the band played more shows than this since the previous time
+	defaultBustOutGap = 50
+	maxBustOutGap     = 1000
+)
+
+// SongPlay is one show a song was played at
+type SongPlay struct {
+	Show    models.Show `json:"show"`
+	Gap     *int        `json:"gap"` // Band shows since the previous play, null for the debut
+	BustOut bool        `json:"bust_out"`
+}
+
+// SongStats describes how often the current user has seen a song and how long the band has gone without it
+type SongStats struct {
+	Song         models.Song  `json:"song"`
+	Band         models.Band  `json:"band"`
+	TimesSeen    int          `json:"times_seen"`
+	FirstSeen    *models.Show `json:"first_seen"`
+	LastSeen     *models.Show `json:"last_seen"`
+	BustOutsSeen int          `json:"bust_outs_seen"`
+	TimesPlayed  int          `json:"times_played"`
+	Gap          int          `json:"gap"` // Band shows since the song was last played
+}
+
+type SongDetailResponse struct {
+	SongStats
+	FirstPlayed *models.Show `json:"first_played"`
+	LastPlayed  *models.Show `json:"last_played"`
+	Seen        []SongPlay   `json:"seen"`      // Plays the current user attended
+	BustOuts    []SongPlay   `json:"bust_outs"` // Every bust-out of the song, seen or not
+}
+
+// songHistory holds the sets of one band in date order
+type songHistory struct {
+	shows []models.Show             // Shows with a set of the band, oldest first
+	plays map[uuid.UUID][]int       // Song ID to the indexes in shows it was played at
+	songs map[uuid.UUID]models.Song // Songs played by the band
+	index map[uuid.UUID]int         // Show ID to its index in shows
+	band  models.Band
+}
+
+// GetProfileSongs returns every song the current user has seen live, most seen first.
+// It accepts ?band= to limit the list to one band and ?bustout_gap= to tune bust-outs.
+func (h *Handler) GetProfileSongs(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	bustOutGap, ok := parseBustOutGap(c)
+	if !ok {
+		return
+	}
+
+	// Find the bands that played a set at an attended show, openers included
+	query := h.DB.Model(&models.Set{}).
+		Joins("JOIN show_attendances ON show_attendances.show_id = sets.show_id").
+		Where("show_attendances.user_id = ? AND show_attendances.status = ?", userID, models.AttendanceStatusAttended)
+
+	if bandName := c.Query("band"); bandName != "" {
+		normalizedBandName := strings.ToLower(strings.TrimSpace(strings.ReplaceAll(bandName, "-", " ")))
+		query = query.Joins("JOIN bands ON bands.id = sets.band_id").Where("bands.name = ?", normalizedBandName)
+	}
+
+	var bandIDs []uuid.UUID
+	if err := query.Distinct("sets.band_id").Pluck("sets.band_id", &bandIDs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attended shows"})
+		return
+	}
+
+	songs := []SongStats{}
+	for _, bandID := range bandIDs {
+		seenShows, err := loadSeenShows(h.DB, userID, bandID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attended shows"})
+			return
+		}
+
+		history, err := loadSongHistory(h.DB, bandID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch setlists"})
+			return
+		}
+
+		for songID := range history.plays {
+			stats := history.stats(songID, seenShows, bustOutGap)
+			if stats.TimesSeen > 0 {
+				songs = append(songs, stats)
+			}
+		}
+	}
+
+	sort.Slice(songs, func(i, j int) bool {
+		if songs[i].TimesSeen != songs[j].TimesSeen {
+			return songs[i].TimesSeen > songs[j].TimesSeen
+		}
+		if songs[i].Band.Name != songs[j].Band.Name {
+			return songs[i].Band.Name < songs[j].Band.Name
+		}
+		return songs[i].Song.Name < songs[j].Song.Name
+	})
+
+	c.JSON(http.StatusOK, songs)
+}
+
+// GetBandSong returns the play history of one song and how often the current user has seen it
+func (h *Handler) GetBandSong(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	bustOutGap, ok := parseBustOutGap(c)
+	if !ok {
+		return
+	}
+
+	bandName := c.Param("id")
+	normalizedBandName := strings.ToLower(strings.TrimSpace(strings.ReplaceAll(bandName, "-", " ")))
+
+	// Find the band
+	band, err := h.Bands.FindByName(c.Request.Context(), normalizedBandName)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Band not found"})
+		return
+	}
+
+	songName := c.Param("song")
+	normalizedSongName := strings.ToLower(strings.TrimSpace(strings.ReplaceAll(songName, "-", " ")))
+
+	// Find the song
+	var song models.Song
+	if err := h.DB.Where("band_id = ? AND name = ?", band.ID, normalizedSongName).First(&song).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Song not found"})
+		return
+	}
+
+	history, err := loadSongHistory(h.DB, band.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch setlists"})
+		return
+	}
+
+	seenShows, err := loadSeenShows(h.DB, userID, band.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attended shows"})
+		return
+	}
+
+	response := SongDetailResponse{
+		SongStats: history.stats(song.ID, seenShows, bustOutGap),
+		Seen:      []SongPlay{},
+		BustOuts:  []SongPlay{},
+	}
+	response.Song = song
+
+	plays := history.songPlays(song.ID, bustOutGap)
+	if len(plays) > 0 {
+		response.FirstPlayed = &plays[0].Show
+		response.LastPlayed = &plays[len(plays)-1].Show
+	}
+
+	for _, play := range plays {
+		if seenShows[play.Show.ID] {
+			response.Seen = append(response.Seen, play)
+		}
+		if play.BustOut {
+			response.BustOuts = append(response.BustOuts, play)
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func parseBustOutGap(c *gin.Context) (int, bool) {
+	gapParam := c.Query("bustout_gap")
+	if gapParam == "" {
+		return defaultBustOutGap, true
+	}
+
+	gap, err := strconv.Atoi(gapParam)
+	if err != nil || gap < 1 || gap > maxBustOutGap {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("bustout_gap must be between 1 and %d", maxBustOutGap)})
+		return 0, false
+	}
+	return gap, true
+}
+
+// loadSeenShows returns the IDs of the attended shows where the user saw the band:
+// its set was caught, or no sets were recorded and the band headlined
+func loadSeenShows(db *gorm.DB, userID, bandID uuid.UUID) (map[uuid.UUID]bool, error) {
+	caughtBand := db.Table("caught_performances").
+		Joins("JOIN show_performances ON show_performances.id = caught_performances.performance_id").
+		Where("show_performances.band_id = ?", bandID).
+		Select("caught_performances.attendance_id")
+	caughtAny := db.Table("caught_performances").Select("attendance_id")
+
+	var showIDs []uuid.UUID
+	if err := db.Model(&models.ShowAttendance{}).
+		Joins("JOIN shows ON shows.id = show_attendances.show_id").
+		Where("show_attendances.user_id = ? AND show_attendances.status = ?", userID, models.AttendanceStatusAttended).
+		Where("show_attendances.id IN (?) OR (show_attendances.id NOT IN (?) AND shows.band_id = ?)", caughtBand, caughtAny, bandID).
+		Pluck("show_attendances.show_id", &showIDs).Error; err != nil {
+		return nil, err
+	}
+
+	seen := make(map[uuid.UUID]bool, len(showIDs))
+	for _, showID := range showIDs {
+		seen[showID] = true
+	}
+	return seen, nil
+}
+
+// loadSongHistory loads every set the band played, headlining or not. Shows
+// without a set of the band are left out so they don't count towards gaps.
+func loadSongHistory(db *gorm.DB, bandID uuid.UUID) (*songHistory, error) {
+	history := &songHistory{
+		plays: make(map[uuid.UUID][]int),
+		songs: make(map[uuid.UUID]models.Song),
+		index: make(map[uuid.UUID]int),
+	}
+
+	if err := db.Where("id = ?", bandID).First(&history.band).Error; err != nil {
+		return nil, err
+	}
+
+	if err := db.Preload("Band").Preload("Venue").
+		Where("id IN (?)", db.Model(&models.Set{}).Select("show_id").Where("band_id = ?", bandID)).
+		Order(database.DialectOf(db).Time("date") + " ASC, id ASC").
+		Find(&history.shows).Error; err != nil {
+		return nil, err
+	}
+
+	for i, show := range history.shows {
+		history.index[show.ID] = i
+	}
+
+	var entries []models.SetlistEntry
+	if err := db.Preload("Song").
+		Joins("JOIN sets ON sets.id = setlist_entries.set_id").
+		Where("sets.band_id = ?", bandID).
+		Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	// A song played twice in one show counts once
+	played := make(map[uuid.UUID]map[int]bool)
+	for _, entry := range entries {
+		i, ok := history.index[entry.ShowID]
+		if !ok {
+			continue
+		}
+		if played[entry.SongID] == nil {
+			played[entry.SongID] = make(map[int]bool)
+			history.songs[entry.SongID] = entry.Song
+		}
+		if !played[entry.SongID][i] {
+			played[entry.SongID][i] = true
+			history.plays[entry.SongID] = append(history.plays[entry.SongID], i)
+		}
+	}
+
+	for songID := range history.plays {
+		sort.Ints(history.plays[songID])
+	}
+
+	return history, nil
+}
+
+// songPlays lists every show the song was played at with the gap since the previous one
+func (h *songHistory) songPlays(songID uuid.UUID, bustOutGap int) []SongPlay {
+	indexes := h.plays[songID]
+	plays := make([]SongPlay, 0, len(indexes))
+	for n, i := range indexes {
+		play := SongPlay{Show: h.shows[i]}
+		if n > 0 {
+			gap := i - indexes[n-1] - 1
+			play.Gap = &gap
+			play.BustOut = gap > bustOutGap
+		}
+		plays = append(plays, play)
+	}
+	return plays
+}
+
+func (h *songHistory) stats(songID uuid.UUID, seenShows map[uuid.UUID]bool, bustOutGap int) SongStats {
+	stats := SongStats{
+		Song:        h.songs[songID],
+		Band:        h.band,
+		TimesPlayed: len(h.plays[songID]),
+		Gap:         len(h.shows),
+	}
+
+	plays := h.songPlays(songID, bustOutGap)
+	for i := range plays {
+		play := plays[i]
+		if !seenShows[play.Show.ID] {
+			continue
+		}
+
+		stats.TimesSeen++
+		if stats.FirstSeen == nil {
+			stats.FirstSeen = &play.Show
+		}
+		stats.LastSeen = &play.Show
+		if play.BustOut {
+			stats.BustOutsSeen++
+		}
+	}
+
+	if len(plays) > 0 {
+		stats.Gap = len(h.shows) - h.index[plays[len(plays)-1].Show.ID] - 1
+	}
+
+	return stats
+}
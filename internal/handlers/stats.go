@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"jam-tracker/internal/database"
+	"jam-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultStatsTop = 10
+	maxStatsTop     = 50
+)
+
+type PeriodCount struct {
+	Period string `json:"period"` // "2024" for years, "2024-07" for months
+	Count  int    `json:"count"`
+}
+
+type NamedCount struct {
+	ID    uuid.UUID `json:"id"`
+	Name  string    `json:"name"`
+	Count int       `json:"count"`
+}
+
+type ShowGap struct {
+	Days int         `json:"days"`
+	From models.Show `json:"from"`
+	To   models.Show `json:"to"`
+}
+
+type ProfileStatsResponse struct {
+	TotalShows    int           `json:"total_shows"`
+	ShowsPerYear  []PeriodCount `json:"shows_per_year"`
+	ShowsPerMonth []PeriodCount `json:"shows_per_month"`
+	TopBands      []NamedCount  `json:"top_bands"` // Counts the sets caught, or the headliner when none are recorded
+	TopVenues     []NamedCount  `json:"top_venues"`
+	States        []string      `json:"states"`
+	Cities        []string      `json:"cities"` // "city, state"
+	RatedShows    int           `json:"rated_shows"`
+	AverageRating *float64      `json:"average_rating"`
+	LongestGap    *ShowGap      `json:"longest_gap"`
+}
+
+// GetProfileStats returns statistics about the shows the current user attended.
+// It accepts the same date_from/date_to filters as GetShows.
+func (h *Handler) GetProfileStats(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	top := defaultStatsTop
+	if topParam := c.Query("top"); topParam != "" {
+		parsed, err := strconv.Atoi(topParam)
+		if err != nil || parsed < 1 || parsed > maxStatsTop {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("top must be between 1 and %d", maxStatsTop)})
+			return
+		}
+		top = parsed
+	}
+
+	query := h.DB.Preload("Show.Band").Preload("Show.Venue").Preload("CaughtPerformances.Band").
+		Joins("JOIN shows ON shows.id = show_attendances.show_id").
+		Where("show_attendances.user_id = ? AND show_attendances.status = ?", userID, models.AttendanceStatusAttended)
+	query = filterShowDates(query, c.Query("date_from"), c.Query("date_to"))
+
+	var attendances []models.ShowAttendance
+	if err := query.Order(database.DialectOf(h.DB).Time("shows.date") + " ASC").Find(&attendances).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attendance records"})
+		return
+	}
+
+	c.JSON(http.StatusOK, computeProfileStats(attendances, top))
+}
+
+// computeProfileStats aggregates attendances that are sorted by show date
+func computeProfileStats(attendances []models.ShowAttendance, top int) ProfileStatsResponse {
+	stats := ProfileStatsResponse{
+		TotalShows:    len(attendances),
+		ShowsPerYear:  []PeriodCount{},
+		ShowsPerMonth: []PeriodCount{},
+		TopBands:      []NamedCount{},
+		TopVenues:     []NamedCount{},
+		States:        []string{},
+		Cities:        []string{},
+	}
+
+	years := make(map[string]int)
+	months := make(map[string]int)
+	bands := make(map[uuid.UUID]*NamedCount)
+	venues := make(map[uuid.UUID]*NamedCount)
+	states := make(map[string]bool)
+	cities := make(map[string]bool)
+	var ratingSum float64
+
+	for i, attendance := range attendances {
+		show := attendance.Show
+
+		// By the calendar day at the venue, LocalDate being YYYY-MM-DD
+		years[show.LocalDate[:4]]++
+		months[show.LocalDate[:7]]++
+
+		seen := attendance.CaughtPerformances
+		if len(seen) == 0 {
+			seen = []models.ShowPerformance{{BandID: show.BandID, Band: show.Band}}
+		}
+		for _, performance := range seen {
+			if bands[performance.BandID] == nil {
+				bands[performance.BandID] = &NamedCount{ID: performance.BandID, Name: performance.Band.Name}
+			}
+			bands[performance.BandID].Count++
+		}
+
+		if venues[show.VenueID] == nil {
+			venues[show.VenueID] = &NamedCount{ID: show.VenueID, Name: show.Venue.Name}
+		}
+		venues[show.VenueID].Count++
+
+		if show.Venue.State != "" {
+			states[show.Venue.State] = true
+		}
+		if show.Venue.City != "" {
+			cities[fmt.Sprintf("%s, %s", show.Venue.City, show.Venue.State)] = true
+		}
+
+		if attendance.Rating != nil {
+			ratingSum += *attendance.Rating
+			stats.RatedShows++
+		}
+
+		// Gap since the previous show
+		if i > 0 {
+			previous := attendances[i-1].Show
+			days := int(show.Date.Sub(previous.Date).Hours() / 24)
+			if stats.LongestGap == nil || days > stats.LongestGap.Days {
+				stats.LongestGap = &ShowGap{Days: days, From: previous, To: show}
+			}
+		}
+	}
+
+	stats.ShowsPerYear = sortedPeriodCounts(years)
+	stats.ShowsPerMonth = sortedPeriodCounts(months)
+	stats.TopBands = topNamedCounts(bands, top)
+	stats.TopVenues = topNamedCounts(venues, top)
+	stats.States = sortedKeys(states)
+	stats.Cities = sortedKeys(cities)
+
+	if stats.RatedShows > 0 {
+		average := math.Round(ratingSum/float64(stats.RatedShows)*100) / 100
+		stats.AverageRating = &average
+	}
+
+	return stats
+}
+
+func sortedPeriodCounts(counts map[string]int) []PeriodCount {
+	periods := make([]PeriodCount, 0, len(counts))
+	for period, count := range counts {
+		periods = append(periods, PeriodCount{Period: period, Count: count})
+	}
+	sort.Slice(periods, func(i, j int) bool {
+		return periods[i].Period < periods[j].Period
+	})
+	return periods
+}
+
+// topNamedCounts returns the most frequent entries, breaking ties by name
+func topNamedCounts(counts map[uuid.UUID]*NamedCount, top int) []NamedCount {
+	named := make([]NamedCount, 0, len(counts))
+	for _, count := range counts {
+		named = append(named, *count)
+	}
+	sort.Slice(named, func(i, j int) bool {
+		if named[i].Count != named[j].Count {
+			return named[i].Count > named[j].Count
+		}
+		return named[i].Name < named[j].Name
+	})
+	if len(named) > top {
+		named = named[:top]
+	}
+	return named
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
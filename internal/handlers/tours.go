@@ -0,0 +1,562 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"jam-tracker/internal/database"
+	"jam-tracker/internal/geo"
+	"jam-tracker/internal/models"
+	"jam-tracker/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultTourMaxGapDays = 7 // Days off between two shows that still count as one tour
+	defaultTourMinShows   = 3 // Shorter runs are one-offs, not tours
+)
+
+type CreateTourRequest struct {
+	BandName    string   `json:"band_name" binding:"required,min=1"`
+	Name        string   `json:"name" binding:"required,min=1,max=200"` // e.g. "Fall Tour 2025"
+	Description string   `json:"description" binding:"omitempty,max=1000"`
+	ShowIDs     []string `json:"show_ids" binding:"omitempty,max=500,dive,uuid"`
+}
+
+type UpdateTourRequest struct {
+	Name        string   `json:"name" binding:"required,min=1,max=200"`
+	Description string   `json:"description" binding:"omitempty,max=1000"`
+	ShowIDs     []string `json:"show_ids" binding:"omitempty,max=500,dive,uuid"` // Left out keeps the shows
+}
+
+type GroupToursRequest struct {
+	BandName   string `json:"band_name" binding:"required,min=1"`
+	MaxGapDays int    `json:"max_gap_days" binding:"omitempty,min=1,max=60"` // Defaults to 7
+	MinShows   int    `json:"min_shows" binding:"omitempty,min=1,max=100"`   // Defaults to 3
+}
+
+// TourStop is one show of a tour on its route
+type TourStop struct {
+	Position   int                     `json:"position"`
+	ShowID     uuid.UUID               `json:"show_id"`
+	Date       time.Time               `json:"date"`
+	LocalDate  string                  `json:"local_date"`
+	VenueID    uuid.UUID               `json:"venue_id"`
+	VenueName  string                  `json:"venue_name"`
+	City       string                  `json:"city"`
+	State      string                  `json:"state"`
+	Country    string                  `json:"country"`
+	Latitude   *float64                `json:"latitude"`
+	Longitude  *float64                `json:"longitude"`
+	DistanceKm *float64                `json:"distance_km"`      // From the previous stop with coordinates
+	Status     models.AttendanceStatus `json:"status,omitempty"` // The current user's attendance
+}
+
+// TourCoverage is how much of a tour the current user saw
+type TourCoverage struct {
+	Shows    int     `json:"shows"`
+	Attended int     `json:"attended"`
+	Going    int     `json:"going"`
+	Percent  float64 `json:"percent"` // Of the shows, attended
+	Summary  string  `json:"summary"` // e.g. "Fall Tour 2025: 24 dates, you saw 5"
+}
+
+type TourResponse struct {
+	models.Tour
+	Route      []TourStop   `json:"route"`
+	DistanceKm float64      `json:"distance_km"` // Along the route, between stops with coordinates
+	Coverage   TourCoverage `json:"coverage"`
+}
+
+// tourListOptions are the sorts GetTours accepts
+var tourListOptions = ListOptions[models.Tour]{
+	Spec: repository.SortSpec[models.Tour]{
+		Fields: map[string]repository.SortField[models.Tour]{
+			"created_at": {Column: "created_at", Type: repository.SortTime, Value: func(t models.Tour) any { return t.CreatedAt }},
+			"name":       {Column: "name", Type: repository.SortText, Value: func(t models.Tour) any { return t.Name }},
+		},
+		IDColumn: "id",
+		ID:       func(t models.Tour) uuid.UUID { return t.ID },
+	},
+	DefaultSort:  "created_at",
+	DefaultOrder: "desc", // Newest first
+}
+
+func (h *Handler) CreateTour(c *gin.Context) {
+	var req CreateTourRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	band, err := h.Bands.FindByName(c.Request.Context(), strings.ToLower(strings.TrimSpace(req.BandName)))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Band not found"})
+		return
+	}
+
+	tour := models.Tour{
+		BandID:      band.ID,
+		Name:        strings.TrimSpace(req.Name),
+		Description: strings.TrimSpace(req.Description),
+		Band:        band,
+	}
+
+	showIDs, ok := h.findTourShows(c, tour, req.ShowIDs)
+	if !ok {
+		return
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Band", "Shows").Create(&tour).Error; err != nil {
+			return err
+		}
+		return saveTourShows(tx, tour, showIDs)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tour"})
+		return
+	}
+
+	if err := h.loadTourShows(&tour); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tour shows"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, tour)
+}
+
+// GroupTours puts the shows of a band that are on none of its tours into new
+// tours. Shows at most max_gap_days apart are one run, and runs of at least
+// min_shows become tours named after the season they start in.
+func (h *Handler) GroupTours(c *gin.Context) {
+	var req GroupToursRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	maxGapDays := req.MaxGapDays
+	if maxGapDays == 0 {
+		maxGapDays = defaultTourMaxGapDays
+	}
+	minShows := req.MinShows
+	if minShows == 0 {
+		minShows = defaultTourMinShows
+	}
+
+	band, err := h.Bands.FindByName(c.Request.Context(), strings.ToLower(strings.TrimSpace(req.BandName)))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Band not found"})
+		return
+	}
+
+	tours := []models.Tour{}
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the band, so shows picked here cannot be put on another of its tours meanwhile
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", band.ID).First(&models.Band{}).Error; err != nil {
+			return err
+		}
+
+		var shows []models.Show
+		if err := tx.Where("id IN (?)", tx.Model(&models.ShowPerformance{}).Select("show_id").Where("band_id = ?", band.ID)).
+			Where("id NOT IN (?)", tx.Model(&models.TourShow{}).Select("show_id").Where("band_id = ?", band.ID)).
+			Order("local_date").Order(database.DialectOf(tx).Time("date")).
+			Find(&shows).Error; err != nil {
+			return err
+		}
+
+		var existingNames []string
+		if err := tx.Model(&models.Tour{}).Where("band_id = ?", band.ID).Pluck("name", &existingNames).Error; err != nil {
+			return err
+		}
+		takenNames := make(map[string]bool, len(existingNames))
+		for _, name := range existingNames {
+			takenNames[strings.ToLower(name)] = true
+		}
+
+		for _, run := range groupShowRuns(shows, maxGapDays) {
+			if len(run) < minShows {
+				continue
+			}
+
+			tour := models.Tour{
+				BandID: band.ID,
+				Name:   tourName(run[0].LocalDate, takenNames),
+				Band:   band,
+			}
+			takenNames[strings.ToLower(tour.Name)] = true
+
+			showIDs := make([]uuid.UUID, len(run))
+			for i, show := range run {
+				showIDs[i] = show.ID
+			}
+
+			if err := tx.Omit("Band", "Shows").Create(&tour).Error; err != nil {
+				return err
+			}
+			if err := saveTourShows(tx, tour, showIDs); err != nil {
+				return err
+			}
+			tours = append(tours, tour)
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tours"})
+		return
+	}
+
+	for i := range tours {
+		if err := h.loadTourShows(&tours[i]); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tour shows"})
+			return
+		}
+	}
+
+	if len(tours) == 0 {
+		c.JSON(http.StatusOK, tours)
+		return
+	}
+	c.JSON(http.StatusCreated, tours)
+}
+
+// GetTours lists tours, optionally filtered by ?band=
+func (h *Handler) GetTours(c *gin.Context) {
+	query := h.DB.Model(&models.Tour{}).Preload("Band")
+	if bandName := strings.ToLower(strings.TrimSpace(c.Query("band"))); bandName != "" {
+		query = query.Where("band_id IN (?)", h.DB.Model(&models.Band{}).Select("id").Where("name = ?", bandName))
+	}
+
+	tours, ok := paginateQuery(c, query, tourListOptions)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, tours)
+}
+
+// GetTour returns a tour with its shows in date order, the route between their
+// venues, and how much of it the current user saw
+func (h *Handler) GetTour(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	tour, ok := h.findTour(c)
+	if !ok {
+		return
+	}
+
+	if err := h.loadTourShows(&tour); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tour shows"})
+		return
+	}
+
+	showIDs := make([]uuid.UUID, len(tour.Shows))
+	for i, show := range tour.Shows {
+		showIDs[i] = show.ID
+	}
+
+	var attendances []models.ShowAttendance
+	if len(showIDs) > 0 {
+		if err := h.DB.Where("user_id = ? AND show_id IN ?", userID, showIDs).Find(&attendances).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attendance"})
+			return
+		}
+	}
+	statuses := make(map[uuid.UUID]models.AttendanceStatus, len(attendances))
+	for _, attendance := range attendances {
+		statuses[attendance.ShowID] = attendance.Status
+	}
+
+	c.JSON(http.StatusOK, buildTourResponse(tour, statuses))
+}
+
+func (h *Handler) UpdateTour(c *gin.Context) {
+	var req UpdateTourRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tour, ok := h.findTour(c)
+	if !ok {
+		return
+	}
+
+	var showIDs []uuid.UUID
+	if req.ShowIDs != nil {
+		showIDs, ok = h.findTourShows(c, tour, req.ShowIDs)
+		if !ok {
+			return
+		}
+	}
+
+	tour.Name = strings.TrimSpace(req.Name)
+	tour.Description = strings.TrimSpace(req.Description)
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Band", "Shows").Save(&tour).Error; err != nil {
+			return err
+		}
+		if req.ShowIDs == nil {
+			return nil
+		}
+		return saveTourShows(tx, tour, showIDs)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tour"})
+		return
+	}
+
+	if err := h.loadTourShows(&tour); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tour shows"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tour)
+}
+
+// DeleteTour removes a tour. Its shows stay.
+func (h *Handler) DeleteTour(c *gin.Context) {
+	tour, ok := h.findTour(c)
+	if !ok {
+		return
+	}
+
+	if err := deleteTours(h.DB, "id = ?", tour.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete tour"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tour deleted successfully"})
+}
+
+// findTour loads the tour in the :id parameter with its band. It writes the error
+// response itself and returns false when there is none.
+func (h *Handler) findTour(c *gin.Context) (models.Tour, bool) {
+	var tour models.Tour
+
+	tourID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tour ID"})
+		return tour, false
+	}
+
+	if err := h.DB.Preload("Band").Where("id = ?", tourID).First(&tour).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tour not found"})
+		return tour, false
+	}
+	return tour, true
+}
+
+// findTourShows checks the shows to put on tour: each needs the band of the tour
+// on its bill and may be on no other tour of the band. It writes the error
+// response itself and returns false when a show does not fit.
+func (h *Handler) findTourShows(c *gin.Context, tour models.Tour, showIDParams []string) ([]uuid.UUID, bool) {
+	showIDs := make([]uuid.UUID, 0, len(showIDParams))
+	for _, showIDParam := range showIDParams {
+		showID, err := uuid.Parse(showIDParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid show ID", "show_id": showIDParam})
+			return nil, false
+		}
+		if !slices.Contains(showIDs, showID) {
+			showIDs = append(showIDs, showID)
+		}
+	}
+	if len(showIDs) == 0 {
+		return showIDs, true
+	}
+
+	var billed []uuid.UUID
+	if err := h.DB.Model(&models.ShowPerformance{}).Where("band_id = ? AND show_id IN ?", tour.BandID, showIDs).
+		Pluck("show_id", &billed).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch shows"})
+		return nil, false
+	}
+	for _, showID := range showIDs {
+		if !slices.Contains(billed, showID) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   fmt.Sprintf("Show not found or %s is not on its bill", tour.Band.Name),
+				"show_id": showID,
+			})
+			return nil, false
+		}
+	}
+
+	var taken []models.TourShow
+	if err := h.DB.Where("show_id IN ? AND tour_id <> ? AND band_id = ?", showIDs, tour.ID, tour.BandID).
+		Find(&taken).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check tours"})
+		return nil, false
+	}
+	if len(taken) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Show is already on another tour of this band",
+			"show_id": taken[0].ShowID,
+			"tour_id": taken[0].TourID,
+		})
+		return nil, false
+	}
+	return showIDs, true
+}
+
+// loadTourShows loads the shows of a tour in date order
+func (h *Handler) loadTourShows(tour *models.Tour) error {
+	tour.Shows = []models.Show{}
+	return repository.PreloadShow(h.DB, "").
+		Where("shows.id IN (?)", h.DB.Model(&models.TourShow{}).Select("show_id").Where("tour_id = ?", tour.ID)).
+		Order("shows.local_date").Order(database.DialectOf(h.DB).Time("shows.date")).
+		Find(&tour.Shows).Error
+}
+
+// saveTourShows replaces the shows of a tour. The schema refuses a show that is
+// on another tour of the band already.
+func saveTourShows(tx *gorm.DB, tour models.Tour, showIDs []uuid.UUID) error {
+	if err := tx.Where("tour_id = ?", tour.ID).Delete(&models.TourShow{}).Error; err != nil {
+		return err
+	}
+	if len(showIDs) == 0 {
+		return nil
+	}
+
+	tourShows := make([]models.TourShow, len(showIDs))
+	for i, showID := range showIDs {
+		tourShows[i] = models.TourShow{TourID: tour.ID, ShowID: showID, BandID: tour.BandID}
+	}
+	return tx.Create(&tourShows).Error
+}
+
+// deleteTours removes the tours matching the condition along with their show lists
+func deleteTours(db *gorm.DB, query any, args ...any) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		tours := tx.Model(&models.Tour{}).Select("id").Where(query, args...)
+		if err := tx.Where("tour_id IN (?)", tours).Delete(&models.TourShow{}).Error; err != nil {
+			return err
+		}
+		return tx.Where(query, args...).Delete(&models.Tour{}).Error
+	})
+}
+
+// dropShowFromOtherBandsTours takes a show off the tours of bands no longer on its bill
+func dropShowFromOtherBandsTours(db *gorm.DB, show models.Show) error {
+	// NOT IN an empty list matches nothing, so an empty bill drops the show from every tour
+	if len(show.Performances) == 0 {
+		return db.Where("show_id = ?", show.ID).Delete(&models.TourShow{}).Error
+	}
+
+	bandIDs := make([]uuid.UUID, len(show.Performances))
+	for i, performance := range show.Performances {
+		bandIDs[i] = performance.BandID
+	}
+
+	return db.Where("show_id = ? AND band_id NOT IN ?", show.ID, bandIDs).Delete(&models.TourShow{}).Error
+}
+
+// groupShowRuns splits shows in date order into runs without gaps of more than
+// maxGapDays days between consecutive shows
+func groupShowRuns(shows []models.Show, maxGapDays int) [][]models.Show {
+	var runs [][]models.Show
+	var previous time.Time
+	for _, show := range shows {
+		day, err := time.Parse("2006-01-02", show.LocalDate)
+		if err != nil {
+			continue
+		}
+
+		if len(runs) == 0 || day.Sub(previous) > time.Duration(maxGapDays)*24*time.Hour {
+			runs = append(runs, nil)
+		}
+		runs[len(runs)-1] = append(runs[len(runs)-1], show)
+		previous = day
+	}
+	return runs
+}
+
+// tourName names a tour after the season and year of its first show, e.g.
+// "Fall Tour 2025", adding a leg number when the band already has that name
+func tourName(firstDate string, takenNames map[string]bool) string {
+	day, _ := time.Parse("2006-01-02", firstDate)
+
+	season := "Winter"
+	switch day.Month() {
+	case time.March, time.April, time.May:
+		season = "Spring"
+	case time.June, time.July, time.August:
+		season = "Summer"
+	case time.September, time.October, time.November:
+		season = "Fall"
+	}
+
+	name := fmt.Sprintf("%s Tour %d", season, day.Year())
+	for leg := 2; takenNames[strings.ToLower(name)]; leg++ {
+		name = fmt.Sprintf("%s Tour %d Leg %d", season, day.Year(), leg)
+	}
+	return name
+}
+
+// buildTourResponse lays out the shows of a tour as a route and counts the ones
+// the user attended or is going to
+func buildTourResponse(tour models.Tour, statuses map[uuid.UUID]models.AttendanceStatus) TourResponse {
+	response := TourResponse{
+		Tour:  tour,
+		Route: make([]TourStop, 0, len(tour.Shows)),
+		Coverage: TourCoverage{
+			Shows: len(tour.Shows),
+		},
+	}
+
+	var previous *geo.Point
+	var total float64
+	for i, show := range tour.Shows {
+		stop := TourStop{
+			Position:  i + 1,
+			ShowID:    show.ID,
+			Date:      show.Date,
+			LocalDate: show.LocalDate,
+			VenueID:   show.VenueID,
+			VenueName: show.Venue.Name,
+			City:      show.Venue.City,
+			State:     show.Venue.State,
+			Country:   show.Venue.Country,
+			Latitude:  show.Venue.Latitude,
+			Longitude: show.Venue.Longitude,
+			Status:    statuses[show.ID],
+		}
+
+		if point, ok := show.Venue.Point(); ok {
+			if previous != nil {
+				distance := geo.Distance(*previous, point)
+				total += distance
+				rounded := math.Round(distance*10) / 10
+				stop.DistanceKm = &rounded
+			}
+			previous = &point
+		}
+
+		switch stop.Status {
+		case models.AttendanceStatusAttended:
+			response.Coverage.Attended++
+		case models.AttendanceStatusGoing:
+			response.Coverage.Going++
+		}
+
+		response.Route = append(response.Route, stop)
+	}
+	response.DistanceKm = math.Round(total*10) / 10
+
+	if response.Coverage.Shows > 0 {
+		response.Coverage.Percent = math.Round(float64(response.Coverage.Attended)/float64(response.Coverage.Shows)*1000) / 10
+	}
+	response.Coverage.Summary = fmt.Sprintf("%s: %d dates, you saw %d", tour.Name, response.Coverage.Shows, response.Coverage.Attended)
+
+	return response
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"jam-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestGroupShowRuns(t *testing.T) {
+	shows := func(days ...string) []models.Show {
+		list := make([]models.Show, len(days))
+		for i, day := range days {
+			list[i] = models.Show{LocalDate: day}
+		}
+		return list
+	}
+	days := func(runs [][]models.Show) [][]string {
+		out := make([][]string, len(runs))
+		for i, run := range runs {
+			for _, show := range run {
+				out[i] = append(out[i], show.LocalDate)
+			}
+		}
+		return out
+	}
+
+	tests := []struct {
+		name  string
+		shows []models.Show
+		gap   int
+		want  string
+	}{
+		{"no shows", nil, 7, "[]"},
+		{"one run", shows("2025-09-01", "2025-09-02", "2025-09-09"), 7, "[[2025-09-01 2025-09-02 2025-09-09]]"},
+		{"a gap of more than the days off", shows("2025-09-01", "2025-09-02", "2025-09-10"), 7, "[[2025-09-01 2025-09-02] [2025-09-10]]"},
+		{"two shows a day", shows("2025-09-01", "2025-09-01", "2025-09-02"), 1, "[[2025-09-01 2025-09-01 2025-09-02]]"},
+		{"across the new year", shows("2025-12-30", "2026-01-02"), 3, "[[2025-12-30 2026-01-02]]"},
+		{"without a local date", shows("2025-09-01", "", "2025-09-03"), 7, "[[2025-09-01 2025-09-03]]"},
+	}
+	for _, tt := range tests {
+		if got := fmt.Sprint(days(groupShowRuns(tt.shows, tt.gap))); got != tt.want {
+			t.Errorf("%s: groupShowRuns = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTourName(t *testing.T) {
+	tests := []struct {
+		firstDate string
+		taken     []string
+		want      string
+	}{
+		{"2025-09-10", nil, "Fall Tour 2025"},
+		{"2025-03-01", nil, "Spring Tour 2025"},
+		{"2025-08-31", nil, "Summer Tour 2025"},
+		{"2025-12-31", nil, "Winter Tour 2025"},
+		{"2026-02-14", nil, "Winter Tour 2026"},
+		{"2025-09-10", []string{"fall tour 2025"}, "Fall Tour 2025 Leg 2"},
+		{"2025-09-10", []string{"fall tour 2025", "fall tour 2025 leg 2"}, "Fall Tour 2025 Leg 3"},
+		{"2025-09-10", []string{"summer tour 2025"}, "Fall Tour 2025"},
+	}
+	for _, tt := range tests {
+		taken := make(map[string]bool)
+		for _, name := range tt.taken {
+			taken[name] = true
+		}
+		if got := tourName(tt.firstDate, taken); got != tt.want {
+			t.Errorf("tourName(%s, %v) = %q, want %q", tt.firstDate, tt.taken, got, tt.want)
+		}
+	}
+}
+
+func TestBuildTourResponse(t *testing.T) {
+	point := func(lat, lng float64) models.Location {
+		return models.Location{City: "somewhere", Latitude: &lat, Longitude: &lng}
+	}
+	london := models.Venue{Name: "brixton academy", Location: point(51.5074, -0.1278)}
+	paris := models.Venue{Name: "olympia", Location: point(48.8566, 2.3522)}
+	unknown := models.Venue{Name: "secret show"}
+
+	tour := models.Tour{Name: "Europe Tour 2025", Shows: []models.Show{
+		{ID: uuid.New(), LocalDate: "2025-05-01", Venue: london},
+		{ID: uuid.New(), LocalDate: "2025-05-02", Venue: unknown},
+		{ID: uuid.New(), LocalDate: "2025-05-03", Venue: paris},
+		{ID: uuid.New(), LocalDate: "2025-05-04", Venue: london},
+	}}
+	statuses := map[uuid.UUID]models.AttendanceStatus{
+		tour.Shows[0].ID: models.AttendanceStatusAttended,
+		tour.Shows[2].ID: models.AttendanceStatusGoing,
+		tour.Shows[3].ID: models.AttendanceStatusMissed,
+	}
+
+	response := buildTourResponse(tour, statuses)
+	if len(response.Route) != 4 {
+		t.Fatalf("route has %d stops, want 4", len(response.Route))
+	}
+	for i, stop := range response.Route {
+		if stop.Position != i+1 || stop.ShowID != tour.Shows[i].ID {
+			t.Errorf("stop %d = %+v, want show %d in order", i, stop, i+1)
+		}
+	}
+
+	// The stop without coordinates is skipped, Paris is measured from London
+	if response.Route[0].DistanceKm != nil || response.Route[1].DistanceKm != nil {
+		t.Errorf("first stops have distances %v and %v, want none", response.Route[0].DistanceKm, response.Route[1].DistanceKm)
+	}
+	if d := response.Route[2].DistanceKm; d == nil || *d != 343.6 {
+		t.Errorf("London to Paris = %v, want 343.6 km", d)
+	}
+	if response.DistanceKm != 687.1 && response.DistanceKm != 687.2 {
+		t.Errorf("distance = %v, want there and back again", response.DistanceKm)
+	}
+
+	want := TourCoverage{Shows: 4, Attended: 1, Going: 1, Percent: 25, Summary: "Europe Tour 2025: 4 dates, you saw 1"}
+	if response.Coverage != want {
+		t.Errorf("coverage = %+v, want %+v", response.Coverage, want)
+	}
+	if response.Route[3].Status != models.AttendanceStatusMissed || response.Route[1].Status != "" {
+		t.Errorf("statuses = %q, %q, want missed and none", response.Route[3].Status, response.Route[1].Status)
+	}
+
+	if empty := buildTourResponse(models.Tour{Name: "Cancelled"}, nil); empty.Coverage.Percent != 0 || len(empty.Route) != 0 {
+		t.Errorf("empty tour = %+v, want no route and no coverage", empty)
+	}
+}
+
+func TestGroupToursPutsEachShowOnOneTour(t *testing.T) {
+	s := newAPIServer(t)
+	s.do(http.MethodPost, "/api/v1/bands", gin.H{"band_name": "goose"}, http.StatusCreated)
+	s.do(http.MethodPost, "/api/v1/venues", gin.H{"name": "Red Rocks", "city": "Morrison", "state": "CO"}, http.StatusCreated)
+
+	var showIDs []string
+	for _, date := range []string{"2025-09-01", "2025-09-03", "2025-09-05", "2025-12-01"} {
+		show := decode[models.Show](t, s.do(http.MethodPost, "/api/v1/shows",
+			gin.H{"band_name": "goose", "venue_name": "red rocks", "date": date}, http.StatusCreated))
+		showIDs = append(showIDs, show.ID.String())
+	}
+
+	tours := decode[[]models.Tour](t, s.do(http.MethodPost, "/api/v1/tours/group", gin.H{"band_name": "goose"}, http.StatusCreated))
+	if len(tours) != 1 || tours[0].Name != "Fall Tour 2025" || len(tours[0].Shows) != 3 {
+		t.Fatalf("tours = %+v, want Fall Tour 2025 with the three September shows", tours)
+	}
+
+	// Grouping again finds no shows that are on none of the band's tours
+	if again := decode[[]models.Tour](t, s.do(http.MethodPost, "/api/v1/tours/group", gin.H{"band_name": "goose", "min_shows": 1}, http.StatusCreated)); len(again) != 1 || len(again[0].Shows) != 1 {
+		t.Errorf("grouping again = %+v, want only the December show on a new tour", again)
+	}
+	s.do(http.MethodPost, "/api/v1/tours/group", gin.H{"band_name": "goose", "min_shows": 1}, http.StatusOK)
+
+	s.do(http.MethodPost, "/api/v1/tours", gin.H{"band_name": "goose", "name": "Red Rocks Run", "show_ids": showIDs[:1]}, http.StatusConflict)
+
+	// The schema holds the rule even when the check above is raced
+	err := s.db.Create(&models.TourShow{TourID: tours[0].ID, ShowID: uuid.MustParse(showIDs[3]), BandID: tours[0].BandID}).Error
+	if err == nil {
+		t.Error("put a show on a second tour of the band")
+	}
+}
@@ -3,32 +3,33 @@ package handlers
 import (
 	"net/http"
 	"strings"
+	"time"
 
-	"jam-tracker/internal/database"
 	"jam-tracker/internal/models"
+	"jam-tracker/internal/repository"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type CreateVenueRequest struct {
-	Name     string `json:"name" binding:"required,min=1,max=100"`
-	City     string `json:"city" binding:"required,min=1,max=200"`
-	State    string `json:"state" binding:"required,min=1,max=100"`
-	Country  string `json:"country" binding:"omitempty,min=1,max=100"`
+	Name string `json:"name" binding:"required,min=1,max=100"`
+	LocationRequest
+	Timezone string `json:"timezone" binding:"omitempty,max=64"` // Guessed from the state or country when left out
 	Address  string `json:"address" binding:"omitempty,min=1,max=200"`
 	Capacity string `json:"capacity" binding:"omitempty,min=1"`
 }
 
 type UpdateVenueRequest struct {
-	Name     string `json:"name" binding:"required,min=1,max=100"`
-	City     string `json:"city" binding:"required,min=1,max=200"`
-	State    string `json:"state" binding:"required,min=1,max=100"`
-	Country  string `json:"country" binding:"omitempty,min=1,max=100"`
+	Name string `json:"name" binding:"required,min=1,max=100"`
+	LocationRequest
+	Timezone string `json:"timezone" binding:"omitempty,max=64"` // Kept when left out
 	Address  string `json:"address" binding:"omitempty,min=1,max=200"`
 	Capacity string `json:"capacity" binding:"omitempty,min=1"`
 }
 
-func CreateVenue(c *gin.Context) {
+func (h *Handler) CreateVenue(c *gin.Context) {
 	var req CreateVenueRequest
 
 	if err := c.ShouldBindBodyWithJSON(&req); err != nil {
@@ -36,32 +37,41 @@ func CreateVenue(c *gin.Context) {
 		return
 	}
 
+	location, ok := normalizeVenueLocation(c, req.LocationRequest)
+	if !ok {
+		return
+	}
+
+	timezone := strings.TrimSpace(req.Timezone)
+	if timezone == "" {
+		timezone = guessTimezone(location)
+	} else if err := validateTimezone(timezone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Check if venue already exists.
-	var existingVenue models.Venue
 	normalizedVenueName := strings.ToLower(strings.TrimSpace(req.Name))
-	normalizedCity := strings.ToLower(strings.TrimSpace(req.City))
-	normalizedState := strings.ToLower(strings.TrimSpace(req.State))
-	normalizedCountry := strings.ToLower(strings.TrimSpace(req.Country))
 	normalizedAddress := strings.TrimSpace(req.Address)
 
-	if err := database.DB.Where("name = ? AND city = ? AND state = ?",
+	if _, err := h.Venues.FindByLocation(c.Request.Context(),
 		normalizedVenueName,
-		normalizedCity,
-		normalizedState).First(&existingVenue).Error; err == nil {
+		location.City,
+		location.State); err == nil {
 		c.JSON(http.StatusConflict, gin.H{"error": "Venue already exists"})
 		return
 	}
 
+	h.locate(c.Request.Context(), &location, normalizedAddress)
 	venue := models.Venue{
 		Name:     normalizedVenueName,
-		City:     normalizedCity,
-		State:    normalizedState,
-		Country:  normalizedCountry,
+		Location: location,
+		Timezone: timezone,
 		Address:  normalizedAddress,
 		Capacity: req.Capacity,
 	}
 
-	if err := database.DB.Create(&venue).Error; err != nil {
+	if err := h.Venues.Create(c.Request.Context(), &venue); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create venue"})
 		return
 	}
@@ -69,52 +79,62 @@ func CreateVenue(c *gin.Context) {
 	c.JSON(http.StatusCreated, venue)
 }
 
-func GetVenues(c *gin.Context) {
-	var venues []models.Venue
+// venueListOptions are the sorts GetVenues accepts
+var venueListOptions = ListOptions[models.Venue]{
+	Spec:         repository.VenueSorts,
+	DefaultSort:  "name",
+	DefaultOrder: "asc",
+}
 
+func (h *Handler) GetVenues(c *gin.Context) {
 	// Get query parameters for filtering
 	name := c.Query("name")
 	city := c.Query("city")
 	state := c.Query("state")
 	country := c.Query("country")
 
-	query := database.DB
+	var filter repository.VenueFilter
+
+	// Filter by distance
+	near, ok := parseNear(c)
+	if !ok {
+		return
+	}
+	filter.Near = near
 
 	// Add filters if provided
 	if name != "" {
-		normalizedName := strings.ToLower(strings.TrimSpace(strings.ReplaceAll(name, "-", " ")))
-		query = query.Where("name LIKE ?", "%"+normalizedName+"%")
+		filter.Name = strings.ToLower(strings.TrimSpace(strings.ReplaceAll(name, "-", " ")))
 	}
 
 	if city != "" {
-		normalizedCity := strings.ToLower(strings.TrimSpace(strings.ReplaceAll(city, "-", " ")))
-		query = query.Where("city LIKE ?", "%"+normalizedCity+"%")
+		filter.City = strings.ToLower(strings.TrimSpace(strings.ReplaceAll(city, "-", " ")))
 	}
 
 	if state != "" {
-		normalizedState := strings.ToLower(strings.TrimSpace(strings.ReplaceAll(state, "-", " ")))
-		query = query.Where("state LIKE ?", "%"+normalizedState+"%")
+		filter.State = strings.ToLower(strings.TrimSpace(strings.ReplaceAll(state, "-", " ")))
 	}
 
 	if country != "" {
-		normalizedCountry := strings.ToLower(strings.TrimSpace(strings.ReplaceAll(country, "-", " ")))
-		query = query.Where("country LIKE ?", "%"+normalizedCountry+"%")
+		filter.Country = strings.ToLower(strings.TrimSpace(strings.ReplaceAll(country, "-", " ")))
 	}
 
-	if err := query.Find(&venues).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch venues"})
+	venues, ok := paginate(c, venueListOptions, func(page repository.PageRequest) (repository.Page[models.Venue], error) {
+		return h.Venues.List(c.Request.Context(), filter, page)
+	})
+	if !ok {
 		return
 	}
 
 	c.JSON(http.StatusOK, venues)
 }
 
-func GetVenue(c *gin.Context) {
+func (h *Handler) GetVenue(c *gin.Context) {
 	venueName := c.Param("id")
 	normalizedVenueName := strings.ToLower(strings.TrimSpace(strings.ReplaceAll(venueName, "-", " ")))
 
-	var venue models.Venue
-	if err := database.DB.Where("name = ?", normalizedVenueName).First(&venue).Error; err != nil {
+	venue, err := h.Venues.FindByName(c.Request.Context(), normalizedVenueName)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Venue not found"})
 		return
 	}
@@ -122,7 +142,7 @@ func GetVenue(c *gin.Context) {
 	c.JSON(http.StatusOK, venue)
 }
 
-func UpdateVenue(c *gin.Context) {
+func (h *Handler) UpdateVenue(c *gin.Context) {
 	var req UpdateVenueRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -132,27 +152,13 @@ func UpdateVenue(c *gin.Context) {
 	venueName := c.Param("id")
 	normalizedVenueName := strings.ToLower(strings.TrimSpace(strings.ReplaceAll(venueName, "-", " ")))
 
-	var venue models.Venue
-	if err := database.DB.Where("name = ?", normalizedVenueName).First(&venue).Error; err != nil {
+	venue, err := h.Venues.FindByName(c.Request.Context(), normalizedVenueName)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Venue not found"})
 		return
 	}
 
-	normalizedCity := strings.ToLower(strings.TrimSpace(req.City))
-	normalizedState := strings.ToLower(strings.TrimSpace(req.State))
-	normalizedCountry := strings.ToLower(strings.TrimSpace(req.Country))
-	normalizedAddress := strings.TrimSpace(req.Address)
-
-	// Update the venue
-	if err := database.DB.Model(&venue).Updates(models.Venue{
-		Name:     normalizedVenueName,
-		City:     normalizedCity,
-		State:    normalizedState,
-		Country:  normalizedCountry,
-		Address:  normalizedAddress,
-		Capacity: req.Capacity,
-	}).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update band"})
+	if !h.applyVenueUpdate(c, &venue, req) {
 		return
 	}
 
@@ -160,7 +166,119 @@ func UpdateVenue(c *gin.Context) {
 	c.JSON(http.StatusOK, venue)
 }
 
-func DeleteVenue(c *gin.Context) {
+// applyVenueUpdate saves req onto venue. It writes the error response itself and
+// returns false when the update failed.
+func (h *Handler) applyVenueUpdate(c *gin.Context, venue *models.Venue, req UpdateVenueRequest) bool {
+	// Keep the country when it was left out
+	if strings.TrimSpace(req.Country) == "" {
+		req.Country = venue.Country
+	}
+
+	location, ok := normalizeVenueLocation(c, req.LocationRequest)
+	if !ok {
+		return false
+	}
+
+	previousTimezone := venue.TimeZone()
+	if timezone := strings.TrimSpace(req.Timezone); timezone != "" {
+		if err := validateTimezone(timezone); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return false
+		}
+		venue.Timezone = timezone
+	}
+
+	// Keep optional fields that were left out. A new address moves the venue.
+	normalizedAddress := strings.TrimSpace(req.Address)
+	if normalizedAddress == "" || normalizedAddress == venue.Address {
+		keepCoordinates(&location, venue.Location)
+	} else {
+		venue.Address = normalizedAddress
+	}
+	if req.Capacity != "" {
+		venue.Capacity = req.Capacity
+	}
+
+	// Geocode again when the venue moved, unless coordinates were given
+	h.locate(c.Request.Context(), &location, venue.Address)
+	venue.Location = location
+
+	if err := h.Venues.Update(c.Request.Context(), venue); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update band"})
+		return false
+	}
+
+	// Shows keep their time on the clock at the venue
+	if venue.TimeZone().String() != previousTimezone.String() {
+		if err := moveShowsToTimezone(h.DB, venue.ID, previousTimezone, venue.TimeZone()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update show times"})
+			return false
+		}
+	}
+	return true
+}
+
+// moveShowsToTimezone keeps the local time of the shows at a venue, and of their
+// sets, when its timezone changes. The timezone was wrong before, so a show at
+// 8pm stays at 8pm and on the same calendar day.
+func moveShowsToTimezone(db *gorm.DB, venueID uuid.UUID, from, to *time.Location) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var shows []models.Show
+		if err := tx.Select("id", "date").Where("venue_id = ?", venueID).Find(&shows).Error; err != nil {
+			return err
+		}
+
+		for _, show := range shows {
+			if err := tx.Model(&models.Show{}).Where("id = ?", show.ID).Update("date", keepWallClock(show.Date, from, to)).Error; err != nil {
+				return err
+			}
+		}
+
+		var performances []models.ShowPerformance
+		if err := tx.Select("id", "set_start", "set_end").
+			Where("show_id IN (?)", tx.Model(&models.Show{}).Select("id").Where("venue_id = ?", venueID)).
+			Where("set_start IS NOT NULL OR set_end IS NOT NULL").Find(&performances).Error; err != nil {
+			return err
+		}
+
+		for _, performance := range performances {
+			updates := map[string]any{}
+			if performance.SetStart != nil {
+				updates["set_start"] = keepWallClock(*performance.SetStart, from, to)
+			}
+			if performance.SetEnd != nil {
+				updates["set_end"] = keepWallClock(*performance.SetEnd, from, to)
+			}
+			if err := tx.Model(&models.ShowPerformance{}).Where("id = ?", performance.ID).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// keepWallClock returns the time in to that reads the same on the clock as t in from
+func keepWallClock(t time.Time, from, to *time.Location) time.Time {
+	local := t.In(from)
+	return time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), local.Nanosecond(), to)
+}
+
+// normalizeVenueLocation checks the location of a venue, which needs a city and
+// a state. It writes the error response itself and returns false when it is invalid.
+func normalizeVenueLocation(c *gin.Context, req LocationRequest) (models.Location, bool) {
+	location, err := normalizeLocation(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return location, false
+	}
+	if location.City == "" || location.State == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "city and state are required"})
+		return location, false
+	}
+	return location, true
+}
+
+func (h *Handler) DeleteVenue(c *gin.Context) {
 	venueName := c.Param("id")
 	normalizedVenueName := strings.ToLower(strings.TrimSpace(strings.ReplaceAll(venueName, "-", " ")))
 
@@ -170,15 +288,15 @@ func DeleteVenue(c *gin.Context) {
 	}
 
 	// Find venue
-	var venue models.Venue
-	if err := database.DB.Where("name = ?", normalizedVenueName).First(&venue).Error; err != nil {
+	venue, err := h.Venues.FindByName(c.Request.Context(), normalizedVenueName)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Venue not found"})
 		return
 	}
 
 	// Check if venue has any shows
-	var showCount int64
-	if err := database.DB.Model(&models.Show{}).Where("venue_id = ?", venue.ID).Count(&showCount).Error; err != nil {
+	showCount, err := h.Shows.CountByVenue(c.Request.Context(), venue.ID)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check venue usage"})
 		return
 	}
@@ -191,8 +309,14 @@ func DeleteVenue(c *gin.Context) {
 		return
 	}
 
+	// Delete suggested changes to the venue
+	if err := deleteChangeRequests(h.DB, "target_type = ? AND target_id = ?", models.ChangeTargetVenue, venue.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete change requests"})
+		return
+	}
+
 	// Delete venue
-	if err := database.DB.Delete(&venue).Error; err != nil {
+	if err := h.Venues.Delete(c.Request.Context(), venue.ID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete venue"})
 		return
 	}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"jam-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCreateVenueNormalizesLocation(t *testing.T) {
+	s := newTestServer(t)
+
+	venue := decode[models.Venue](t, s.do(http.MethodPost, "/api/v1/venues",
+		gin.H{"name": "Red Rocks", "city": "  Denver ", "state": "CO"}, http.StatusCreated))
+
+	if venue.Name != "red rocks" || venue.City != "denver" || venue.State != "co" {
+		t.Errorf("venue = %q in %q, %q, want red rocks in denver, co", venue.Name, venue.City, venue.State)
+	}
+	if venue.Country != "usa" {
+		t.Errorf("country = %q, want usa", venue.Country)
+	}
+	if venue.Timezone != "America/Denver" {
+		t.Errorf("timezone = %q, want America/Denver", venue.Timezone)
+	}
+	if _, ok := venue.Point(); !ok {
+		t.Error("venue was not geocoded")
+	}
+
+	s.do(http.MethodPost, "/api/v1/venues", gin.H{"name": "red rocks", "city": "Denver", "state": "co"}, http.StatusConflict)
+}
+
+func TestCreateVenueRejectsUnknownTimezone(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := s.do(http.MethodPost, "/api/v1/venues",
+		gin.H{"name": "Red Rocks", "city": "Denver", "state": "CO", "timezone": "Mountain"}, http.StatusBadRequest)
+	if msg := errorMessage(t, rec); msg == "" {
+		t.Error("no error message")
+	}
+}
+
+func TestGetVenuesFilters(t *testing.T) {
+	s := newTestServer(t)
+	s.do(http.MethodPost, "/api/v1/venues", gin.H{"name": "Red Rocks", "city": "Denver", "state": "CO"}, http.StatusCreated)
+	s.do(http.MethodPost, "/api/v1/venues", gin.H{"name": "The Gorge", "city": "Seattle", "state": "WA"}, http.StatusCreated)
+	s.do(http.MethodPost, "/api/v1/venues", gin.H{"name": "O2 Academy", "city": "London", "state": "England", "country": "UK"}, http.StatusCreated)
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"state=CO", []string{"red rocks"}},
+		{"name=the-gorge", []string{"the gorge"}},
+		{"country=usa", []string{"red rocks", "the gorge"}},
+		{"near=39.74,-104.99&radius_km=100", []string{"red rocks"}},
+	}
+	for _, tt := range tests {
+		venues := decode[[]models.Venue](t, s.do(http.MethodGet, "/api/v1/venues?"+tt.query, nil, http.StatusOK))
+
+		names := make([]string, len(venues))
+		for i, venue := range venues {
+			names[i] = venue.Name
+		}
+		if len(names) != len(tt.want) {
+			t.Errorf("?%s = %v, want %v", tt.query, names, tt.want)
+			continue
+		}
+		for i := range names {
+			if names[i] != tt.want[i] {
+				t.Errorf("?%s = %v, want %v", tt.query, names, tt.want)
+				break
+			}
+		}
+	}
+
+	s.do(http.MethodGet, "/api/v1/venues/red-rocks", nil, http.StatusOK)
+	s.do(http.MethodGet, "/api/v1/venues/msg", nil, http.StatusNotFound)
+}
+
+func TestKeepWallClock(t *testing.T) {
+	denver, err := time.LoadLocation("America/Denver")
+	if err != nil {
+		t.Fatalf("load timezone: %v", err)
+	}
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Fatalf("load timezone: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		t        time.Time
+		from, to *time.Location
+		want     time.Time
+	}{
+		{"utc to denver", time.Date(2025, 9, 10, 20, 0, 0, 0, time.UTC), time.UTC, denver, time.Date(2025, 9, 11, 2, 0, 0, 0, time.UTC)},
+		{"denver to utc", time.Date(2025, 9, 11, 2, 0, 0, 0, time.UTC), denver, time.UTC, time.Date(2025, 9, 10, 20, 0, 0, 0, time.UTC)},
+		{"across a change to summer time", time.Date(2025, 3, 29, 20, 0, 0, 0, time.UTC), time.UTC, london, time.Date(2025, 3, 29, 20, 0, 0, 0, time.UTC)},
+		{"in summer time", time.Date(2025, 3, 30, 20, 0, 0, 0, time.UTC), time.UTC, london, time.Date(2025, 3, 30, 19, 0, 0, 0, time.UTC)},
+		{"same timezone", time.Date(2025, 9, 10, 20, 0, 0, 0, time.UTC), denver, denver, time.Date(2025, 9, 10, 20, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := keepWallClock(tt.t, tt.from, tt.to); !got.Equal(tt.want) {
+			t.Errorf("%s: keepWallClock = %v, want %v", tt.name, got.UTC(), tt.want)
+		}
+	}
+}
+
+func TestUpdateVenueTimezoneKeepsShowTimes(t *testing.T) {
+	s := newAPIServer(t)
+	s.do(http.MethodPost, "/api/v1/bands", gin.H{"band_name": "goose"}, http.StatusCreated)
+	s.do(http.MethodPost, "/api/v1/venues", gin.H{"name": "Red Rocks", "city": "Morrison", "state": "CO", "timezone": "UTC"}, http.StatusCreated)
+
+	show := decode[models.Show](t, s.do(http.MethodPost, "/api/v1/shows", gin.H{
+		"venue_name":   "red rocks",
+		"date":         "2025-09-10 20:00",
+		"performances": []gin.H{{"band_name": "goose", "set_start": "20:00", "set_end": "23:30"}},
+	}, http.StatusCreated))
+
+	// The timezone was wrong, the show still started at 8pm at the venue
+	s.do(http.MethodPut, "/api/v1/venues/red-rocks",
+		gin.H{"name": "Red Rocks", "city": "Morrison", "state": "CO", "timezone": "America/Denver"}, http.StatusOK)
+
+	show = decode[models.Show](t, s.do(http.MethodGet, "/api/v1/shows/"+show.ID.String(), nil, http.StatusOK))
+	if want := time.Date(2025, 9, 11, 2, 0, 0, 0, time.UTC); !show.Date.Equal(want) || show.LocalDate != "2025-09-10" {
+		t.Errorf("show on %v (%s), want %v on 2025-09-10", show.Date.UTC(), show.LocalDate, want)
+	}
+	if len(show.Performances) != 1 {
+		t.Fatalf("performances = %+v, want goose alone", show.Performances)
+	}
+	set := show.Performances[0]
+	if set.SetStart == nil || set.SetEnd == nil ||
+		!set.SetStart.Equal(time.Date(2025, 9, 11, 2, 0, 0, 0, time.UTC)) || !set.SetEnd.Equal(time.Date(2025, 9, 11, 5, 30, 0, 0, time.UTC)) {
+		t.Errorf("set from %v to %v, want 8pm to 11:30pm in Denver", set.SetStart, set.SetEnd)
+	}
+}
@@ -0,0 +1,54 @@
+package mailer
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+)
+
+// Message is a plain-text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends emails to users
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Connect returns the mailer selected by MAILER
+func Connect() Mailer {
+	var m Mailer
+	switch backend := getEnv("MAILER", "log"); backend {
+	case "log":
+		m = LogMailer{}
+	case "smtp":
+		port, err := strconv.Atoi(getEnv("SMTP_PORT", "1025"))
+		if err != nil {
+			log.Fatalf("Invalid SMTP_PORT: %v", err)
+		}
+		m = &SMTPMailer{
+			Host:     getEnv("SMTP_HOST", "localhost"),
+			Port:     port,
+			Username: getEnv("SMTP_USERNAME", ""),
+			Password: getEnv("SMTP_PASSWORD", ""),
+			From:     getEnv("MAIL_FROM", "JamTracker <no-reply@jamtracker.local>"),
+		}
+	default:
+		log.Fatalf("Unknown MAILER %q, expected log or smtp", backend)
+	}
+
+	log.Println("Mailer initialized!")
+	return m
+}
+
+// Helper functions
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
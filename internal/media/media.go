@@ -0,0 +1,135 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	"net/http"
+	"time"
+
+	// Register decoders for the supported formats
+	_ "image/gif"
+	_ "image/png"
+
+	"github.com/rwcarlsen/goexif/exif"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	MaxUploadSize      = 10 << 20 // 10 MB
+	MaxPixels          = 50e6     // 50 megapixels, checked before decoding as a small file can claim huge dimensions
+	ThumbnailMaxSize   = 320      // Longest edge of a thumbnail in pixels
+	ThumbnailMIMEType  = "image/jpeg"
+	thumbnailJPEGLevel = 80
+)
+
+// AllowedTypes maps the accepted MIME types to the file extension used when storing them
+var AllowedTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+var (
+	ErrTooLarge        = errors.New("file is larger than 10 MB")
+	ErrUnsupportedType = errors.New("unsupported file type, use JPEG, PNG, GIF or WebP")
+	ErrTooManyPixels   = errors.New("image is larger than 50 megapixels")
+)
+
+// Image is an uploaded image after validation and processing
+type Image struct {
+	ContentType string
+	Extension   string
+	Width       int
+	Height      int
+	TakenAt     *time.Time // From EXIF, when the camera recorded it
+	Thumbnail   []byte     // JPEG encoded
+}
+
+// Process validates an uploaded file by size, sniffed content type and pixel
+// count, then extracts its dimensions and EXIF date and renders a thumbnail
+func Process(data []byte) (*Image, error) {
+	if len(data) > MaxUploadSize {
+		return nil, ErrTooLarge
+	}
+
+	// Trust the bytes, not the client supplied Content-Type
+	contentType := http.DetectContentType(data)
+	extension, ok := AllowedTypes[contentType]
+	if !ok {
+		return nil, ErrUnsupportedType
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, ErrUnsupportedType
+	}
+	if int64(config.Width)*int64(config.Height) > MaxPixels {
+		return nil, ErrTooManyPixels
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+
+	thumbnail, err := renderThumbnail(src)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := src.Bounds()
+	return &Image{
+		ContentType: contentType,
+		Extension:   extension,
+		Width:       bounds.Dx(),
+		Height:      bounds.Dy(),
+		TakenAt:     takenAt(data),
+		Thumbnail:   thumbnail,
+	}, nil
+}
+
+// renderThumbnail scales the image down so its longest edge fits ThumbnailMaxSize
+func renderThumbnail(src image.Image) ([]byte, error) {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	if width > ThumbnailMaxSize || height > ThumbnailMaxSize {
+		if width >= height {
+			height = max(1, height*ThumbnailMaxSize/width)
+			width = ThumbnailMaxSize
+		} else {
+			width = max(1, width*ThumbnailMaxSize/height)
+			height = ThumbnailMaxSize
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Src, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: thumbnailJPEGLevel}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// takenAt reads the capture date from EXIF data, if the file has any
+func takenAt(data []byte) *time.Time {
+	x, err := exif.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+
+	t, err := x.DateTime()
+	if err != nil {
+		return nil
+	}
+	return &t
+}
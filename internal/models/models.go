@@ -3,26 +3,75 @@ package models
 import (
 	"time"
 
+	"jam-tracker/internal/geo"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// Role decides what a user may change. Each role includes the ones below it.
+type Role string
+
+const (
+	RoleUser   Role = "user"   // Manages their own attendance
+	RoleEditor Role = "editor" // Also edits bands, venues and shows
+	RoleAdmin  Role = "admin"  // Also grants roles
+)
+
+var roleRanks = map[Role]int{RoleUser: 1, RoleEditor: 2, RoleAdmin: 3}
+
+// Valid reports whether the role is one of the known roles
+func (r Role) Valid() bool {
+	_, ok := roleRanks[r]
+	return ok
+}
+
+// Includes reports whether the role grants everything the other role does
+func (r Role) Includes(other Role) bool {
+	return roleRanks[r] >= roleRanks[other]
+}
+
 // User represents a user in the system.
 type User struct {
-	ID        uuid.UUID `gorm:"type:uuid;primary_key;" json:"id"`
-	Email     string    `gorm:"uniqueIndex;not null" json:"email"`
-	Username  string    `gorm:"uniqueIndex;not null" json:"username"`
-	Password  string    `gorm:"not null" json:"-"` // "-" excludes from JSON
-	FirstName string    `json:"first_name"`
-	LastName  string    `json:"last_name"`
-	Location  string    `json:"location"` // City, State for now
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID              uuid.UUID  `gorm:"type:uuid;primary_key;" json:"id"`
+	Email           string     `gorm:"uniqueIndex;not null" json:"email"`
+	Username        string     `gorm:"uniqueIndex;not null" json:"username"`
+	Password        string     `gorm:"not null" json:"-"` // "-" excludes from JSON
+	FirstName       string     `json:"first_name"`
+	LastName        string     `json:"last_name"`
+	Location        Location   `gorm:"embedded;embeddedPrefix:home_" json:"location"` // Where the user lives
+	Role            Role       `gorm:"not null;default:'user'" json:"role"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`           // Nil until the user follows the verification email
+	TOTPSecret      string     `json:"-"`                           // Set on enrollment, used once TOTPEnabledAt is set
+	TOTPEnabledAt   *time.Time `json:"totp_enabled_at"`             // Nil unless two-factor login is on
+	TOTPLastStep    int64      `gorm:"not null;default:0" json:"-"` // Last accepted time step, so a code cannot be replayed
+	FailedLogins    int        `gorm:"not null;default:0" json:"-"` // Wrong passwords or codes since the last login
+	LockedUntil     *time.Time `json:"-"`                           // Logins are refused until then
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
 
 	// Relationships
 	ShowAttendances []ShowAttendance `gorm:"foreignKey:UserID" json:"show_attendances,omitempty"`
 }
 
+// Location is where a venue is or a user lives. City, state and country are
+// stored lowercase; the coordinates are nil until known.
+type Location struct {
+	City      string   `json:"city"`
+	State     string   `json:"state"`
+	Country   string   `json:"country"`
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+}
+
+// Point returns the coordinates of the location, if known
+func (l Location) Point() (geo.Point, bool) {
+	if l.Latitude == nil || l.Longitude == nil {
+		return geo.Point{}, false
+	}
+	return geo.Point{Lat: *l.Latitude, Lng: *l.Longitude}, true
+}
+
 // Band represents a musical band/artist.
 type Band struct {
 	ID          uuid.UUID `gorm:"type:uuid;primary_key;" json:"id"`
@@ -34,15 +83,15 @@ type Band struct {
 
 	// Relationships
 	Shows []Show `gorm:"foreignKey:BandID" json:"shows,omitempty"`
+	Songs []Song `gorm:"foreignKey:BandID" json:"songs,omitempty"`
 }
 
 // Venue represents a concert venue
 type Venue struct {
 	ID        uuid.UUID `gorm:"type:uuid;primary_key;" json:"id"`
 	Name      string    `gorm:"not null;index" json:"name"`
-	City      string    `gorm:"not null" json:"city"`
-	State     string    `gorm:"not null" json:"state"`
-	Country   string    `gorm:"default:'USA'" json:"country"`
+	Location  `gorm:"embedded"`
+	Timezone  string    `gorm:"not null;default:'UTC'" json:"timezone"` // IANA name, e.g. America/Denver
 	Address   string    `json:"address"`
 	Capacity  string    `json:"capacity"`
 	CreatedAt time.Time `json:"created_at"`
@@ -52,38 +101,331 @@ type Venue struct {
 	Shows []Show `gorm:"foreignKey:VenueID" json:"shows,omitempty"`
 }
 
-// Show represents a concert/performance
+// TimeZone returns the timezone of the venue, UTC when it is unknown
+func (v Venue) TimeZone() *time.Location {
+	if loc, err := time.LoadLocation(v.Timezone); err == nil {
+		return loc
+	}
+	return time.UTC
+}
+
+// LocalDate returns the calendar day at the venue at time t, as YYYY-MM-DD
+func (v Venue) LocalDate(t time.Time) string {
+	return t.In(v.TimeZone()).Format("2006-01-02")
+}
+
+// Show represents a concert/performance. Every band on the bill has a
+// performance; the headliner's setlist is the one stored in Sets.
 type Show struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;" json:"id"`
+	BandID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"band_id"` // Headliner, the first band on the bill
+	VenueID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"venue_id"`
+	FestivalID *uuid.UUID `gorm:"type:uuid;index" json:"festival_id,omitempty"`
+	Stage      string     `json:"stage,omitempty"` // Stage of the venue or festival, e.g. "Which Stage"
+	Date       time.Time  `gorm:"not null;index" json:"date"`
+	LocalDate  string     `gorm:"not null;index" json:"local_date"` // Calendar day at the venue, YYYY-MM-DD
+	SetlistID  string     `json:"setlist_id,omitempty"`             // setlist.fm ID of an imported show, so importing it again updates it
+	Notes      string     `json:"notes"`
+	Setlist    string     `gorm:"-" json:"setlist,omitempty"` // Rendered from Sets, e.g. "Set 1: Tweezer > Reba -> Tweezer"
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	// Relationships
+	Band            Band              `gorm:"foreignKey:BandID" json:"band"`
+	Venue           Venue             `gorm:"foreignKey:VenueID" json:"venue"`
+	Performances    []ShowPerformance `gorm:"foreignKey:ShowID" json:"performances"` // The bill in billing order
+	ShowAttendances []ShowAttendance  `gorm:"foreignKey:ShowID" json:"attendances,omitempty"`
+	Sets            []Set             `gorm:"foreignKey:ShowID" json:"sets,omitempty"`
+}
+
+// ShowPerformance is the set of one band on the bill of a show
+type ShowPerformance struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;" json:"id"`
+	ShowID    uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_show_performances_show_band" json:"show_id"`
+	BandID    uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_show_performances_show_band;index" json:"band_id"`
+	Position  int        `gorm:"not null" json:"position"` // Billing order, 1 for the headliner
+	SetStart  *time.Time `json:"set_start"`
+	SetEnd    *time.Time `json:"set_end"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+
+	// Relationships
+	Band Band `gorm:"foreignKey:BandID" json:"band"`
+}
+
+// Festival groups the shows of an event spanning several days or stages. Each
+// show is one stage on one day.
+type Festival struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;" json:"id"`
+	Name        string    `gorm:"not null;index" json:"name"`
+	Description string    `json:"description"`
+	StartDate   string    `gorm:"not null" json:"start_date"` // First day, YYYY-MM-DD
+	EndDate     string    `gorm:"not null" json:"end_date"`   // Last day, YYYY-MM-DD
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Relationships
+	Shows []Show `gorm:"foreignKey:FestivalID" json:"shows,omitempty"`
+}
+
+// Tour is a run of shows a band played or will play. Its shows are ordered by
+// date and need the band on their bill.
+type Tour struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;" json:"id"`
+	BandID      uuid.UUID `gorm:"type:uuid;not null;index" json:"band_id"`
+	Name        string    `gorm:"not null" json:"name"` // e.g. "Fall Tour 2025"
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Relationships
+	Band  Band   `gorm:"foreignKey:BandID" json:"band"`
+	Shows []Show `gorm:"many2many:tour_shows;joinForeignKey:TourID;joinReferences:ShowID" json:"shows,omitempty"`
+}
+
+// TourShow puts a show on a tour. A show is on at most one tour of each band.
+type TourShow struct {
+	TourID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	ShowID uuid.UUID `gorm:"type:uuid;primaryKey;index;uniqueIndex:idx_tour_shows_band_show,priority:2"`
+	BandID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_tour_shows_band_show,priority:1"` // Band of the tour
+}
+
+// Song represents a song in a band's catalog
+type Song struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;" json:"id"`
+	BandID      uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_songs_band_name" json:"band_id"`
+	Name        string    `gorm:"not null;uniqueIndex:idx_songs_band_name" json:"name"` // Lowercase, to look the song up by
+	DisplayName string    `gorm:"not null;default:''" json:"display_name"`              // As first written, e.g. "Tweezer"
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Relationships
+	Band Band `gorm:"foreignKey:BandID" json:"-"`
+}
+
+// Set represents one set of a show (Set 1, Set 2, Encore)
+type Set struct {
 	ID        uuid.UUID `gorm:"type:uuid;primary_key;" json:"id"`
-	BandID    uuid.UUID `gorm:"type:uuid;not null;index" json:"band_id"`
-	VenueID   uuid.UUID `gorm:"type:uuid;not null;index" json:"venue_id"`
-	Date      time.Time `gorm:"not null;index" json:"date"`
-	SetlistID string    `json:"setlist_id,omitempty"` // For setlist.fm integration later
-	Notes     string    `json:"notes"`
+	ShowID    uuid.UUID `gorm:"type:uuid;not null;index" json:"show_id"`
+	BandID    uuid.UUID `gorm:"type:uuid;not null;index" json:"band_id"` // Band on the bill playing the set
+	Name      string    `gorm:"not null" json:"name"`
+	Position  int       `gorm:"not null" json:"position"` // Order of the set within the show
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 
 	// Relationships
-	Band            Band             `gorm:"foreignKey:BandID" json:"band"`
-	Venue           Venue            `gorm:"foreignKey:VenueID" json:"venue"`
-	ShowAttendances []ShowAttendance `gorm:"foreignKey:ShowID" json:"attendances,omitempty"`
+	Entries []SetlistEntry `gorm:"foreignKey:SetID" json:"entries"`
 }
 
+// Transition describes how a song flows into the next one
+type Transition string
+
+const (
+	TransitionStop  Transition = "stop" // Song ends before the next one starts
+	TransitionSegue Transition = ">"    // Song flows directly into the next one
+	TransitionJam   Transition = "->"   // Song jams into the next one
+)
+
+// SetlistEntry represents a song played at a given position within a set
+type SetlistEntry struct {
+	ID              uuid.UUID  `gorm:"type:uuid;primary_key;" json:"id"`
+	ShowID          uuid.UUID  `gorm:"type:uuid;not null;index" json:"show_id"` // Denormalized for per-show queries
+	SetID           uuid.UUID  `gorm:"type:uuid;not null;index" json:"set_id"`
+	SongID          uuid.UUID  `gorm:"type:uuid;not null;index" json:"song_id"`
+	Position        int        `gorm:"not null" json:"position"`                  // Order of the song within the set
+	Transition      Transition `gorm:"not null;default:'stop'" json:"transition"` // Transition into the next song
+	DurationSeconds *int       `json:"duration_seconds"`
+	Teases          []string   `gorm:"serializer:json" json:"teases"` // Songs teased during this one
+	JamNotes        string     `json:"jam_notes"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+
+	// Relationships
+	Song Song `gorm:"foreignKey:SongID" json:"song"`
+}
+
+// AttendanceStatus describes a user's relationship to a show
+type AttendanceStatus string
+
+const (
+	AttendanceStatusAttended   AttendanceStatus = "attended"
+	AttendanceStatusInterested AttendanceStatus = "interested"
+	AttendanceStatusGoing      AttendanceStatus = "going"
+	AttendanceStatusMissed     AttendanceStatus = "missed"
+)
+
 // ShowAttendance represents a user's attendance at a show
 type ShowAttendance struct {
-	ID           uuid.UUID `gorm:"type:uuid;primary_key;" json:"id"`
-	UserID       uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
-	ShowID       uuid.UUID `gorm:"type:uuid;not null;index" json:"show_id"`
-	Rating       *float64  `gorm:"check:rating >= 1 AND rating <= 5" json:"rating"` // 1-5 scale, nullable
-	FavoriteSong string    `json:"favorite_song"`
-	Notes        string    `json:"notes"`
-	Attended     bool      `gorm:"default:true" json:"attended"` // For future "want to attend" feature
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           uuid.UUID        `gorm:"type:uuid;primary_key;" json:"id"`
+	UserID       uuid.UUID        `gorm:"type:uuid;not null;index" json:"user_id"`
+	ShowID       uuid.UUID        `gorm:"type:uuid;not null;index" json:"show_id"`
+	Rating       *float64         `gorm:"check:rating >= 1 AND rating <= 5" json:"rating"` // 1-5 scale, nullable
+	FavoriteSong string           `json:"favorite_song"`
+	Notes        string           `json:"notes"`
+	Status       AttendanceStatus `gorm:"not null;default:'attended';index" json:"status"`
+	CreatedAt    time.Time        `json:"created_at"`
+	UpdatedAt    time.Time        `json:"updated_at"`
+
+	// Relationships
+	User               User              `gorm:"foreignKey:UserID" json:"user"`
+	Show               Show              `gorm:"foreignKey:ShowID" json:"show"`
+	CaughtPerformances []ShowPerformance `gorm:"many2many:caught_performances;joinForeignKey:AttendanceID;joinReferences:PerformanceID" json:"caught_performances"` // Sets the user saw, empty when not recorded
+	Media              []Media           `gorm:"foreignKey:AttendanceID" json:"media,omitempty"`
+}
+
+// CaughtPerformance records that a user saw a set of a show they attended
+type CaughtPerformance struct {
+	AttendanceID  uuid.UUID `gorm:"type:uuid;primaryKey"`
+	PerformanceID uuid.UUID `gorm:"type:uuid;primaryKey;index"`
+}
+
+// MediaKind describes what an uploaded file shows
+type MediaKind string
+
+const (
+	MediaKindPhoto      MediaKind = "photo"
+	MediaKindTicketStub MediaKind = "ticket_stub"
+)
+
+// Media represents a photo or ticket stub attached to a show attendance
+type Media struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primary_key;" json:"id"`
+	AttendanceID uuid.UUID  `gorm:"type:uuid;not null;index" json:"attendance_id"`
+	UserID       uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	Kind         MediaKind  `gorm:"not null;default:'photo'" json:"kind"`
+	Caption      string     `json:"caption"`
+	ContentType  string     `gorm:"not null" json:"content_type"`
+	Size         int64      `gorm:"not null" json:"size"` // Bytes
+	Width        int        `json:"width"`
+	Height       int        `json:"height"`
+	TakenAt      *time.Time `json:"taken_at,omitempty"` // From EXIF
+	StorageKey   string     `gorm:"not null" json:"-"`
+	ThumbnailKey string     `gorm:"not null" json:"-"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// FollowStatus describes where a follow request stands
+type FollowStatus string
+
+const (
+	FollowStatusPending  FollowStatus = "pending"
+	FollowStatusAccepted FollowStatus = "accepted"
+)
+
+// Follow represents one user following another. The followee has to accept the
+// request before the follower sees their activity.
+type Follow struct {
+	ID         uuid.UUID    `gorm:"type:uuid;primary_key;" json:"id"`
+	FollowerID uuid.UUID    `gorm:"type:uuid;not null;uniqueIndex:idx_follows_pair" json:"follower_id"`
+	FolloweeID uuid.UUID    `gorm:"type:uuid;not null;uniqueIndex:idx_follows_pair;index" json:"followee_id"`
+	Status     FollowStatus `gorm:"not null;default:'pending';index" json:"status"`
+	AcceptedAt *time.Time   `json:"accepted_at,omitempty"`
+	CreatedAt  time.Time    `json:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at"`
 
 	// Relationships
-	User User `gorm:"foreignKey:UserID" json:"user"`
-	Show Show `gorm:"foreignKey:ShowID" json:"show"`
+	Follower User `gorm:"foreignKey:FollowerID" json:"-"`
+	Followee User `gorm:"foreignKey:FolloweeID" json:"-"`
+}
+
+// Session is one signed-in device. Access tokens carry the session ID so revoking
+// the session invalidates them before they expire. The refresh token rotates on
+// every use and only its hash is stored.
+type Session struct {
+	ID                uuid.UUID  `gorm:"type:uuid;primary_key;" json:"id"`
+	UserID            uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	RefreshTokenHash  string     `gorm:"not null;uniqueIndex" json:"-"`
+	PreviousTokenHash string     `gorm:"index" json:"-"` // Hash of the token the current one replaced, to detect reuse
+	UserAgent         string     `json:"user_agent"`
+	IPAddress         string     `json:"ip_address"`
+	ExpiresAt         time.Time  `gorm:"not null" json:"expires_at"`
+	LastUsedAt        time.Time  `gorm:"not null" json:"last_used_at"`
+	RevokedAt         *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+// TokenPurpose tells what a mailed token can be used for
+type TokenPurpose string
+
+const (
+	TokenPurposePasswordReset     TokenPurpose = "password_reset"
+	TokenPurposeEmailVerification TokenPurpose = "email_verification"
+)
+
+// UserToken is a single-use token mailed to a user to prove they own their email
+// address. Only its hash is stored.
+type UserToken struct {
+	ID        uuid.UUID    `gorm:"type:uuid;primary_key;" json:"id"`
+	UserID    uuid.UUID    `gorm:"type:uuid;not null;index" json:"user_id"`
+	Purpose   TokenPurpose `gorm:"not null" json:"purpose"`
+	TokenHash string       `gorm:"not null;uniqueIndex" json:"-"`
+	Email     string       `gorm:"not null" json:"email"` // Address the token was sent to
+	ExpiresAt time.Time    `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time   `json:"used_at,omitempty"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+// RecoveryCode is a single-use code that stands in for a TOTP code when the
+// authenticator is lost. Only its hash is stored.
+type RecoveryCode struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	CodeHash  string     `gorm:"not null;uniqueIndex" json:"-"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+// ChangeTarget is the kind of catalog record a change request edits
+type ChangeTarget string
+
+const (
+	ChangeTargetBand  ChangeTarget = "band"
+	ChangeTargetVenue ChangeTarget = "venue"
+	ChangeTargetShow  ChangeTarget = "show"
+)
+
+// ChangeRequestStatus describes where a change request stands
+type ChangeRequestStatus string
+
+const (
+	ChangeRequestStatusPending  ChangeRequestStatus = "pending"
+	ChangeRequestStatusApproved ChangeRequestStatus = "approved"
+	ChangeRequestStatusRejected ChangeRequestStatus = "rejected"
+)
+
+// ChangeRequest is an edit to a band, venue or show suggested by a user. Changes
+// holds only the fields to change, keyed like the target's update request, and is
+// applied once an editor approves it.
+type ChangeRequest struct {
+	ID         uuid.UUID           `gorm:"type:uuid;primary_key;" json:"id"`
+	UserID     uuid.UUID           `gorm:"type:uuid;not null;index" json:"user_id"`
+	TargetType ChangeTarget        `gorm:"not null;index:idx_change_requests_target" json:"target_type"`
+	TargetID   uuid.UUID           `gorm:"type:uuid;not null;index:idx_change_requests_target" json:"target_id"`
+	Changes    map[string]any      `gorm:"serializer:json;not null" json:"changes"`
+	Comment    string              `json:"comment"` // Why the change is needed, e.g. a source
+	Status     ChangeRequestStatus `gorm:"not null;default:'pending';index" json:"status"`
+	ReviewerID *uuid.UUID          `gorm:"type:uuid" json:"reviewer_id,omitempty"`
+	ReviewNote string              `json:"review_note,omitempty"`
+	ReviewedAt *time.Time          `json:"reviewed_at,omitempty"`
+	CreatedAt  time.Time           `json:"created_at"`
+	UpdatedAt  time.Time           `json:"updated_at"`
+
+	// Relationships
+	User     User  `gorm:"foreignKey:UserID" json:"-"`
+	Reviewer *User `gorm:"foreignKey:ReviewerID" json:"-"`
 }
 
 // BeforeCreate hooks for generating UUIDs
@@ -115,9 +457,93 @@ func (s *Show) BeforeCreate(tx *gorm.DB) (err error) {
 	return
 }
 
+func (sp *ShowPerformance) BeforeCreate(tx *gorm.DB) (err error) {
+	if sp.ID == uuid.Nil {
+		sp.ID = uuid.New()
+	}
+	return
+}
+
+func (f *Festival) BeforeCreate(tx *gorm.DB) (err error) {
+	if f.ID == uuid.Nil {
+		f.ID = uuid.New()
+	}
+	return
+}
+
+func (t *Tour) BeforeCreate(tx *gorm.DB) (err error) {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return
+}
+
 func (sa *ShowAttendance) BeforeCreate(tx *gorm.DB) (err error) {
 	if sa.ID == uuid.Nil {
 		sa.ID = uuid.New()
 	}
 	return
 }
+
+func (s *Song) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return
+}
+
+func (s *Set) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return
+}
+
+func (se *SetlistEntry) BeforeCreate(tx *gorm.DB) (err error) {
+	if se.ID == uuid.Nil {
+		se.ID = uuid.New()
+	}
+	return
+}
+
+func (f *Follow) BeforeCreate(tx *gorm.DB) (err error) {
+	if f.ID == uuid.Nil {
+		f.ID = uuid.New()
+	}
+	return
+}
+
+func (m *Media) BeforeCreate(tx *gorm.DB) (err error) {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return
+}
+
+func (s *Session) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return
+}
+
+func (cr *ChangeRequest) BeforeCreate(tx *gorm.DB) (err error) {
+	if cr.ID == uuid.Nil {
+		cr.ID = uuid.New()
+	}
+	return
+}
+
+func (t *UserToken) BeforeCreate(tx *gorm.DB) (err error) {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return
+}
+
+func (rc *RecoveryCode) BeforeCreate(tx *gorm.DB) (err error) {
+	if rc.ID == uuid.Nil {
+		rc.ID = uuid.New()
+	}
+	return
+}
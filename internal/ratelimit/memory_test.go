@@ -0,0 +1,114 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// clock is a fake time source for a MemoryStore
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time          { return c.now }
+func (c *clock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestStore() (*MemoryStore, *clock) {
+	c := &clock{now: time.Date(2024, 12, 30, 21, 0, 0, 0, time.UTC)}
+	s := NewMemoryStore()
+	s.now, s.lastSweep = c.Now, c.now
+	return s, c
+}
+
+func take(t *testing.T, s *MemoryStore, key string, limit Limit) (bool, time.Duration) {
+	t.Helper()
+	allowed, wait, err := s.Take(context.Background(), key, limit)
+	if err != nil {
+		t.Fatalf("Take(%q) error = %v", key, err)
+	}
+	return allowed, wait
+}
+
+func TestMemoryStoreTakesBurstThenWaits(t *testing.T) {
+	s, c := newTestStore()
+	limit := PerMinute(3) // A token every 20 seconds
+
+	for i := 0; i < 3; i++ {
+		if allowed, _ := take(t, s, "ip:1", limit); !allowed {
+			t.Fatalf("request %d of the burst was refused", i+1)
+		}
+	}
+
+	allowed, wait := take(t, s, "ip:1", limit)
+	if allowed {
+		t.Fatal("request past the burst was allowed")
+	}
+	if wait != 20*time.Second {
+		t.Errorf("wait = %v, want 20s", wait)
+	}
+
+	// Other keys have their own bucket
+	if allowed, _ := take(t, s, "ip:2", limit); !allowed {
+		t.Error("another key was refused")
+	}
+
+	// Half a token is not enough, a whole one is
+	c.Advance(10 * time.Second)
+	if allowed, wait := take(t, s, "ip:1", limit); allowed || wait != 10*time.Second {
+		t.Errorf("after 10s: allowed = %v, wait = %v, want refused for 10s more", allowed, wait)
+	}
+	c.Advance(10 * time.Second)
+	if allowed, _ := take(t, s, "ip:1", limit); !allowed {
+		t.Error("after 20s the refilled token was refused")
+	}
+	if allowed, _ := take(t, s, "ip:1", limit); allowed {
+		t.Error("the refilled token was taken twice")
+	}
+
+	// A long pause refills up to the burst, not beyond
+	c.Advance(time.Hour)
+	for i := 0; i < 3; i++ {
+		if allowed, _ := take(t, s, "ip:1", limit); !allowed {
+			t.Fatalf("request %d after an hour was refused", i+1)
+		}
+	}
+	if allowed, _ := take(t, s, "ip:1", limit); allowed {
+		t.Error("the bucket filled past its burst")
+	}
+}
+
+func TestMemoryStoreSweepsFullBuckets(t *testing.T) {
+	s, c := newTestStore()
+	limit := PerMinute(60)              // Full again a second after a single request
+	slow := Limit{Rate: 0.01, Burst: 1} // Full again 100 seconds after a request
+
+	take(t, s, "ip:idle", limit)
+	take(t, s, "ip:busy", slow)
+
+	// By the sweep the idle bucket is full again, the busy one is not
+	c.Advance(sweepInterval - time.Second)
+	take(t, s, "ip:new", limit)
+	if len(s.buckets) != 3 {
+		t.Fatalf("swept before the sweep interval, %d bucket(s) left", len(s.buckets))
+	}
+
+	c.Advance(2 * time.Second)
+	take(t, s, "ip:new", limit)
+	if _, ok := s.buckets["ip:idle"]; ok {
+		t.Error("the full idle bucket was kept")
+	}
+	if _, ok := s.buckets["ip:busy"]; !ok {
+		t.Error("the busy bucket was swept before it filled up")
+	}
+	if _, ok := s.buckets["ip:new"]; !ok {
+		t.Error("the bucket just taken from was swept")
+	}
+
+	// A swept bucket behaves like a new, full one
+	for i := 0; i < 60; i++ {
+		if allowed, _ := take(t, s, "ip:idle", limit); !allowed {
+			t.Fatalf("request %d to the swept bucket was refused", i+1)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Limit is a token bucket: Burst requests at once, refilled at Rate per second
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// PerMinute allows n requests a minute, all of which may come at once
+func PerMinute(n int) Limit {
+	return Limit{Rate: float64(n) / 60, Burst: n}
+}
+
+// Store keeps the token buckets
+type Store interface {
+	// Take removes a token from the bucket of key. When the bucket is empty it
+	// returns false and how long until the next token.
+	Take(ctx context.Context, key string, limit Limit) (bool, time.Duration, error)
+}
+
+// Connect returns the store selected by RATE_LIMIT_STORE. Use redis when several
+// replicas run behind a load balancer so they share their counts.
+func Connect() Store {
+	var store Store
+	switch backend := getEnv("RATE_LIMIT_STORE", "memory"); backend {
+	case "memory":
+		store = NewMemoryStore()
+	case "redis":
+		opts, err := redis.ParseURL(getEnv("REDIS_URL", "redis://localhost:6379/0"))
+		if err != nil {
+			log.Fatal("Invalid REDIS_URL:", err)
+		}
+		store = NewRedisStore(redis.NewClient(opts))
+	default:
+		log.Fatalf("Unknown RATE_LIMIT_STORE %q, expected memory or redis", backend)
+	}
+
+	log.Println("Rate limit store initialized!")
+	return store
+}
+
+// Helper functions
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
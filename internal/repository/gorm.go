@@ -0,0 +1,460 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"jam-tracker/internal/database"
+	"jam-tracker/internal/geo"
+	"jam-tracker/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NewGormRepositories returns repositories backed by the database
+func NewGormRepositories(db *gorm.DB) Repositories {
+	return Repositories{
+		Users:       &gormUsers{db: db},
+		Bands:       &gormBands{db: db},
+		Venues:      &gormVenues{db: db},
+		Shows:       &gormShows{db: db},
+		Attendances: &gormAttendances{db: db},
+	}
+}
+
+// first loads the first record matching the query, mapping a missing record to ErrNotFound
+func first[T any](query *gorm.DB) (T, error) {
+	var record T
+	err := query.First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return record, ErrNotFound
+	}
+	return record, err
+}
+
+type gormUsers struct {
+	db *gorm.DB
+}
+
+func (r *gormUsers) Create(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
+}
+
+func (r *gormUsers) FindByID(ctx context.Context, id uuid.UUID) (models.User, error) {
+	return first[models.User](r.db.WithContext(ctx).Where("id = ?", id))
+}
+
+func (r *gormUsers) FindByEmail(ctx context.Context, email string) (models.User, error) {
+	return first[models.User](r.db.WithContext(ctx).Where("email = ?", email))
+}
+
+func (r *gormUsers) FindByUsername(ctx context.Context, username string) (models.User, error) {
+	return first[models.User](r.db.WithContext(ctx).Where("username = ?", username))
+}
+
+func (r *gormUsers) FindByEmailOrUsername(ctx context.Context, email, username string) (models.User, error) {
+	return first[models.User](r.db.WithContext(ctx).Where("email = ? OR username = ?", email, username))
+}
+
+func (r *gormUsers) CountByRole(ctx context.Context, role models.Role) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.User{}).Where("role = ?", role).Count(&count).Error
+	return count, err
+}
+
+func (r *gormUsers) Update(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(user).Error
+}
+
+// Delete removes the user for good
+func (r *gormUsers) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Unscoped().Delete(&models.User{}, "id = ?", id).Error
+}
+
+type gormBands struct {
+	db *gorm.DB
+}
+
+func (r *gormBands) Create(ctx context.Context, band *models.Band) error {
+	return r.db.WithContext(ctx).Create(band).Error
+}
+
+func (r *gormBands) FindByID(ctx context.Context, id uuid.UUID) (models.Band, error) {
+	return first[models.Band](r.db.WithContext(ctx).Where("id = ?", id))
+}
+
+func (r *gormBands) FindByName(ctx context.Context, name string) (models.Band, error) {
+	return first[models.Band](r.db.WithContext(ctx).Where("name = ?", name))
+}
+
+func (r *gormBands) List(ctx context.Context, page PageRequest) (Page[models.Band], error) {
+	return Paginate(r.db.WithContext(ctx).Model(&models.Band{}), BandSorts, page)
+}
+
+func (r *gormBands) Update(ctx context.Context, band *models.Band) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(band).Error
+}
+
+// Delete removes the band along with its song catalog
+func (r *gormBands) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("band_id = ?", id).Delete(&models.Song{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Band{}, "id = ?", id).Error
+	})
+}
+
+type gormVenues struct {
+	db *gorm.DB
+}
+
+func (r *gormVenues) Create(ctx context.Context, venue *models.Venue) error {
+	return r.db.WithContext(ctx).Create(venue).Error
+}
+
+func (r *gormVenues) FindByID(ctx context.Context, id uuid.UUID) (models.Venue, error) {
+	return first[models.Venue](r.db.WithContext(ctx).Where("id = ?", id))
+}
+
+func (r *gormVenues) FindByName(ctx context.Context, name string) (models.Venue, error) {
+	return first[models.Venue](r.db.WithContext(ctx).Where("name = ?", name))
+}
+
+func (r *gormVenues) FindByLocation(ctx context.Context, name, city, state string) (models.Venue, error) {
+	return first[models.Venue](r.db.WithContext(ctx).Where("name = ? AND city = ? AND state = ?", name, city, state))
+}
+
+func (r *gormVenues) List(ctx context.Context, filter VenueFilter, page PageRequest) (Page[models.Venue], error) {
+	query := r.db.WithContext(ctx).Model(&models.Venue{})
+	dialect := database.DialectOf(r.db)
+
+	if filter.Name != "" {
+		query = query.Where(dialect.Contains("name"), database.ContainsPattern(filter.Name))
+	}
+	if filter.City != "" {
+		query = query.Where(dialect.Contains("city"), database.ContainsPattern(filter.City))
+	}
+	if filter.State != "" {
+		query = query.Where(dialect.Contains("state"), database.ContainsPattern(filter.State))
+	}
+	if filter.Country != "" {
+		query = query.Where(dialect.Contains("country"), database.ContainsPattern(filter.Country))
+	}
+	if filter.Near != nil {
+		ids, err := venuesNear(r.db.WithContext(ctx), *filter.Near)
+		if err != nil {
+			return Page[models.Venue]{}, err
+		}
+		query = query.Where("id IN ?", ids)
+	}
+
+	return Paginate(query, VenueSorts, page)
+}
+
+func (r *gormVenues) Update(ctx context.Context, venue *models.Venue) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(venue).Error
+}
+
+func (r *gormVenues) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.Venue{}, "id = ?", id).Error
+}
+
+// venuesNear returns the IDs of the venues inside the circle. The database only
+// narrows them down to a bounding box, the exact distance is measured here.
+func venuesNear(db *gorm.DB, near geo.Circle) ([]uuid.UUID, error) {
+	box := near.Bounds()
+	query := db.Model(&models.Venue{}).Select("id", "latitude", "longitude").
+		Where("latitude BETWEEN ? AND ?", box.MinLat, box.MaxLat)
+	if !box.AnyLng {
+		query = query.Where("longitude BETWEEN ? AND ?", box.MinLng, box.MaxLng)
+	}
+
+	var venues []models.Venue
+	if err := query.Where("longitude IS NOT NULL").Find(&venues).Error; err != nil {
+		return nil, err
+	}
+
+	ids := []uuid.UUID{}
+	for _, venue := range venues {
+		if near.Contains(geo.Point{Lat: *venue.Latitude, Lng: *venue.Longitude}) {
+			ids = append(ids, venue.ID)
+		}
+	}
+	return ids, nil
+}
+
+// PreloadShow loads the band, venue and bill of the shows at path, e.g. "Show."
+// for attendance records or "" for shows
+func PreloadShow(query *gorm.DB, path string) *gorm.DB {
+	return query.Preload(path+"Band").Preload(path+"Venue").
+		Preload(path+"Performances", byPosition).Preload(path + "Performances.Band")
+}
+
+// byPosition orders preloaded performances by billing order
+func byPosition(db *gorm.DB) *gorm.DB {
+	return db.Order("position")
+}
+
+type gormShows struct {
+	db *gorm.DB
+}
+
+func (r *gormShows) Create(ctx context.Context, show *models.Show) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(show).Error; err != nil {
+			return err
+		}
+		return saveBill(tx, show)
+	})
+	if err != nil {
+		return err
+	}
+	return r.preload(ctx, show)
+}
+
+func (r *gormShows) FindByID(ctx context.Context, id uuid.UUID) (models.Show, error) {
+	return first[models.Show](PreloadShow(r.db.WithContext(ctx), "").Where("id = ?", id))
+}
+
+func (r *gormShows) FindOnDate(ctx context.Context, bandID, venueID uuid.UUID, localDate string) (models.Show, error) {
+	return first[models.Show](PreloadShow(r.db.WithContext(ctx), "").
+		Where("venue_id = ? AND local_date = ?", venueID, localDate).
+		Where("id IN (?)", r.db.Model(&models.ShowPerformance{}).Select("show_id").Where("band_id = ?", bandID)))
+}
+
+func (r *gormShows) List(ctx context.Context, filter ShowFilter, page PageRequest) (Page[models.Show], error) {
+	query := PreloadShow(r.db.WithContext(ctx).Model(&models.Show{}), "")
+	dialect := database.DialectOf(r.db)
+
+	// Any band on the bill matches
+	if filter.Band != "" {
+		query = query.Where("shows.id IN (?)", r.db.Model(&models.ShowPerformance{}).Select("show_performances.show_id").
+			Joins("JOIN bands ON show_performances.band_id = bands.id").
+			Where(dialect.Contains("bands.name"), database.ContainsPattern(filter.Band)))
+	}
+
+	// Venue filters share a single join
+	if filter.Venue != "" || filter.City != "" || filter.State != "" {
+		query = query.Joins("JOIN venues ON shows.venue_id = venues.id")
+	}
+	if filter.Venue != "" {
+		query = query.Where(dialect.Contains("venues.name"), database.ContainsPattern(filter.Venue))
+	}
+	if filter.City != "" {
+		query = query.Where(dialect.Contains("venues.city"), database.ContainsPattern(filter.City))
+	}
+	if filter.State != "" {
+		query = query.Where(dialect.Contains("venues.state"), database.ContainsPattern(filter.State))
+	}
+
+	if filter.DateFrom != nil {
+		query = query.Where("shows.local_date >= ?", filter.DateFrom.Format("2006-01-02"))
+	}
+	if filter.DateTo != nil {
+		query = query.Where("shows.local_date <= ?", filter.DateTo.Format("2006-01-02"))
+	}
+	if filter.Near != nil {
+		ids, err := venuesNear(r.db.WithContext(ctx), *filter.Near)
+		if err != nil {
+			return Page[models.Show]{}, err
+		}
+		query = query.Where("shows.venue_id IN ?", ids)
+	}
+
+	return Paginate(query, ShowSorts, page)
+}
+
+func (r *gormShows) CountByVenue(ctx context.Context, venueID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Show{}).Where("venue_id = ?", venueID).Count(&count).Error
+	return count, err
+}
+
+func (r *gormShows) Update(ctx context.Context, show *models.Show) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(show).Error; err != nil {
+			return err
+		}
+		return saveBill(tx, show)
+	})
+	if err != nil {
+		return err
+	}
+	return r.preload(ctx, show)
+}
+
+func (r *gormShows) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		performances := tx.Model(&models.ShowPerformance{}).Select("id").Where("show_id = ?", id)
+		if err := tx.Where("performance_id IN (?)", performances).Delete(&models.CaughtPerformance{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("show_id = ?", id).Delete(&models.ShowPerformance{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Show{}, "id = ?", id).Error
+	})
+}
+
+// preload reloads the band, venue and bill of a show after they changed
+func (r *gormShows) preload(ctx context.Context, show *models.Show) error {
+	return PreloadShow(r.db.WithContext(ctx), "").First(show, "id = ?", show.ID).Error
+}
+
+// saveBill replaces the performances of a show with show.Performances. A band
+// that stays on the bill keeps its performance, and with it the users who caught it.
+func saveBill(tx *gorm.DB, show *models.Show) error {
+	var existing []models.ShowPerformance
+	if err := tx.Where("show_id = ?", show.ID).Find(&existing).Error; err != nil {
+		return err
+	}
+
+	byBand := make(map[uuid.UUID]models.ShowPerformance, len(existing))
+	for _, performance := range existing {
+		byBand[performance.BandID] = performance
+	}
+
+	for i := range show.Performances {
+		performance := &show.Performances[i]
+		performance.ShowID = show.ID
+		performance.Band = models.Band{}
+
+		if previous, ok := byBand[performance.BandID]; ok {
+			performance.ID, performance.CreatedAt = previous.ID, previous.CreatedAt
+			delete(byBand, performance.BandID)
+			if err := tx.Omit(clause.Associations).Save(performance).Error; err != nil {
+				return err
+			}
+			continue
+		}
+
+		performance.ID = uuid.Nil
+		if err := tx.Omit(clause.Associations).Create(performance).Error; err != nil {
+			return err
+		}
+	}
+
+	// Bands left on byBand were dropped from the bill
+	removed := []uuid.UUID{}
+	for _, performance := range byBand {
+		removed = append(removed, performance.ID)
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+	if err := tx.Where("performance_id IN ?", removed).Delete(&models.CaughtPerformance{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("id IN ?", removed).Delete(&models.ShowPerformance{}).Error
+}
+
+type gormAttendances struct {
+	db *gorm.DB
+}
+
+func (r *gormAttendances) Create(ctx context.Context, attendance *models.ShowAttendance) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(attendance).Error; err != nil {
+			return err
+		}
+		return saveCaughtPerformances(tx, attendance)
+	})
+	if err != nil {
+		return err
+	}
+	return r.preload(ctx, attendance)
+}
+
+func (r *gormAttendances) FindByID(ctx context.Context, id uuid.UUID) (models.ShowAttendance, error) {
+	return first[models.ShowAttendance](preloadAttendance(r.db.WithContext(ctx)).Where("id = ?", id))
+}
+
+func (r *gormAttendances) FindByUserAndShow(ctx context.Context, userID, showID uuid.UUID) (models.ShowAttendance, error) {
+	return first[models.ShowAttendance](preloadAttendance(r.db.WithContext(ctx)).
+		Where("user_id = ? AND show_id = ?", userID, showID))
+}
+
+func (r *gormAttendances) List(ctx context.Context, filter AttendanceFilter, page PageRequest) (Page[models.ShowAttendance], error) {
+	dialect := database.DialectOf(r.db)
+	query := preloadAttendance(r.db.WithContext(ctx)).
+		Joins("JOIN shows ON shows.id = show_attendances.show_id").
+		Where("show_attendances.user_id = ?", filter.UserID)
+
+	if len(filter.Statuses) > 0 {
+		query = query.Where("show_attendances.status IN ?", filter.Statuses)
+	}
+	if filter.ShowFrom != nil {
+		query = query.Where(dialect.Time("shows.date")+" >= "+dialect.Time("?"), *filter.ShowFrom)
+	}
+	if filter.ShowBefore != nil {
+		query = query.Where(dialect.Time("shows.date")+" < "+dialect.Time("?"), *filter.ShowBefore)
+	}
+
+	return Paginate(query, AttendanceSorts, page)
+}
+
+func (r *gormAttendances) Update(ctx context.Context, attendance *models.ShowAttendance) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(attendance).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("attendance_id = ?", attendance.ID).Delete(&models.CaughtPerformance{}).Error; err != nil {
+			return err
+		}
+		return saveCaughtPerformances(tx, attendance)
+	})
+	if err != nil {
+		return err
+	}
+	return r.preload(ctx, attendance)
+}
+
+func (r *gormAttendances) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.deleteWhere(ctx, "id = ?", id)
+}
+
+func (r *gormAttendances) DeleteByShow(ctx context.Context, showID uuid.UUID) error {
+	return r.deleteWhere(ctx, "show_id = ?", showID)
+}
+
+func (r *gormAttendances) DeleteByUser(ctx context.Context, userID uuid.UUID) error {
+	return r.deleteWhere(ctx, "user_id = ?", userID)
+}
+
+// deleteWhere removes the attendance records matching the condition along with
+// the performances they caught
+func (r *gormAttendances) deleteWhere(ctx context.Context, condition string, value any) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		attendances := tx.Model(&models.ShowAttendance{}).Select("id").Where(condition, value)
+		if err := tx.Where("attendance_id IN (?)", attendances).Delete(&models.CaughtPerformance{}).Error; err != nil {
+			return err
+		}
+		return tx.Where(condition, value).Delete(&models.ShowAttendance{}).Error
+	})
+}
+
+// preload reloads the show of an attendance record after it changed
+func (r *gormAttendances) preload(ctx context.Context, attendance *models.ShowAttendance) error {
+	return preloadAttendance(r.db.WithContext(ctx)).First(attendance, "id = ?", attendance.ID).Error
+}
+
+// preloadAttendance loads the show of attendance records and the performances caught
+func preloadAttendance(query *gorm.DB) *gorm.DB {
+	return PreloadShow(query, "Show.").Preload("CaughtPerformances", byPosition).Preload("CaughtPerformances.Band")
+}
+
+// saveCaughtPerformances records the performances in attendance.CaughtPerformances
+func saveCaughtPerformances(tx *gorm.DB, attendance *models.ShowAttendance) error {
+	if len(attendance.CaughtPerformances) == 0 {
+		return nil
+	}
+
+	caught := make([]models.CaughtPerformance, 0, len(attendance.CaughtPerformances))
+	for _, performance := range attendance.CaughtPerformances {
+		caught = append(caught, models.CaughtPerformance{AttendanceID: attendance.ID, PerformanceID: performance.ID})
+	}
+	return tx.Create(&caught).Error
+}
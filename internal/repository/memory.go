@@ -0,0 +1,547 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"jam-tracker/internal/geo"
+	"jam-tracker/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// memoryStore keeps every record in maps. It is shared by the memory
+// repositories so shows and attendances can be returned with their relations.
+type memoryStore struct {
+	mu          sync.RWMutex
+	users       map[uuid.UUID]models.User
+	bands       map[uuid.UUID]models.Band
+	venues      map[uuid.UUID]models.Venue
+	shows       map[uuid.UUID]models.Show
+	attendances map[uuid.UUID]models.ShowAttendance
+}
+
+// NewMemoryRepositories returns empty repositories that keep their records in memory.
+// They are meant for tests and do not persist anything.
+func NewMemoryRepositories() Repositories {
+	store := &memoryStore{
+		users:       make(map[uuid.UUID]models.User),
+		bands:       make(map[uuid.UUID]models.Band),
+		venues:      make(map[uuid.UUID]models.Venue),
+		shows:       make(map[uuid.UUID]models.Show),
+		attendances: make(map[uuid.UUID]models.ShowAttendance),
+	}
+
+	return Repositories{
+		Users:       &memoryUsers{store},
+		Bands:       &memoryBands{store},
+		Venues:      &memoryVenues{store},
+		Shows:       &memoryShows{store},
+		Attendances: &memoryAttendances{store},
+	}
+}
+
+// stamp fills in the ID and timestamps the database would set
+func stamp(id *uuid.UUID, createdAt, updatedAt *time.Time) {
+	now := time.Now()
+	if *id == uuid.Nil {
+		*id = uuid.New()
+	}
+	if createdAt.IsZero() {
+		*createdAt = now
+	}
+	*updatedAt = now
+}
+
+// findOne returns the first record matching the condition
+func findOne[T any](records map[uuid.UUID]T, match func(T) bool) (T, error) {
+	for _, record := range records {
+		if match(record) {
+			return record, nil
+		}
+	}
+
+	var zero T
+	return zero, ErrNotFound
+}
+
+type memoryUsers struct {
+	*memoryStore
+}
+
+func (r *memoryUsers) Create(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Same default as the role column
+	if user.Role == "" {
+		user.Role = models.RoleUser
+	}
+	stamp(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+	r.users[user.ID] = *user
+	return nil
+}
+
+func (r *memoryUsers) FindByID(ctx context.Context, id uuid.UUID) (models.User, error) {
+	return r.find(func(u models.User) bool { return u.ID == id })
+}
+
+func (r *memoryUsers) FindByEmail(ctx context.Context, email string) (models.User, error) {
+	return r.find(func(u models.User) bool { return u.Email == email })
+}
+
+func (r *memoryUsers) FindByUsername(ctx context.Context, username string) (models.User, error) {
+	return r.find(func(u models.User) bool { return u.Username == username })
+}
+
+func (r *memoryUsers) FindByEmailOrUsername(ctx context.Context, email, username string) (models.User, error) {
+	return r.find(func(u models.User) bool { return u.Email == email || u.Username == username })
+}
+
+func (r *memoryUsers) CountByRole(ctx context.Context, role models.Role) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var count int64
+	for _, user := range r.users {
+		if user.Role == role {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryUsers) Update(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[user.ID]; !ok {
+		return ErrNotFound
+	}
+	stamp(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+	r.users[user.ID] = *user
+	return nil
+}
+
+func (r *memoryUsers) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.users, id)
+	return nil
+}
+
+func (r *memoryUsers) find(match func(models.User) bool) (models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return findOne(r.users, match)
+}
+
+type memoryBands struct {
+	*memoryStore
+}
+
+func (r *memoryBands) Create(ctx context.Context, band *models.Band) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stamp(&band.ID, &band.CreatedAt, &band.UpdatedAt)
+	r.bands[band.ID] = *band
+	return nil
+}
+
+func (r *memoryBands) FindByID(ctx context.Context, id uuid.UUID) (models.Band, error) {
+	return r.find(func(b models.Band) bool { return b.ID == id })
+}
+
+func (r *memoryBands) FindByName(ctx context.Context, name string) (models.Band, error) {
+	return r.find(func(b models.Band) bool { return b.Name == name })
+}
+
+func (r *memoryBands) List(ctx context.Context, page PageRequest) (Page[models.Band], error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	bands := make([]models.Band, 0, len(r.bands))
+	for _, band := range r.bands {
+		bands = append(bands, band)
+	}
+	return paginateSlice(bands, BandSorts, page)
+}
+
+func (r *memoryBands) Update(ctx context.Context, band *models.Band) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.bands[band.ID]; !ok {
+		return ErrNotFound
+	}
+	stamp(&band.ID, &band.CreatedAt, &band.UpdatedAt)
+	r.bands[band.ID] = *band
+	return nil
+}
+
+func (r *memoryBands) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.bands, id)
+	return nil
+}
+
+func (r *memoryBands) find(match func(models.Band) bool) (models.Band, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return findOne(r.bands, match)
+}
+
+type memoryVenues struct {
+	*memoryStore
+}
+
+func (r *memoryVenues) Create(ctx context.Context, venue *models.Venue) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Same default as the country column, lowercase like every stored location
+	if venue.Country == "" {
+		venue.Country = "usa"
+	}
+	stamp(&venue.ID, &venue.CreatedAt, &venue.UpdatedAt)
+	r.venues[venue.ID] = *venue
+	return nil
+}
+
+func (r *memoryVenues) FindByID(ctx context.Context, id uuid.UUID) (models.Venue, error) {
+	return r.find(func(v models.Venue) bool { return v.ID == id })
+}
+
+func (r *memoryVenues) FindByName(ctx context.Context, name string) (models.Venue, error) {
+	return r.find(func(v models.Venue) bool { return v.Name == name })
+}
+
+func (r *memoryVenues) FindByLocation(ctx context.Context, name, city, state string) (models.Venue, error) {
+	return r.find(func(v models.Venue) bool { return v.Name == name && v.City == city && v.State == state })
+}
+
+func (r *memoryVenues) List(ctx context.Context, filter VenueFilter, page PageRequest) (Page[models.Venue], error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	venues := []models.Venue{}
+	for _, venue := range r.venues {
+		if strings.Contains(venue.Name, filter.Name) &&
+			strings.Contains(venue.City, filter.City) &&
+			strings.Contains(venue.State, filter.State) &&
+			strings.Contains(venue.Country, filter.Country) &&
+			inCircle(venue, filter.Near) {
+			venues = append(venues, venue)
+		}
+	}
+	return paginateSlice(venues, VenueSorts, page)
+}
+
+func (r *memoryVenues) Update(ctx context.Context, venue *models.Venue) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.venues[venue.ID]; !ok {
+		return ErrNotFound
+	}
+	stamp(&venue.ID, &venue.CreatedAt, &venue.UpdatedAt)
+	r.venues[venue.ID] = *venue
+	return nil
+}
+
+func (r *memoryVenues) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.venues, id)
+	return nil
+}
+
+func (r *memoryVenues) find(match func(models.Venue) bool) (models.Venue, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return findOne(r.venues, match)
+}
+
+// inCircle reports whether the venue has coordinates inside near, or near is nil
+func inCircle(venue models.Venue, near *geo.Circle) bool {
+	if near == nil {
+		return true
+	}
+	if venue.Latitude == nil || venue.Longitude == nil {
+		return false
+	}
+	return near.Contains(geo.Point{Lat: *venue.Latitude, Lng: *venue.Longitude})
+}
+
+type memoryShows struct {
+	*memoryStore
+}
+
+func (r *memoryShows) Create(ctx context.Context, show *models.Show) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stamp(&show.ID, &show.CreatedAt, &show.UpdatedAt)
+	show.Band, show.Venue = models.Band{}, models.Venue{}
+	show.Performances = stampBill(show.ID, show.Performances, nil)
+	r.shows[show.ID] = *show
+	*show = r.withRelations(*show)
+	return nil
+}
+
+func (r *memoryShows) FindByID(ctx context.Context, id uuid.UUID) (models.Show, error) {
+	return r.find(func(s models.Show) bool { return s.ID == id })
+}
+
+func (r *memoryShows) FindOnDate(ctx context.Context, bandID, venueID uuid.UUID, localDate string) (models.Show, error) {
+	return r.find(func(s models.Show) bool {
+		return s.VenueID == venueID && s.LocalDate == localDate &&
+			slices.ContainsFunc(s.Performances, func(p models.ShowPerformance) bool { return p.BandID == bandID })
+	})
+}
+
+func (r *memoryShows) List(ctx context.Context, filter ShowFilter, page PageRequest) (Page[models.Show], error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	shows := []models.Show{}
+	for _, show := range r.shows {
+		show = r.withRelations(show)
+
+		// Any band on the bill matches
+		onBill := filter.Band == "" || slices.ContainsFunc(show.Performances, func(p models.ShowPerformance) bool {
+			return strings.Contains(strings.ToLower(p.Band.Name), filter.Band)
+		})
+
+		if !onBill ||
+			!strings.Contains(strings.ToLower(show.Venue.Name), filter.Venue) ||
+			!strings.Contains(strings.ToLower(show.Venue.City), filter.City) ||
+			!strings.Contains(strings.ToLower(show.Venue.State), filter.State) ||
+			!inCircle(show.Venue, filter.Near) {
+			continue
+		}
+
+		if filter.DateFrom != nil && show.LocalDate < filter.DateFrom.Format("2006-01-02") {
+			continue
+		}
+		if filter.DateTo != nil && show.LocalDate > filter.DateTo.Format("2006-01-02") {
+			continue
+		}
+
+		shows = append(shows, show)
+	}
+	return paginateSlice(shows, ShowSorts, page)
+}
+
+func (r *memoryShows) CountByVenue(ctx context.Context, venueID uuid.UUID) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var count int64
+	for _, show := range r.shows {
+		if show.VenueID == venueID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryShows) Update(ctx context.Context, show *models.Show) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	previous, ok := r.shows[show.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	stamp(&show.ID, &show.CreatedAt, &show.UpdatedAt)
+	show.Band, show.Venue = models.Band{}, models.Venue{}
+	show.Performances = stampBill(show.ID, show.Performances, previous.Performances)
+	r.shows[show.ID] = *show
+	*show = r.withRelations(*show)
+	return nil
+}
+
+func (r *memoryShows) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.shows, id)
+	return nil
+}
+
+func (r *memoryShows) find(match func(models.Show) bool) (models.Show, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	show, err := findOne(r.shows, match)
+	if err != nil {
+		return show, err
+	}
+	return r.withRelations(show), nil
+}
+
+// stampBill fills in the IDs and timestamps of a bill. A band that stays on the
+// bill keeps the ID of its previous performance, like in the database.
+func stampBill(showID uuid.UUID, performances, previous []models.ShowPerformance) []models.ShowPerformance {
+	bill := make([]models.ShowPerformance, len(performances))
+	for i, performance := range performances {
+		performance.ID, performance.CreatedAt = uuid.Nil, time.Time{}
+		for _, old := range previous {
+			if old.BandID == performance.BandID {
+				performance.ID, performance.CreatedAt = old.ID, old.CreatedAt
+			}
+		}
+		performance.ShowID = showID
+		performance.Band = models.Band{}
+		stamp(&performance.ID, &performance.CreatedAt, &performance.UpdatedAt)
+		bill[i] = performance
+	}
+	return bill
+}
+
+// withRelations fills in the band, venue and bill of a show. The caller must hold the lock.
+func (s *memoryStore) withRelations(show models.Show) models.Show {
+	show.Band = s.bands[show.BandID]
+	show.Venue = s.venues[show.VenueID]
+
+	performances := make([]models.ShowPerformance, len(show.Performances))
+	for i, performance := range show.Performances {
+		performance.Band = s.bands[performance.BandID]
+		performances[i] = performance
+	}
+	show.Performances = performances
+	return show
+}
+
+type memoryAttendances struct {
+	*memoryStore
+}
+
+func (r *memoryAttendances) Create(ctx context.Context, attendance *models.ShowAttendance) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Same default as the status column
+	if attendance.Status == "" {
+		attendance.Status = models.AttendanceStatusAttended
+	}
+	stamp(&attendance.ID, &attendance.CreatedAt, &attendance.UpdatedAt)
+	attendance.Show, attendance.User = models.Show{}, models.User{}
+	r.attendances[attendance.ID] = *attendance
+	*attendance = r.withShow(*attendance)
+	return nil
+}
+
+func (r *memoryAttendances) FindByID(ctx context.Context, id uuid.UUID) (models.ShowAttendance, error) {
+	return r.find(func(a models.ShowAttendance) bool { return a.ID == id })
+}
+
+func (r *memoryAttendances) FindByUserAndShow(ctx context.Context, userID, showID uuid.UUID) (models.ShowAttendance, error) {
+	return r.find(func(a models.ShowAttendance) bool { return a.UserID == userID && a.ShowID == showID })
+}
+
+func (r *memoryAttendances) List(ctx context.Context, filter AttendanceFilter, page PageRequest) (Page[models.ShowAttendance], error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	attendances := []models.ShowAttendance{}
+	for _, attendance := range r.attendances {
+		attendance = r.withShow(attendance)
+
+		if attendance.UserID != filter.UserID {
+			continue
+		}
+		if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, attendance.Status) {
+			continue
+		}
+		if filter.ShowFrom != nil && attendance.Show.Date.Before(*filter.ShowFrom) {
+			continue
+		}
+		if filter.ShowBefore != nil && !attendance.Show.Date.Before(*filter.ShowBefore) {
+			continue
+		}
+
+		attendances = append(attendances, attendance)
+	}
+	return paginateSlice(attendances, AttendanceSorts, page)
+}
+
+func (r *memoryAttendances) Update(ctx context.Context, attendance *models.ShowAttendance) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.attendances[attendance.ID]; !ok {
+		return ErrNotFound
+	}
+	stamp(&attendance.ID, &attendance.CreatedAt, &attendance.UpdatedAt)
+	attendance.Show, attendance.User = models.Show{}, models.User{}
+	r.attendances[attendance.ID] = *attendance
+	*attendance = r.withShow(*attendance)
+	return nil
+}
+
+func (r *memoryAttendances) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.attendances, id)
+	return nil
+}
+
+func (r *memoryAttendances) DeleteByShow(ctx context.Context, showID uuid.UUID) error {
+	return r.deleteWhere(func(a models.ShowAttendance) bool { return a.ShowID == showID })
+}
+
+func (r *memoryAttendances) DeleteByUser(ctx context.Context, userID uuid.UUID) error {
+	return r.deleteWhere(func(a models.ShowAttendance) bool { return a.UserID == userID })
+}
+
+func (r *memoryAttendances) deleteWhere(match func(models.ShowAttendance) bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, attendance := range r.attendances {
+		if match(attendance) {
+			delete(r.attendances, id)
+		}
+	}
+	return nil
+}
+
+func (r *memoryAttendances) find(match func(models.ShowAttendance) bool) (models.ShowAttendance, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	attendance, err := findOne(r.attendances, match)
+	if err != nil {
+		return attendance, err
+	}
+	return r.withShow(attendance), nil
+}
+
+// withShow fills in the show of an attendance record and the performances caught,
+// dropping those no longer on the bill. The caller must hold the lock.
+func (s *memoryStore) withShow(attendance models.ShowAttendance) models.ShowAttendance {
+	attendance.Show = s.withRelations(s.shows[attendance.ShowID])
+
+	caught := []models.ShowPerformance{}
+	for _, performance := range attendance.Show.Performances {
+		if slices.ContainsFunc(attendance.CaughtPerformances, func(p models.ShowPerformance) bool { return p.ID == performance.ID }) {
+			caught = append(caught, performance)
+		}
+	}
+	attendance.CaughtPerformances = caught
+	return attendance
+}
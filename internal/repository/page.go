@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"jam-tracker/internal/database"
+	"jam-tracker/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SortType tells how the values of a sort field compare and how cursors decode them
+type SortType int
+
+const (
+	SortText SortType = iota
+	SortTime
+	SortNumber
+)
+
+// SortField is a column a list can be sorted by
+type SortField[T any] struct {
+	Column string      // Qualified column, e.g. "shows.date"
+	Type   SortType    // Type of the column
+	Value  func(T) any // Reads the column value from a row
+}
+
+// SortSpec lists the sort fields of one kind of row
+type SortSpec[T any] struct {
+	Fields   map[string]SortField[T] // Allow-list for ?sort=
+	IDColumn string                  // Qualified ID column used to break ties, e.g. "shows.id"
+	ID       func(T) uuid.UUID
+}
+
+// Keys returns the sort field names in alphabetical order
+func (s SortSpec[T]) Keys() []string {
+	keys := make([]string, 0, len(s.Fields))
+	for key := range s.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Cursor is the position of the last row of a page
+type Cursor struct {
+	Value any
+	ID    uuid.UUID
+}
+
+// PageRequest selects one page of a sorted list
+type PageRequest struct {
+	Limit int
+	Sort  string // Key of a field in the SortSpec
+	Desc  bool
+	After *Cursor // Continue after this row, nil for the first page
+}
+
+// Page is one page of results
+type Page[T any] struct {
+	Items []T
+	Total int64   // Number of results across all pages
+	Next  *Cursor // Nil on the last page
+}
+
+// Sort specs of the list endpoints backed by repositories
+var (
+	ShowSorts = SortSpec[models.Show]{
+		Fields: map[string]SortField[models.Show]{
+			"date":       {Column: "shows.date", Type: SortTime, Value: func(s models.Show) any { return s.Date }},
+			"created_at": {Column: "shows.created_at", Type: SortTime, Value: func(s models.Show) any { return s.CreatedAt }},
+		},
+		IDColumn: "shows.id",
+		ID:       func(s models.Show) uuid.UUID { return s.ID },
+	}
+
+	BandSorts = SortSpec[models.Band]{
+		Fields: map[string]SortField[models.Band]{
+			"name":       {Column: "bands.name", Type: SortText, Value: func(b models.Band) any { return b.Name }},
+			"genre":      {Column: "bands.genre", Type: SortText, Value: func(b models.Band) any { return b.Genre }},
+			"created_at": {Column: "bands.created_at", Type: SortTime, Value: func(b models.Band) any { return b.CreatedAt }},
+		},
+		IDColumn: "bands.id",
+		ID:       func(b models.Band) uuid.UUID { return b.ID },
+	}
+
+	VenueSorts = SortSpec[models.Venue]{
+		Fields: map[string]SortField[models.Venue]{
+			"name":       {Column: "venues.name", Type: SortText, Value: func(v models.Venue) any { return v.Name }},
+			"city":       {Column: "venues.city", Type: SortText, Value: func(v models.Venue) any { return v.City }},
+			"state":      {Column: "venues.state", Type: SortText, Value: func(v models.Venue) any { return v.State }},
+			"created_at": {Column: "venues.created_at", Type: SortTime, Value: func(v models.Venue) any { return v.CreatedAt }},
+		},
+		IDColumn: "venues.id",
+		ID:       func(v models.Venue) uuid.UUID { return v.ID },
+	}
+
+	// AttendanceSorts expect the query to join shows
+	AttendanceSorts = SortSpec[models.ShowAttendance]{
+		Fields: map[string]SortField[models.ShowAttendance]{
+			"date":       {Column: "shows.date", Type: SortTime, Value: func(a models.ShowAttendance) any { return a.Show.Date }},
+			"created_at": {Column: "show_attendances.created_at", Type: SortTime, Value: func(a models.ShowAttendance) any { return a.CreatedAt }},
+		},
+		IDColumn: "show_attendances.id",
+		ID:       func(a models.ShowAttendance) uuid.UUID { return a.ID },
+	}
+)
+
+// Paginate loads one page of a GORM query using keyset pagination
+func Paginate[T any](query *gorm.DB, spec SortSpec[T], req PageRequest) (Page[T], error) {
+	field, ok := spec.Fields[req.Sort]
+	if !ok {
+		return Page[T]{}, fmt.Errorf("unknown sort %q", req.Sort)
+	}
+
+	// Count before the cursor narrows the query
+	var page Page[T]
+	if err := query.Session(&gorm.Session{}).Model(new(T)).Count(&page.Total).Error; err != nil {
+		return Page[T]{}, err
+	}
+
+	op, order := ">", "asc"
+	if req.Desc {
+		op, order = "<", "desc"
+	}
+
+	// Times compare through the dialect, everything else compares as stored
+	column, placeholder := field.Column, "?"
+	if field.Type == SortTime {
+		dialect := database.DialectOf(query)
+		column, placeholder = dialect.Time(column), dialect.Time(placeholder)
+	}
+
+	// Continue after the last row of the previous page
+	if req.After != nil {
+		query = query.Where(fmt.Sprintf("%s %s %s OR (%s = %s AND %s %s ?)", column, op, placeholder, column, placeholder, spec.IDColumn, op),
+			req.After.Value, req.After.Value, req.After.ID)
+	}
+
+	if err := query.Order(fmt.Sprintf("%s %s, %s %s", column, order, spec.IDColumn, order)).
+		Limit(req.Limit + 1).
+		Find(&page.Items).Error; err != nil {
+		return Page[T]{}, err
+	}
+
+	return trimPage(page, spec, field, req), nil
+}
+
+// paginateSlice is the in-memory counterpart of Paginate
+func paginateSlice[T any](items []T, spec SortSpec[T], req PageRequest) (Page[T], error) {
+	field, ok := spec.Fields[req.Sort]
+	if !ok {
+		return Page[T]{}, fmt.Errorf("unknown sort %q", req.Sort)
+	}
+
+	// Compare by the sort field, then by ID
+	compare := func(a T, aID uuid.UUID, value any, id uuid.UUID) int {
+		if n := compareValues(field.Value(a), value); n != 0 {
+			return n
+		}
+		return strings.Compare(aID.String(), id.String())
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		n := compare(items[i], spec.ID(items[i]), field.Value(items[j]), spec.ID(items[j]))
+		if req.Desc {
+			return n > 0
+		}
+		return n < 0
+	})
+
+	page := Page[T]{Total: int64(len(items))}
+	for _, item := range items {
+		if req.After != nil {
+			n := compare(item, spec.ID(item), req.After.Value, req.After.ID)
+			if (!req.Desc && n <= 0) || (req.Desc && n >= 0) {
+				continue
+			}
+		}
+		page.Items = append(page.Items, item)
+		if len(page.Items) > req.Limit {
+			break
+		}
+	}
+
+	return trimPage(page, spec, field, req), nil
+}
+
+// trimPage drops the extra row loaded to detect a next page and builds its cursor
+func trimPage[T any](page Page[T], spec SortSpec[T], field SortField[T], req PageRequest) Page[T] {
+	if len(page.Items) > req.Limit {
+		page.Items = page.Items[:req.Limit]
+		last := page.Items[len(page.Items)-1]
+		page.Next = &Cursor{Value: field.Value(last), ID: spec.ID(last)}
+	}
+
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+	return page
+}
+
+func compareValues(a, b any) int {
+	switch a := a.(type) {
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	case string:
+		return strings.Compare(a, b.(string))
+	case int:
+		return compareNumbers(float64(a), b)
+	case int64:
+		return compareNumbers(float64(a), b)
+	case float64:
+		return compareNumbers(a, b)
+	}
+	return 0
+}
+
+func compareNumbers(a float64, b any) int {
+	var other float64
+	switch b := b.(type) {
+	case int:
+		other = float64(b)
+	case int64:
+		other = float64(b)
+	case float64:
+		other = b
+	}
+
+	switch {
+	case a < other:
+		return -1
+	case a > other:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"jam-tracker/internal/geo"
+	"jam-tracker/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// ErrNotFound is returned when no record matches a lookup
+var ErrNotFound = errors.New("repository: record not found")
+
+// UserRepository stores user accounts
+type UserRepository interface {
+	Create(ctx context.Context, user *models.User) error
+	FindByID(ctx context.Context, id uuid.UUID) (models.User, error)
+	FindByEmail(ctx context.Context, email string) (models.User, error)
+	FindByUsername(ctx context.Context, username string) (models.User, error)
+	// FindByEmailOrUsername returns any user using either the email or the username
+	FindByEmailOrUsername(ctx context.Context, email, username string) (models.User, error)
+	CountByRole(ctx context.Context, role models.Role) (int64, error)
+	Update(ctx context.Context, user *models.User) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// BandRepository stores bands. Names are stored lowercase.
+type BandRepository interface {
+	Create(ctx context.Context, band *models.Band) error
+	FindByID(ctx context.Context, id uuid.UUID) (models.Band, error)
+	FindByName(ctx context.Context, name string) (models.Band, error)
+	List(ctx context.Context, page PageRequest) (Page[models.Band], error)
+	Update(ctx context.Context, band *models.Band) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// VenueFilter narrows a venue list. Each set string field matches values containing it.
+type VenueFilter struct {
+	Name    string
+	City    string
+	State   string
+	Country string
+	Near    *geo.Circle // Venues with coordinates inside the circle
+}
+
+// VenueRepository stores venues. Names, cities and states are stored lowercase.
+type VenueRepository interface {
+	Create(ctx context.Context, venue *models.Venue) error
+	FindByID(ctx context.Context, id uuid.UUID) (models.Venue, error)
+	FindByName(ctx context.Context, name string) (models.Venue, error)
+	FindByLocation(ctx context.Context, name, city, state string) (models.Venue, error)
+	List(ctx context.Context, filter VenueFilter, page PageRequest) (Page[models.Venue], error)
+	Update(ctx context.Context, venue *models.Venue) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// ShowFilter narrows a show list. Name filters match values containing them,
+// ignoring case. Dates compare by the calendar day at the venue.
+type ShowFilter struct {
+	Band     string // Any band on the bill
+	Venue    string
+	City     string
+	State    string
+	DateFrom *time.Time
+	DateTo   *time.Time
+	Near     *geo.Circle // Shows at venues with coordinates inside the circle
+}
+
+// ShowRepository stores shows. Shows are returned with their band, venue and
+// bill. Create and Update save the bill in Performances along with the show.
+type ShowRepository interface {
+	Create(ctx context.Context, show *models.Show) error
+	FindByID(ctx context.Context, id uuid.UUID) (models.Show, error)
+	// FindOnDate returns the show with the band on the bill at the venue on a
+	// calendar day at the venue, given as YYYY-MM-DD
+	FindOnDate(ctx context.Context, bandID, venueID uuid.UUID, localDate string) (models.Show, error)
+	List(ctx context.Context, filter ShowFilter, page PageRequest) (Page[models.Show], error)
+	CountByVenue(ctx context.Context, venueID uuid.UUID) (int64, error)
+	Update(ctx context.Context, show *models.Show) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// AttendanceFilter narrows a user's attendance list
+type AttendanceFilter struct {
+	UserID     uuid.UUID
+	Statuses   []models.AttendanceStatus // Any status when empty
+	ShowFrom   *time.Time                // Shows on or after this time
+	ShowBefore *time.Time                // Shows strictly before this time
+}
+
+// AttendanceRepository stores show attendance records. Records are returned
+// with their show, band, venue and the performances caught, which Create and
+// Update save along with the record.
+type AttendanceRepository interface {
+	Create(ctx context.Context, attendance *models.ShowAttendance) error
+	FindByID(ctx context.Context, id uuid.UUID) (models.ShowAttendance, error)
+	FindByUserAndShow(ctx context.Context, userID, showID uuid.UUID) (models.ShowAttendance, error)
+	List(ctx context.Context, filter AttendanceFilter, page PageRequest) (Page[models.ShowAttendance], error)
+	Update(ctx context.Context, attendance *models.ShowAttendance) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	DeleteByShow(ctx context.Context, showID uuid.UUID) error
+	DeleteByUser(ctx context.Context, userID uuid.UUID) error
+}
+
+// Repositories groups the repositories of one backend
+type Repositories struct {
+	Users       UserRepository
+	Bands       BandRepository
+	Venues      VenueRepository
+	Shows       ShowRepository
+	Attendances AttendanceRepository
+}
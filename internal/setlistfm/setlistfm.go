@@ -0,0 +1,169 @@
+package setlistfm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+const (
+	DefaultBaseURL  = "https://api.setlist.fm/rest/1.0"
+	eventDateFormat = "02-01-2006" // setlist.fm uses dd-MM-yyyy
+)
+
+// ErrNotFound is returned when setlist.fm has no setlist for the requested ID
+var ErrNotFound = errors.New("setlist not found on setlist.fm")
+
+// Setlist mirrors the setlist object returned by the setlist.fm API
+type Setlist struct {
+	ID        string `json:"id"`
+	VersionID string `json:"versionId"`
+	EventDate string `json:"eventDate"`
+	Artist    Artist `json:"artist"`
+	Venue     Venue  `json:"venue"`
+	Tour      *Tour  `json:"tour,omitempty"`
+	Sets      Sets   `json:"sets"`
+	Info      string `json:"info"`
+	URL       string `json:"url"`
+}
+
+type Artist struct {
+	MBID           string `json:"mbid"`
+	Name           string `json:"name"`
+	SortName       string `json:"sortName"`
+	Disambiguation string `json:"disambiguation"`
+}
+
+type Venue struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	City City   `json:"city"`
+}
+
+type City struct {
+	ID        string  `json:"id"`
+	Name      string  `json:"name"`
+	State     string  `json:"state"`
+	StateCode string  `json:"stateCode"`
+	Coords    Coords  `json:"coords"`
+	Country   Country `json:"country"`
+}
+
+type Coords struct {
+	Lat  float64 `json:"lat"`
+	Long float64 `json:"long"`
+}
+
+type Country struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+type Tour struct {
+	Name string `json:"name"`
+}
+
+type Sets struct {
+	Set []Set `json:"set"`
+}
+
+type Set struct {
+	Name   string `json:"name"`
+	Encore int    `json:"encore"` // 1 for the first encore, 2 for the second, 0 otherwise
+	Song   []Song `json:"song"`
+}
+
+type Song struct {
+	Name string `json:"name"`
+	Info string `json:"info"`
+	Tape bool   `json:"tape"` // Played from tape rather than live
+}
+
+// Date parses the event date, which setlist.fm gives without a time
+func (s Setlist) Date() (time.Time, error) {
+	return time.Parse(eventDateFormat, s.EventDate)
+}
+
+// Parse decodes a setlist from setlist.fm API JSON and checks the fields an import needs
+func Parse(data []byte) (*Setlist, error) {
+	var setlist Setlist
+	if err := json.Unmarshal(data, &setlist); err != nil {
+		return nil, fmt.Errorf("invalid setlist JSON: %w", err)
+	}
+
+	switch {
+	case setlist.ID == "":
+		return nil, errors.New("setlist id is required")
+	case setlist.Artist.Name == "":
+		return nil, errors.New("setlist artist name is required")
+	case setlist.Venue.Name == "" || setlist.Venue.City.Name == "":
+		return nil, errors.New("setlist venue name and city are required")
+	}
+
+	if _, err := setlist.Date(); err != nil {
+		return nil, fmt.Errorf("invalid eventDate %q, expected dd-MM-yyyy", setlist.EventDate)
+	}
+
+	return &setlist, nil
+}
+
+// Client fetches setlists from the setlist.fm API or any server that mimics it
+type Client struct {
+	BaseURL    string
+	APIKey     string
+	HTTPClient *http.Client
+}
+
+// NewClientFromEnv builds a client from SETLISTFM_BASE_URL and SETLISTFM_API_KEY.
+// Point SETLISTFM_BASE_URL at a local mock to avoid calling the real API.
+func NewClientFromEnv() *Client {
+	baseURL := os.Getenv("SETLISTFM_BASE_URL")
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	return &Client{
+		BaseURL:    baseURL,
+		APIKey:     os.Getenv("SETLISTFM_API_KEY"),
+		HTTPClient: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// GetSetlist fetches a single setlist by its setlist.fm ID
+func (c *Client) GetSetlist(ctx context.Context, setlistID string) (*Setlist, error) {
+	endpoint := fmt.Sprintf("%s/setlist/%s", c.BaseURL, url.PathEscape(setlistID))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.APIKey != "" {
+		req.Header.Set("x-api-key", c.APIKey)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("setlist.fm returned status %d", resp.StatusCode)
+	}
+
+	var body json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("invalid setlist.fm response: %w", err)
+	}
+
+	return Parse(body)
+}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+)
+
+// ErrNotFound is returned when no object exists for a key
+var ErrNotFound = errors.New("storage: object not found")
+
+// Storage stores uploaded files under slash-separated keys
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+var Media Storage
+
+// Connect initializes the media storage backend selected by MEDIA_STORAGE
+func Connect() {
+	var err error
+
+	switch backend := getEnv("MEDIA_STORAGE", "local"); backend {
+	case "local":
+		Media, err = NewLocalStorage(getEnv("MEDIA_LOCAL_DIR", "./uploads"))
+	case "s3":
+		Media, err = NewS3Storage(S3Config{
+			Endpoint:  getEnv("S3_ENDPOINT", "localhost:9000"),
+			AccessKey: getEnv("S3_ACCESS_KEY", ""),
+			SecretKey: getEnv("S3_SECRET_KEY", ""),
+			Bucket:    getEnv("S3_BUCKET", "jamtracker-media"),
+			Region:    getEnv("S3_REGION", ""),
+			UseSSL:    getEnv("S3_USE_SSL", "false") == "true",
+		})
+	default:
+		log.Fatalf("Unknown MEDIA_STORAGE %q, expected local or s3", backend)
+	}
+
+	if err != nil {
+		log.Fatal("Failed to initialize media storage:", err)
+	}
+
+	log.Println("Media storage initialized!")
+}
+
+// Helper functions
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}